@@ -23,6 +23,11 @@ import (
 
 const (
 	unsetCapacity = -1
+
+	// MinLoadFactor is the minimum load factor that can be passed to the Builder.LoadFactor.
+	MinLoadFactor = 0.25
+	// MaxLoadFactor is the maximum load factor that can be passed to the Builder.LoadFactor.
+	MaxLoadFactor = 1.0
 )
 
 var (
@@ -30,25 +35,139 @@ var (
 	ErrIllegalCapacity = errors.New("capacity should be positive")
 	// ErrIllegalInitialCapacity means that a non-positive capacity has been passed to the Builder.InitialCapacity.
 	ErrIllegalInitialCapacity = errors.New("initial capacity should be positive")
+	// ErrIllegalLoadFactor means that a load factor out of the [MinLoadFactor, MaxLoadFactor] range
+	// has been passed to the Builder.LoadFactor.
+	ErrIllegalLoadFactor = errors.New("load factor should be in the [0.25, 1] range")
 	// ErrNilCostFunc means that a nil cost func has been passed to the Builder.Cost.
 	ErrNilCostFunc = errors.New("setCostFunc func should not be nil")
+	// ErrNilStatsRecorder means that a nil recorder has been passed to the Builder.RecordStats.
+	ErrNilStatsRecorder = errors.New("stats recorder should not be nil")
+	// ErrNilInterningFunc means that a nil equal or hash func has been passed to the Builder.ValueInterning.
+	ErrNilInterningFunc = errors.New("value interning funcs should not be nil")
+	// ErrNilHasher means that a nil hash func has been passed to the Builder.Hasher.
+	ErrNilHasher = errors.New("hasher should not be nil")
+	// ErrIllegalCPUAffinity means that an empty set or a negative CPU id has been passed to the Builder.CPUAffinity.
+	ErrIllegalCPUAffinity = errors.New("cpu affinity should be a non-empty set of non-negative cpu ids")
+	// ErrIllegalLoaderRateLimit means that a non-positive rate or an unknown behavior has been passed
+	// to the Builder.LoaderRateLimit.
+	ErrIllegalLoaderRateLimit = errors.New("loader rate limit should be positive and the behavior should be known")
+	// ErrIllegalLoaderCircuitBreaker means that a non-positive threshold or cooldown has been passed
+	// to the Builder.LoaderCircuitBreaker.
+	ErrIllegalLoaderCircuitBreaker = errors.New("loader circuit breaker threshold and cooldown should be positive")
+	// ErrIllegalLoaderRetry means that a non-positive number of attempts or a nil backoff policy has been passed
+	// to the Builder.LoaderRetry.
+	ErrIllegalLoaderRetry = errors.New("loader max attempts should be positive and backoff policy should not be nil")
+	// ErrIllegalTimeResolution means that a non-positive or too coarse resolution has been passed
+	// to the Builder.TimeResolution.
+	ErrIllegalTimeResolution = errors.New("time resolution should be positive and not greater than a second")
+	// ErrIllegalWarmUpThreshold means that a threshold outside of (0, 1] has been passed to the Builder.WarmUpThreshold.
+	ErrIllegalWarmUpThreshold = errors.New("warm-up threshold should be in (0, 1]")
+	// ErrIllegalEvictionPolicy means that an unknown policy has been passed to the Builder.EvictionPolicy.
+	ErrIllegalEvictionPolicy = errors.New("unknown eviction policy")
 	// ErrIllegalTTL means that a non-positive ttl has been passed to the Builder.WithTTL.
 	ErrIllegalTTL = errors.New("ttl should be positive")
+	// ErrIllegalPreExpiryLead means that a non-positive lead time has been passed to the PreExpiryCallback.
+	ErrIllegalPreExpiryLead = errors.New("pre-expiry lead time should be positive")
+	// ErrIllegalCleanupInterval means that a non-positive cleanup interval has been passed to the Builder
+	// or the min cleanup interval is greater than the max one.
+	ErrIllegalCleanupInterval = errors.New("cleanup interval should be positive and min should not exceed max")
+	// ErrIllegalCleanupBatchSize means that a negative cleanup batch size has been passed to the Builder.
+	ErrIllegalCleanupBatchSize = errors.New("cleanup batch size should not be negative")
+	// ErrIllegalCleanupConcurrency means that a negative cleanup concurrency has been passed to the Builder.
+	ErrIllegalCleanupConcurrency = errors.New("cleanup concurrency should not be negative")
 )
 
 type baseOptions[K comparable, V any] struct {
-	capacity         int
-	initialCapacity  int
-	statsEnabled     bool
-	withCost         bool
-	costFunc         func(key K, value V) uint32
-	deletionListener func(key K, value V, cause DeletionCause)
+	capacity              int
+	initialCapacity       int
+	statsEnabled          bool
+	withCost              bool
+	costFunc              func(key K, value V) uint32
+	deletionListener      func(key K, value V, cause DeletionCause)
+	deletionBatchListener func(entries []DeletedEntry[K, V])
+	dryRun                bool
+	withLastAccess        bool
+	withCreatedAt         bool
+	timeResolution        time.Duration
+	warmUpThreshold       float64
+	withWarmUp            bool
+	eventBus              *EventBus[K, V]
+	events                *eventChannel[K, V]
+	evictionPolicy        EvictionPolicy
+	compact               bool
+	manualCleanup         bool
+	panicHandler          func(stage string, r any)
+	cloneFunc             func(value V) V
+	equalFunc             func(a, b V) bool
+	trackWriteLatency     bool
+	name                  string
+	withTrace             bool
+	preExpiryCallback     func(key K, value V)
+	preExpiryLead         time.Duration
+	minCleanupInterval    time.Duration
+	maxCleanupInterval    time.Duration
+	cleanupBatchSize      int
+	cleanupConcurrency    int
+	loadFactor            float64
+	withLoadFactor        bool
+	internEqual           func(a, b V) bool
+	internHash            func(v V) uint64
+	withValueInterning    bool
+	statsRecorder         StatsRecorder
+	withStatsRecorder     bool
+	cpuAffinity           []int
+	withCPUAffinity       bool
+	hashFunc              func(key K) uint64
+	withHasher            bool
+	loaderRateLimit       float64
+	rateLimitBehavior     RateLimitBehavior
+	withLoaderRateLimit   bool
+	loaderFailures        int
+	loaderCooldown        time.Duration
+	withCircuitBreaker    bool
+	loaderMaxAttempts     int
+	loaderBackoff         BackoffPolicy
+	withLoaderRetry       bool
 }
 
 func (o *baseOptions[K, V]) collectStats() {
 	o.statsEnabled = true
 }
 
+func (o *baseOptions[K, V]) setStatsRecorder(recorder StatsRecorder) {
+	o.statsEnabled = true
+	o.statsRecorder = recorder
+	o.withStatsRecorder = true
+}
+
+func (o *baseOptions[K, V]) setCPUAffinity(cpus []int) {
+	o.cpuAffinity = append([]int(nil), cpus...)
+	o.withCPUAffinity = true
+}
+
+func (o *baseOptions[K, V]) setLoaderRateLimit(rps float64, behavior RateLimitBehavior) {
+	o.loaderRateLimit = rps
+	o.rateLimitBehavior = behavior
+	o.withLoaderRateLimit = true
+}
+
+func (o *baseOptions[K, V]) setLoaderCircuitBreaker(threshold int, cooldown time.Duration) {
+	o.loaderFailures = threshold
+	o.loaderCooldown = cooldown
+	o.withCircuitBreaker = true
+}
+
+func (o *baseOptions[K, V]) setLoaderRetry(maxAttempts int, backoff BackoffPolicy) {
+	o.loaderMaxAttempts = maxAttempts
+	o.loaderBackoff = backoff
+	o.withLoaderRetry = true
+}
+
+func (o *baseOptions[K, V]) setHasher(hash func(key K) uint64) {
+	o.hashFunc = hash
+	o.withHasher = true
+}
+
 func (o *baseOptions[K, V]) setCostFunc(costFunc func(key K, value V) uint32) {
 	o.costFunc = costFunc
 	o.withCost = true
@@ -58,17 +177,173 @@ func (o *baseOptions[K, V]) setInitialCapacity(initialCapacity int) {
 	o.initialCapacity = initialCapacity
 }
 
+func (o *baseOptions[K, V]) setLoadFactor(loadFactor float64) {
+	o.loadFactor = loadFactor
+	o.withLoadFactor = true
+}
+
 func (o *baseOptions[K, V]) setDeletionListener(deletionListener func(key K, value V, cause DeletionCause)) {
 	o.deletionListener = deletionListener
 }
 
+func (o *baseOptions[K, V]) setDeletionBatchListener(deletionBatchListener func(entries []DeletedEntry[K, V])) {
+	o.deletionBatchListener = deletionBatchListener
+}
+
+func (o *baseOptions[K, V]) enableDryRun() {
+	o.dryRun = true
+}
+
+func (o *baseOptions[K, V]) trackLastAccess() {
+	o.withLastAccess = true
+}
+
+func (o *baseOptions[K, V]) setPanicHandler(panicHandler func(stage string, r any)) {
+	o.panicHandler = panicHandler
+}
+
+func (o *baseOptions[K, V]) setCloneFunc(cloneFunc func(value V) V) {
+	o.cloneFunc = cloneFunc
+}
+
+func (o *baseOptions[K, V]) setEqualFunc(equalFunc func(a, b V) bool) {
+	o.equalFunc = equalFunc
+}
+
+func (o *baseOptions[K, V]) setValueInterning(equal func(a, b V) bool, hash func(v V) uint64) {
+	o.internEqual = equal
+	o.internHash = hash
+	o.withValueInterning = true
+}
+
+func (o *baseOptions[K, V]) enableWriteLatencyTracking() {
+	o.trackWriteLatency = true
+}
+
+func (o *baseOptions[K, V]) setName(name string) {
+	o.name = name
+}
+
+func (o *baseOptions[K, V]) enableRuntimeTrace() {
+	o.withTrace = true
+}
+
+func (o *baseOptions[K, V]) setPreExpiryCallback(lead time.Duration, callback func(key K, value V)) {
+	o.preExpiryLead = lead
+	o.preExpiryCallback = callback
+}
+
+func (o *baseOptions[K, V]) setMinCleanupInterval(interval time.Duration) {
+	o.minCleanupInterval = interval
+}
+
+func (o *baseOptions[K, V]) setMaxCleanupInterval(interval time.Duration) {
+	o.maxCleanupInterval = interval
+}
+
+func (o *baseOptions[K, V]) setCleanupBatchSize(batchSize int) {
+	o.cleanupBatchSize = batchSize
+}
+
+func (o *baseOptions[K, V]) setCleanupConcurrency(concurrency int) {
+	o.cleanupConcurrency = concurrency
+}
+
+func (o *baseOptions[K, V]) trackCreatedAt() {
+	o.withCreatedAt = true
+}
+
+func (o *baseOptions[K, V]) setTimeResolution(timeResolution time.Duration) {
+	o.timeResolution = timeResolution
+}
+
+func (o *baseOptions[K, V]) setWarmUpThreshold(threshold float64) {
+	o.warmUpThreshold = threshold
+	o.withWarmUp = true
+}
+
+func (o *baseOptions[K, V]) setEventBus(eventBus *EventBus[K, V]) {
+	o.eventBus = eventBus
+}
+
+func (o *baseOptions[K, V]) setEventChannel(bufferSize int, overflow EventOverflow) {
+	o.events = newEventChannel[K, V](bufferSize, overflow)
+}
+
+func (o *baseOptions[K, V]) setEvictionPolicy(evictionPolicy EvictionPolicy) {
+	o.evictionPolicy = evictionPolicy
+}
+
+func (o *baseOptions[K, V]) enableCompact() {
+	o.compact = true
+}
+
+func (o *baseOptions[K, V]) enableManualCleanup() {
+	o.manualCleanup = true
+}
+
 func (o *baseOptions[K, V]) validate() error {
 	if o.initialCapacity <= 0 && o.initialCapacity != unsetCapacity {
 		return ErrIllegalInitialCapacity
 	}
+	if o.withLoadFactor && !(o.loadFactor >= MinLoadFactor && o.loadFactor <= MaxLoadFactor) {
+		return ErrIllegalLoadFactor
+	}
 	if o.costFunc == nil {
 		return ErrNilCostFunc
 	}
+	if o.withStatsRecorder && o.statsRecorder == nil {
+		return ErrNilStatsRecorder
+	}
+	if o.withValueInterning && (o.internEqual == nil || o.internHash == nil) {
+		return ErrNilInterningFunc
+	}
+	if o.withHasher && o.hashFunc == nil {
+		return ErrNilHasher
+	}
+	if o.withCPUAffinity {
+		if len(o.cpuAffinity) == 0 {
+			return ErrIllegalCPUAffinity
+		}
+		for _, cpu := range o.cpuAffinity {
+			if cpu < 0 {
+				return ErrIllegalCPUAffinity
+			}
+		}
+	}
+	if o.withLoaderRateLimit && (!(o.loaderRateLimit > 0) ||
+		o.rateLimitBehavior != RateLimitWait && o.rateLimitBehavior != RateLimitReject) {
+		return ErrIllegalLoaderRateLimit
+	}
+	if o.withCircuitBreaker && (o.loaderFailures <= 0 || o.loaderCooldown <= 0) {
+		return ErrIllegalLoaderCircuitBreaker
+	}
+	if o.withLoaderRetry && (o.loaderMaxAttempts <= 0 || o.loaderBackoff == nil) {
+		return ErrIllegalLoaderRetry
+	}
+	if o.timeResolution < 0 || o.timeResolution > time.Second {
+		return ErrIllegalTimeResolution
+	}
+	if o.withWarmUp && !(o.warmUpThreshold > 0 && o.warmUpThreshold <= 1) {
+		return ErrIllegalWarmUpThreshold
+	}
+	if o.evictionPolicy != S3FIFO && o.evictionPolicy != LRU && o.evictionPolicy != Sampled &&
+		o.evictionPolicy != LFU {
+		return ErrIllegalEvictionPolicy
+	}
+	if o.preExpiryCallback != nil && o.preExpiryLead <= 0 {
+		return ErrIllegalPreExpiryLead
+	}
+	if o.minCleanupInterval < 0 || o.maxCleanupInterval < 0 ||
+		o.minCleanupInterval > 0 && o.maxCleanupInterval > 0 && o.minCleanupInterval > o.maxCleanupInterval {
+		return ErrIllegalCleanupInterval
+	}
+	if o.cleanupBatchSize < 0 {
+		return ErrIllegalCleanupBatchSize
+	}
+	if o.cleanupConcurrency < 0 {
+		return ErrIllegalCleanupConcurrency
+	}
 	return nil
 }
 
@@ -77,13 +352,92 @@ func (o *baseOptions[K, V]) toConfig() core.Config[K, V] {
 	if o.initialCapacity != unsetCapacity {
 		initialCapacity = &o.initialCapacity
 	}
+	deletionListener := o.deletionListener
+	var setListener func(key K, oldValue V, newValue V, replaced bool)
+	if o.eventBus != nil {
+		setListener = o.eventBus.onSet
+		deletionListener = chainDeletionListeners(deletionListener, o.eventBus.onDeletion)
+	}
+	if o.events != nil {
+		setListener = chainSetListeners(setListener, o.events.onSet)
+		deletionListener = chainDeletionListeners(deletionListener, o.events.onDeletion)
+	}
+	var deletionBatchListener func(entries []core.DeletedEntry[K, V])
+	if o.deletionBatchListener != nil {
+		batchListener := o.deletionBatchListener
+		deletionBatchListener = func(entries []core.DeletedEntry[K, V]) {
+			batch := make([]DeletedEntry[K, V], 0, len(entries))
+			for _, e := range entries {
+				batch = append(batch, DeletedEntry[K, V](e))
+			}
+			batchListener(batch)
+		}
+	}
 	return core.Config[K, V]{
-		Capacity:         o.capacity,
-		InitialCapacity:  initialCapacity,
-		StatsEnabled:     o.statsEnabled,
-		CostFunc:         o.costFunc,
-		WithCost:         o.withCost,
-		DeletionListener: o.deletionListener,
+		Capacity:                o.capacity,
+		InitialCapacity:         initialCapacity,
+		StatsEnabled:            o.statsEnabled,
+		CostFunc:                o.costFunc,
+		WithCost:                o.withCost,
+		DeletionListener:        deletionListener,
+		DeletionBatchListener:   deletionBatchListener,
+		DryRun:                  o.dryRun,
+		WithLastAccess:          o.withLastAccess,
+		WithCreatedAt:           o.withCreatedAt,
+		TrackWriteLatency:       o.trackWriteLatency,
+		Name:                    o.name,
+		WithTrace:               o.withTrace,
+		PreExpiryCallback:       o.preExpiryCallback,
+		PreExpiryLead:           o.preExpiryLead,
+		MinCleanupInterval:      o.minCleanupInterval,
+		MaxCleanupInterval:      o.maxCleanupInterval,
+		CleanupBatchSize:        o.cleanupBatchSize,
+		CleanupConcurrency:      o.cleanupConcurrency,
+		LoadFactor:              o.loadFactor,
+		InternEqual:             o.internEqual,
+		InternHash:              o.internHash,
+		StatsRecorder:           o.statsRecorder,
+		CPUAffinity:             o.cpuAffinity,
+		HashFunc:                o.hashFunc,
+		LoaderRateLimit:         o.loaderRateLimit,
+		LoaderRateLimitBehavior: o.rateLimitBehavior,
+		LoaderFailureThreshold:  o.loaderFailures,
+		LoaderCooldown:          o.loaderCooldown,
+		LoaderMaxAttempts:       o.loaderMaxAttempts,
+		LoaderBackoff:           o.loaderBackoff,
+		TimeResolution:          o.timeResolution,
+		WarmUpThreshold:         o.warmUpThreshold,
+		SetListener:             setListener,
+		EvictionPolicy:          o.evictionPolicy,
+		Compact:                 o.compact,
+		ManualCleanup:           o.manualCleanup,
+		PanicHandler:            o.panicHandler,
+		CloneFunc:               o.cloneFunc,
+		EqualFunc:               o.equalFunc,
+	}
+}
+
+func chainDeletionListeners[K comparable, V any](
+	first, second func(key K, value V, cause DeletionCause),
+) func(key K, value V, cause DeletionCause) {
+	if first == nil {
+		return second
+	}
+	return func(key K, value V, cause DeletionCause) {
+		first(key, value, cause)
+		second(key, value, cause)
+	}
+}
+
+func chainSetListeners[K comparable, V any](
+	first, second func(key K, oldValue V, newValue V, replaced bool),
+) func(key K, oldValue V, newValue V, replaced bool) {
+	if first == nil {
+		return second
+	}
+	return func(key K, oldValue V, newValue V, replaced bool) {
+		first(key, oldValue, newValue, replaced)
+		second(key, oldValue, newValue, replaced)
 	}
 }
 
@@ -151,6 +505,34 @@ func NewBuilder[K comparable, V any](capacity int) (*Builder[K, V], error) {
 	}, nil
 }
 
+// MustComparableBuilder creates a builder for the comparable values and sets the future cache capacity.
+// The values are compared with the native ==.
+//
+// Panics if capacity <= 0.
+func MustComparableBuilder[K comparable, V comparable](capacity int) *Builder[K, V] {
+	b, err := NewComparableBuilder[K, V](capacity)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// NewComparableBuilder creates a builder for the comparable values and sets the future cache capacity.
+// The values are compared with the native ==.
+//
+// Returns an error if capacity <= 0.
+func NewComparableBuilder[K comparable, V comparable](capacity int) (*Builder[K, V], error) {
+	b, err := NewBuilder[K, V](capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	b.setEqualFunc(func(x, y V) bool {
+		return x == y
+	})
+	return b, nil
+}
+
 // CollectStats determines whether statistics should be calculated when the cache is running.
 //
 // By default, statistics calculating is disabled.
@@ -159,6 +541,46 @@ func (b *Builder[K, V]) CollectStats() *Builder[K, V] {
 	return b
 }
 
+// RecordStats enables the statistics like CollectStats and also forwards the statistics events
+// to recorder as they happen, so that they can be emitted to a metrics system without polling Stats.
+//
+// The recorder is called synchronously on the hot paths of the cache, so it must be thread-safe and fast.
+func (b *Builder[K, V]) RecordStats(recorder StatsRecorder) *Builder[K, V] {
+	b.setStatsRecorder(recorder)
+	return b
+}
+
+// Name sets the name of the cache. The background goroutines of the cache are labeled with
+// otter_cache=name, so that they can be attributed to the cache in the goroutine and CPU profiles.
+//
+// By default, the cache has no name and its goroutines are not labeled.
+func (b *Builder[K, V]) Name(name string) *Builder[K, V] {
+	b.setName(name)
+	return b
+}
+
+// CPUAffinity binds the background goroutines of the cache, which apply the writes to the eviction policy
+// and remove the expired items, to the given CPUs. Each of them is locked to its own OS thread, so that
+// the rest of the application can be kept off these CPUs to reduce the scheduling jitter.
+//
+// The binding is supported only on Linux. On the other platforms, or if the binding fails, the goroutines
+// run on any CPU as usual and the failure is reported by Cache.AffinityFailed.
+//
+// By default, the background goroutines are not bound to any CPUs.
+func (b *Builder[K, V]) CPUAffinity(cpus []int) *Builder[K, V] {
+	b.setCPUAffinity(cpus)
+	return b
+}
+
+// RuntimeTrace specifies that Get, Set, Delete and the waits for the internal eviction lock should be
+// wrapped in the runtime/trace regions, so that they are visible in the go tool trace output.
+//
+// By default, the operations are not traced and the tracing costs nothing.
+func (b *Builder[K, V]) RuntimeTrace() *Builder[K, V] {
+	b.enableRuntimeTrace()
+	return b
+}
+
 // InitialCapacity sets the minimum total size for the internal data structures. Providing a large enough estimate
 // at construction time avoids the need for expensive resizing operations later, but setting this
 // value unnecessarily high wastes memory.
@@ -167,6 +589,30 @@ func (b *Builder[K, V]) InitialCapacity(initialCapacity int) *Builder[K, V] {
 	return b
 }
 
+// LoadFactor sets the occupancy of the internal hash table at which it is expanded. It must be
+// in the [MinLoadFactor, MaxLoadFactor] range, and 0.75 is used by default.
+//
+// A lower load factor means fewer collisions and faster lookups at the cost of a larger table,
+// which suits the latency-critical caches. A higher one packs the entries tighter and saves memory,
+// but the lookups walk longer bucket chains.
+func (b *Builder[K, V]) LoadFactor(loadFactor float64) *Builder[K, V] {
+	b.setLoadFactor(loadFactor)
+	return b
+}
+
+// Hasher sets the function used to hash the keys in the internal hash table, e.g. a faster
+// or better distributed one for the particular key type. The function must be deterministic,
+// and the keys equal according to == must have the same hash.
+//
+// Unlike the default hasher, it is not seeded randomly, so the keys controlled by an attacker can be
+// chosen to collide. The low bits of the hash select the bucket, so they must be well distributed.
+//
+// By default, the keys are hashed with the runtime hash function used by the Go maps.
+func (b *Builder[K, V]) Hasher(hash func(key K) uint64) *Builder[K, V] {
+	b.setHasher(hash)
+	return b
+}
+
 // Cost sets a function to dynamically calculate the cost of an item.
 //
 // By default, this function always returns 1.
@@ -183,6 +629,191 @@ func (b *Builder[K, V]) DeletionListener(deletionListener func(key K, value V, c
 	return b
 }
 
+// DeletionBatchListener specifies a listener instance that caches should notify once per batch of deleted entries.
+//
+// The batches are collected by the background goroutines, so the listener is called once for all entries
+// evicted, expired or deleted during one drain, which allows bulk downstream operations.
+// The entries slice is owned by the listener. It can be used together with DeletionListener.
+func (b *Builder[K, V]) DeletionBatchListener(deletionBatchListener func(entries []DeletedEntry[K, V])) *Builder[K, V] {
+	b.setDeletionBatchListener(deletionBatchListener)
+	return b
+}
+
+// OnPanic specifies a handler that is notified about the panics in the user callbacks: the cost function,
+// the deletion listeners, the event bus and the loaders. The stage is one of the PanicStage constants.
+//
+// If the handler is set, the panics are recovered and the cache keeps operating, otherwise a panic
+// in a callback called by a background goroutine crashes the program.
+func (b *Builder[K, V]) OnPanic(handler func(stage string, r any)) *Builder[K, V] {
+	b.setPanicHandler(handler)
+	return b
+}
+
+// LoaderRateLimit limits the calls of the loaders passed to GetMultiOrSet to rps calls per second
+// on average with the bursts of up to rps calls. The limiter belongs to the cache and is shared by all its loads.
+// The concurrent loads of the same keys are deduplicated before the limit is applied.
+//
+// When the limit is exceeded, the load waits for its turn with the RateLimitWait behavior
+// or fails with ErrRateLimited with the RateLimitReject behavior.
+//
+// By default, the loader calls are not limited.
+func (b *Builder[K, V]) LoaderRateLimit(rps float64, behavior RateLimitBehavior) *Builder[K, V] {
+	b.setLoaderRateLimit(rps, behavior)
+	return b
+}
+
+// LoaderCircuitBreaker stops calling the loaders passed to GetMultiOrSet after threshold consecutive failures,
+// so that the downstream service has time to recover. While the circuit is open, the loads of the missing keys
+// fail with ErrCircuitOpen right away. After the cooldown, a single trial call is allowed: the circuit is closed
+// if it succeeds and opened again otherwise. The state is reported by Cache.CircuitState.
+//
+// By default, the loaders are always called.
+func (b *Builder[K, V]) LoaderCircuitBreaker(threshold int, cooldown time.Duration) *Builder[K, V] {
+	b.setLoaderCircuitBreaker(threshold, cooldown)
+	return b
+}
+
+// LoaderRetry retries the failed calls of the loaders passed to GetMultiOrSet, so that a load makes up to
+// maxAttempts calls in total and waits for the duration returned by the backoff policy before each retry.
+// FixedBackoff and ExponentialBackoff provide the common policies. The waits are interrupted by the cancellation
+// of the context passed to GetMultiOrSetContext. If all the attempts fail, the error of the last one is returned.
+//
+// The retries happen inside the circuit breaker, so they are counted as a single failure, and each of them
+// is subject to the rate limit.
+//
+// By default, the failed loads are not retried.
+func (b *Builder[K, V]) LoaderRetry(maxAttempts int, backoff BackoffPolicy) *Builder[K, V] {
+	b.setLoaderRetry(maxAttempts, backoff)
+	return b
+}
+
+// CloneValues specifies a function that copies the values, which gives the value semantics to the mutable values
+// like slices and maps. The writes like Set, GetOrSet and Transact store a clone of the given value, and the reads
+// like Get, Range, GetAndDelete, NextEvictions and the snapshots return a clone of the cached value,
+// so the callers can't corrupt the values seen by others.
+//
+// By default, the values are not cloned, because it costs CPU and is not needed for the immutable values.
+func (b *Builder[K, V]) CloneValues(cloneFunc func(value V) V) *Builder[K, V] {
+	b.setCloneFunc(cloneFunc)
+	return b
+}
+
+// Equal specifies a function that compares the values in CompareAndSwap, CompareAndDelete and SetIfChanged.
+//
+// By default, the values are compared with == via the interface conversion, which panics for
+// the non-comparable values, so the function must be set for them. Use NewComparableBuilder
+// to compare the comparable values with the native ==.
+func (b *Builder[K, V]) Equal(equalFunc func(a, b V) bool) *Builder[K, V] {
+	b.setEqualFunc(equalFunc)
+	return b
+}
+
+// ValueInterning specifies that the equal values stored in the cache should share a single canonical copy.
+// When a stored value is equal to an interned one, the cache keeps the interned value instead of the new one,
+// so the memory referenced by the values (the bytes of strings, the backing arrays of slices, the pointed-to
+// structs) is not duplicated. It saves memory for the caches where many entries share the same value,
+// like the configuration flags or the status strings.
+//
+// The equal and hash functions are called on each write. The intern table is bounded by the capacity
+// of the cache, and a value can displace the interned one with the same hash slot, so the deduplication
+// is best-effort. The values without pointers are copied anyway, so they don't benefit from the interning.
+func (b *Builder[K, V]) ValueInterning(equal func(a, b V) bool, hash func(v V) uint64) *Builder[K, V] {
+	b.setValueInterning(equal, hash)
+	return b
+}
+
+// EventBus specifies an EventBus to which the cache should publish the events about the changes of its entries.
+// The events are published in the background goroutine after the corresponding operation has completed.
+func (b *Builder[K, V]) EventBus(eventBus *EventBus[K, V]) *Builder[K, V] {
+	b.setEventBus(eventBus)
+	return b
+}
+
+// EventChannel specifies that the cache should deliver the events about the changes of its entries
+// to the channel returned by Events, which buffers at most bufferSize events. overflow determines
+// what happens to a new event when the channel is full. The channel is closed when the cache is closed.
+//
+// Unlike the listeners, the events are consumed in a goroutine of the caller, so the slow processing
+// doesn't stall the cache unless BlockOnFullEvents is used.
+func (b *Builder[K, V]) EventChannel(bufferSize int, overflow EventOverflow) *Builder[K, V] {
+	b.setEventChannel(bufferSize, overflow)
+	return b
+}
+
+// DryRun enables the dry-run mode. In this mode the cache works as usual, but the entries selected
+// for eviction due to size constraints are not deleted and the deletion listener is not notified.
+// Instead, the cache counts them, and the result is available via DryRunStats.
+//
+// This mode is intended for capacity planning: the cache can grow beyond its capacity in it.
+func (b *Builder[K, V]) DryRun() *Builder[K, V] {
+	b.enableDryRun()
+	return b
+}
+
+// TrackLastAccess specifies that the cache should record the time of the last access to each item.
+// The time is available via LastAccess.
+//
+// By default, the access time is not tracked, because it requires an additional field in each item
+// and a write on each read.
+func (b *Builder[K, V]) TrackLastAccess() *Builder[K, V] {
+	b.trackLastAccess()
+	return b
+}
+
+// TrackCreatedAt specifies that the cache should record the time when each item was inserted.
+// The time is available via CreatedAt.
+//
+// By default, the creation time is not tracked, because it requires an additional field in each item.
+func (b *Builder[K, V]) TrackCreatedAt() *Builder[K, V] {
+	b.trackCreatedAt()
+	return b
+}
+
+// TrackWriteLatency specifies that the cache should record the time the writes wait in the write buffer
+// before they are applied to the eviction policy. The latency distribution is available via Stats
+// and is recorded only if the statistics are collected.
+//
+// By default, the write latency is not tracked, because it costs a time.Now call per write.
+func (b *Builder[K, V]) TrackWriteLatency() *Builder[K, V] {
+	b.enableWriteLatencyTracking()
+	return b
+}
+
+// TimeResolution sets how often the internal clock used for expiration and access times is updated.
+// A finer resolution makes the expiration more precise at the cost of a more frequent background update.
+// A resolution below a millisecond makes the clock read the current time on every access instead.
+//
+// By default, the clock is updated every second.
+func (b *Builder[K, V]) TimeResolution(timeResolution time.Duration) *Builder[K, V] {
+	b.setTimeResolution(timeResolution)
+	return b
+}
+
+// WarmUpThreshold sets the fraction of the capacity that the cache should be filled to be considered warm.
+// The channel returned by WarmUpDone is closed once the number of items reaches this fraction of the capacity.
+func (b *Builder[K, V]) WarmUpThreshold(threshold float64) *Builder[K, V] {
+	b.setWarmUpThreshold(threshold)
+	return b
+}
+
+// EvictionPolicy sets the algorithm used to select the entries to evict.
+//
+// By default, the S3FIFO policy is used. LRU, an approximate LRU as the reads are recorded through lossy buffers,
+// can be used for the workloads that need the recently used entries to survive rather than the scan resistance,
+// and LFU for the stable working sets dominated by the frequently used entries.
+func (b *Builder[K, V]) EvictionPolicy(evictionPolicy EvictionPolicy) *Builder[K, V] {
+	b.setEvictionPolicy(evictionPolicy)
+	return b
+}
+
+// Compact specifies that the cache should use the minimal number and size of the internal buffers
+// regardless of the number of available CPUs. It lowers the baseline memory usage of the cache
+// at the cost of the throughput under contention, so it suits the processes with many small caches.
+func (b *Builder[K, V]) Compact() *Builder[K, V] {
+	b.enableCompact()
+	return b
+}
+
 // WithTTL specifies that each item should be automatically removed from the cache once a fixed duration
 // has elapsed after the item's creation.
 func (b *Builder[K, V]) WithTTL(ttl time.Duration) *ConstTTLBuilder[K, V] {
@@ -213,7 +844,7 @@ func (b *Builder[K, V]) Build() (Cache[K, V], error) {
 		return Cache[K, V]{}, err
 	}
 
-	return newCache(b.toConfig()), nil
+	return newCache(b.toConfig(), b.events), nil
 }
 
 // ConstTTLBuilder is a one-shot builder for creating a cache instance.
@@ -229,6 +860,96 @@ func (b *ConstTTLBuilder[K, V]) CollectStats() *ConstTTLBuilder[K, V] {
 	return b
 }
 
+// RecordStats enables the statistics like CollectStats and also forwards the statistics events
+// to recorder as they happen, so that they can be emitted to a metrics system without polling Stats.
+//
+// The recorder is called synchronously on the hot paths of the cache, so it must be thread-safe and fast.
+func (b *ConstTTLBuilder[K, V]) RecordStats(recorder StatsRecorder) *ConstTTLBuilder[K, V] {
+	b.setStatsRecorder(recorder)
+	return b
+}
+
+// Name sets the name of the cache. The background goroutines of the cache are labeled with
+// otter_cache=name, so that they can be attributed to the cache in the goroutine and CPU profiles.
+//
+// By default, the cache has no name and its goroutines are not labeled.
+func (b *ConstTTLBuilder[K, V]) Name(name string) *ConstTTLBuilder[K, V] {
+	b.setName(name)
+	return b
+}
+
+// CPUAffinity binds the background goroutines of the cache, which apply the writes to the eviction policy
+// and remove the expired items, to the given CPUs. Each of them is locked to its own OS thread, so that
+// the rest of the application can be kept off these CPUs to reduce the scheduling jitter.
+//
+// The binding is supported only on Linux. On the other platforms, or if the binding fails, the goroutines
+// run on any CPU as usual and the failure is reported by Cache.AffinityFailed.
+//
+// By default, the background goroutines are not bound to any CPUs.
+func (b *ConstTTLBuilder[K, V]) CPUAffinity(cpus []int) *ConstTTLBuilder[K, V] {
+	b.setCPUAffinity(cpus)
+	return b
+}
+
+// RuntimeTrace specifies that Get, Set, Delete and the waits for the internal eviction lock should be
+// wrapped in the runtime/trace regions, so that they are visible in the go tool trace output.
+//
+// By default, the operations are not traced and the tracing costs nothing.
+func (b *ConstTTLBuilder[K, V]) RuntimeTrace() *ConstTTLBuilder[K, V] {
+	b.enableRuntimeTrace()
+	return b
+}
+
+// PreExpiryCallback specifies a function that is called once for each item approximately lead before
+// the item expires, e.g. to refresh it in the background. The callback is called by the cleanup goroutine
+// for the items that expire within lead, so it should be fast. The items with a ttl shorter than lead are notified
+// by the first cleanup cycle after they are inserted, and the callback is not called at all if ManualCleanup is set.
+func (b *ConstTTLBuilder[K, V]) PreExpiryCallback(lead time.Duration, callback func(key K, value V)) *ConstTTLBuilder[K, V] {
+	b.setPreExpiryCallback(lead, callback)
+	return b
+}
+
+// MinCleanupInterval sets the lower bound of the interval between the cycles of the background cleanup
+// of the expired items. The interval is shortened down to it when many items expire in a cycle.
+//
+// By default, it is 100ms.
+func (b *ConstTTLBuilder[K, V]) MinCleanupInterval(interval time.Duration) *ConstTTLBuilder[K, V] {
+	b.setMinCleanupInterval(interval)
+	return b
+}
+
+// MaxCleanupInterval sets the upper bound of the interval between the cycles of the background cleanup
+// of the expired items, which is used when few items expire.
+//
+// By default, it is 1s.
+func (b *ConstTTLBuilder[K, V]) MaxCleanupInterval(interval time.Duration) *ConstTTLBuilder[K, V] {
+	b.setMaxCleanupInterval(interval)
+	return b
+}
+
+// CleanupBatchSize limits the number of the expired items removed by a cycle of the background cleanup.
+// If a cycle hits the limit, then the next one starts right away, so the limit bounds the time the cleanup
+// holds the internal lock and the memory it allocates, but the expired items may stay in the cache longer.
+//
+// By default, it is 0, which means no limit.
+func (b *ConstTTLBuilder[K, V]) CleanupBatchSize(batchSize int) *ConstTTLBuilder[K, V] {
+	b.setCleanupBatchSize(batchSize)
+	return b
+}
+
+// CleanupConcurrency sets the number of the goroutines that remove the expired items in a cycle
+// of the background cleanup. The items are kept in one queue ordered by the expiration time, so the goroutines
+// take turns removing its expired head under the internal lock. Then each goroutine deletes its items
+// from the hash table and notifies the listeners in parallel with the others, so it pays off for the caches
+// in which many items expire at once. The batch size set by CleanupBatchSize is split between the goroutines.
+//
+// The deletion listener is called concurrently if the concurrency is greater than 1.
+// By default, the expired items are removed by the cleanup goroutine itself.
+func (b *ConstTTLBuilder[K, V]) CleanupConcurrency(concurrency int) *ConstTTLBuilder[K, V] {
+	b.setCleanupConcurrency(concurrency)
+	return b
+}
+
 // InitialCapacity sets the minimum total size for the internal data structures. Providing a large enough estimate
 // at construction time avoids the need for expensive resizing operations later, but setting this
 // value unnecessarily high wastes memory.
@@ -237,6 +958,30 @@ func (b *ConstTTLBuilder[K, V]) InitialCapacity(initialCapacity int) *ConstTTLBu
 	return b
 }
 
+// LoadFactor sets the occupancy of the internal hash table at which it is expanded. It must be
+// in the [MinLoadFactor, MaxLoadFactor] range, and 0.75 is used by default.
+//
+// A lower load factor means fewer collisions and faster lookups at the cost of a larger table,
+// which suits the latency-critical caches. A higher one packs the entries tighter and saves memory,
+// but the lookups walk longer bucket chains.
+func (b *ConstTTLBuilder[K, V]) LoadFactor(loadFactor float64) *ConstTTLBuilder[K, V] {
+	b.setLoadFactor(loadFactor)
+	return b
+}
+
+// Hasher sets the function used to hash the keys in the internal hash table, e.g. a faster
+// or better distributed one for the particular key type. The function must be deterministic,
+// and the keys equal according to == must have the same hash.
+//
+// Unlike the default hasher, it is not seeded randomly, so the keys controlled by an attacker can be
+// chosen to collide. The low bits of the hash select the bucket, so they must be well distributed.
+//
+// By default, the keys are hashed with the runtime hash function used by the Go maps.
+func (b *ConstTTLBuilder[K, V]) Hasher(hash func(key K) uint64) *ConstTTLBuilder[K, V] {
+	b.setHasher(hash)
+	return b
+}
+
 // Cost sets a function to dynamically calculate the cost of an item.
 //
 // By default, this function always returns 1.
@@ -253,6 +998,202 @@ func (b *ConstTTLBuilder[K, V]) DeletionListener(deletionListener func(key K, va
 	return b
 }
 
+// DeletionBatchListener specifies a listener instance that caches should notify once per batch of deleted entries.
+//
+// The batches are collected by the background goroutines, so the listener is called once for all entries
+// evicted, expired or deleted during one drain, which allows bulk downstream operations.
+// The entries slice is owned by the listener. It can be used together with DeletionListener.
+func (b *ConstTTLBuilder[K, V]) DeletionBatchListener(deletionBatchListener func(entries []DeletedEntry[K, V])) *ConstTTLBuilder[K, V] {
+	b.setDeletionBatchListener(deletionBatchListener)
+	return b
+}
+
+// OnPanic specifies a handler that is notified about the panics in the user callbacks: the cost function,
+// the deletion listeners, the event bus and the loaders. The stage is one of the PanicStage constants.
+//
+// If the handler is set, the panics are recovered and the cache keeps operating, otherwise a panic
+// in a callback called by a background goroutine crashes the program.
+func (b *ConstTTLBuilder[K, V]) OnPanic(handler func(stage string, r any)) *ConstTTLBuilder[K, V] {
+	b.setPanicHandler(handler)
+	return b
+}
+
+// LoaderRateLimit limits the calls of the loaders passed to GetMultiOrSet to rps calls per second
+// on average with the bursts of up to rps calls. The limiter belongs to the cache and is shared by all its loads.
+// The concurrent loads of the same keys are deduplicated before the limit is applied.
+//
+// When the limit is exceeded, the load waits for its turn with the RateLimitWait behavior
+// or fails with ErrRateLimited with the RateLimitReject behavior.
+//
+// By default, the loader calls are not limited.
+func (b *ConstTTLBuilder[K, V]) LoaderRateLimit(rps float64, behavior RateLimitBehavior) *ConstTTLBuilder[K, V] {
+	b.setLoaderRateLimit(rps, behavior)
+	return b
+}
+
+// LoaderCircuitBreaker stops calling the loaders passed to GetMultiOrSet after threshold consecutive failures,
+// so that the downstream service has time to recover. While the circuit is open, the loads of the missing keys
+// fail with ErrCircuitOpen right away. After the cooldown, a single trial call is allowed: the circuit is closed
+// if it succeeds and opened again otherwise. The state is reported by Cache.CircuitState.
+//
+// By default, the loaders are always called.
+func (b *ConstTTLBuilder[K, V]) LoaderCircuitBreaker(threshold int, cooldown time.Duration) *ConstTTLBuilder[K, V] {
+	b.setLoaderCircuitBreaker(threshold, cooldown)
+	return b
+}
+
+// LoaderRetry retries the failed calls of the loaders passed to GetMultiOrSet, so that a load makes up to
+// maxAttempts calls in total and waits for the duration returned by the backoff policy before each retry.
+// FixedBackoff and ExponentialBackoff provide the common policies. The waits are interrupted by the cancellation
+// of the context passed to GetMultiOrSetContext. If all the attempts fail, the error of the last one is returned.
+//
+// The retries happen inside the circuit breaker, so they are counted as a single failure, and each of them
+// is subject to the rate limit.
+//
+// By default, the failed loads are not retried.
+func (b *ConstTTLBuilder[K, V]) LoaderRetry(maxAttempts int, backoff BackoffPolicy) *ConstTTLBuilder[K, V] {
+	b.setLoaderRetry(maxAttempts, backoff)
+	return b
+}
+
+// CloneValues specifies a function that copies the values, which gives the value semantics to the mutable values
+// like slices and maps. The writes like Set, GetOrSet and Transact store a clone of the given value, and the reads
+// like Get, Range, GetAndDelete, NextEvictions and the snapshots return a clone of the cached value,
+// so the callers can't corrupt the values seen by others.
+//
+// By default, the values are not cloned, because it costs CPU and is not needed for the immutable values.
+func (b *ConstTTLBuilder[K, V]) CloneValues(cloneFunc func(value V) V) *ConstTTLBuilder[K, V] {
+	b.setCloneFunc(cloneFunc)
+	return b
+}
+
+// Equal specifies a function that compares the values in CompareAndSwap, CompareAndDelete and SetIfChanged.
+//
+// By default, the values are compared with == via the interface conversion, which panics for
+// the non-comparable values, so the function must be set for them. Use NewComparableBuilder
+// to compare the comparable values with the native ==.
+func (b *ConstTTLBuilder[K, V]) Equal(equalFunc func(a, b V) bool) *ConstTTLBuilder[K, V] {
+	b.setEqualFunc(equalFunc)
+	return b
+}
+
+// ValueInterning specifies that the equal values stored in the cache should share a single canonical copy.
+// When a stored value is equal to an interned one, the cache keeps the interned value instead of the new one,
+// so the memory referenced by the values (the bytes of strings, the backing arrays of slices, the pointed-to
+// structs) is not duplicated. It saves memory for the caches where many entries share the same value,
+// like the configuration flags or the status strings.
+//
+// The equal and hash functions are called on each write. The intern table is bounded by the capacity
+// of the cache, and a value can displace the interned one with the same hash slot, so the deduplication
+// is best-effort. The values without pointers are copied anyway, so they don't benefit from the interning.
+func (b *ConstTTLBuilder[K, V]) ValueInterning(equal func(a, b V) bool, hash func(v V) uint64) *ConstTTLBuilder[K, V] {
+	b.setValueInterning(equal, hash)
+	return b
+}
+
+// EventBus specifies an EventBus to which the cache should publish the events about the changes of its entries.
+// The events are published in the background goroutine after the corresponding operation has completed.
+func (b *ConstTTLBuilder[K, V]) EventBus(eventBus *EventBus[K, V]) *ConstTTLBuilder[K, V] {
+	b.setEventBus(eventBus)
+	return b
+}
+
+// EventChannel specifies that the cache should deliver the events about the changes of its entries
+// to the channel returned by Events, which buffers at most bufferSize events. overflow determines
+// what happens to a new event when the channel is full. The channel is closed when the cache is closed.
+//
+// Unlike the listeners, the events are consumed in a goroutine of the caller, so the slow processing
+// doesn't stall the cache unless BlockOnFullEvents is used.
+func (b *ConstTTLBuilder[K, V]) EventChannel(bufferSize int, overflow EventOverflow) *ConstTTLBuilder[K, V] {
+	b.setEventChannel(bufferSize, overflow)
+	return b
+}
+
+// DryRun enables the dry-run mode. In this mode the cache works as usual, but the entries selected
+// for eviction due to size constraints are not deleted and the deletion listener is not notified.
+// Instead, the cache counts them, and the result is available via DryRunStats.
+//
+// This mode is intended for capacity planning: the cache can grow beyond its capacity in it.
+func (b *ConstTTLBuilder[K, V]) DryRun() *ConstTTLBuilder[K, V] {
+	b.enableDryRun()
+	return b
+}
+
+// TrackLastAccess specifies that the cache should record the time of the last access to each item.
+// The time is available via LastAccess.
+//
+// By default, the access time is not tracked, because it requires an additional field in each item
+// and a write on each read.
+func (b *ConstTTLBuilder[K, V]) TrackLastAccess() *ConstTTLBuilder[K, V] {
+	b.trackLastAccess()
+	return b
+}
+
+// TrackCreatedAt specifies that the cache should record the time when each item was inserted.
+// The time is available via CreatedAt.
+//
+// By default, the creation time is not tracked, because it requires an additional field in each item.
+func (b *ConstTTLBuilder[K, V]) TrackCreatedAt() *ConstTTLBuilder[K, V] {
+	b.trackCreatedAt()
+	return b
+}
+
+// TrackWriteLatency specifies that the cache should record the time the writes wait in the write buffer
+// before they are applied to the eviction policy. The latency distribution is available via Stats
+// and is recorded only if the statistics are collected.
+//
+// By default, the write latency is not tracked, because it costs a time.Now call per write.
+func (b *ConstTTLBuilder[K, V]) TrackWriteLatency() *ConstTTLBuilder[K, V] {
+	b.enableWriteLatencyTracking()
+	return b
+}
+
+// TimeResolution sets how often the internal clock used for expiration and access times is updated.
+// A finer resolution makes the expiration more precise at the cost of a more frequent background update.
+// A resolution below a millisecond makes the clock read the current time on every access instead.
+//
+// By default, the clock is updated every second.
+func (b *ConstTTLBuilder[K, V]) TimeResolution(timeResolution time.Duration) *ConstTTLBuilder[K, V] {
+	b.setTimeResolution(timeResolution)
+	return b
+}
+
+// WarmUpThreshold sets the fraction of the capacity that the cache should be filled to be considered warm.
+// The channel returned by WarmUpDone is closed once the number of items reaches this fraction of the capacity.
+func (b *ConstTTLBuilder[K, V]) WarmUpThreshold(threshold float64) *ConstTTLBuilder[K, V] {
+	b.setWarmUpThreshold(threshold)
+	return b
+}
+
+// EvictionPolicy sets the algorithm used to select the entries to evict.
+//
+// By default, the S3FIFO policy is used. LRU, an approximate LRU as the reads are recorded through lossy buffers,
+// can be used for the workloads that need the recently used entries to survive rather than the scan resistance,
+// and LFU for the stable working sets dominated by the frequently used entries.
+func (b *ConstTTLBuilder[K, V]) EvictionPolicy(evictionPolicy EvictionPolicy) *ConstTTLBuilder[K, V] {
+	b.setEvictionPolicy(evictionPolicy)
+	return b
+}
+
+// Compact specifies that the cache should use the minimal number and size of the internal buffers
+// regardless of the number of available CPUs. It lowers the baseline memory usage of the cache
+// at the cost of the throughput under contention, so it suits the processes with many small caches.
+func (b *ConstTTLBuilder[K, V]) Compact() *ConstTTLBuilder[K, V] {
+	b.enableCompact()
+	return b
+}
+
+// ManualCleanup specifies that the cache should not start the background goroutine that removes
+// the expired items every second. The expired items are still never returned by the reads,
+// but their memory is reclaimed only when PurgeExpired is called or they are evicted.
+//
+// Note that the internal clock used for the expiration is still updated by a background goroutine
+// shared by all caches.
+func (b *ConstTTLBuilder[K, V]) ManualCleanup() *ConstTTLBuilder[K, V] {
+	b.enableManualCleanup()
+	return b
+}
+
 // Build creates a configured cache or
 // returns an error if invalid parameters were passed to the builder.
 func (b *ConstTTLBuilder[K, V]) Build() (Cache[K, V], error) {
@@ -260,7 +1201,7 @@ func (b *ConstTTLBuilder[K, V]) Build() (Cache[K, V], error) {
 		return Cache[K, V]{}, err
 	}
 
-	return newCache(b.toConfig()), nil
+	return newCache(b.toConfig(), b.events), nil
 }
 
 // VariableTTLBuilder is a one-shot builder for creating a cache instance.
@@ -276,6 +1217,97 @@ func (b *VariableTTLBuilder[K, V]) CollectStats() *VariableTTLBuilder[K, V] {
 	return b
 }
 
+// RecordStats enables the statistics like CollectStats and also forwards the statistics events
+// to recorder as they happen, so that they can be emitted to a metrics system without polling Stats.
+//
+// The recorder is called synchronously on the hot paths of the cache, so it must be thread-safe and fast.
+func (b *VariableTTLBuilder[K, V]) RecordStats(recorder StatsRecorder) *VariableTTLBuilder[K, V] {
+	b.setStatsRecorder(recorder)
+	return b
+}
+
+// Name sets the name of the cache. The background goroutines of the cache are labeled with
+// otter_cache=name, so that they can be attributed to the cache in the goroutine and CPU profiles.
+//
+// By default, the cache has no name and its goroutines are not labeled.
+func (b *VariableTTLBuilder[K, V]) Name(name string) *VariableTTLBuilder[K, V] {
+	b.setName(name)
+	return b
+}
+
+// CPUAffinity binds the background goroutines of the cache, which apply the writes to the eviction policy
+// and remove the expired items, to the given CPUs. Each of them is locked to its own OS thread, so that
+// the rest of the application can be kept off these CPUs to reduce the scheduling jitter.
+//
+// The binding is supported only on Linux. On the other platforms, or if the binding fails, the goroutines
+// run on any CPU as usual and the failure is reported by Cache.AffinityFailed.
+//
+// By default, the background goroutines are not bound to any CPUs.
+func (b *VariableTTLBuilder[K, V]) CPUAffinity(cpus []int) *VariableTTLBuilder[K, V] {
+	b.setCPUAffinity(cpus)
+	return b
+}
+
+// RuntimeTrace specifies that Get, Set, Delete and the waits for the internal eviction lock should be
+// wrapped in the runtime/trace regions, so that they are visible in the go tool trace output.
+//
+// By default, the operations are not traced and the tracing costs nothing.
+func (b *VariableTTLBuilder[K, V]) RuntimeTrace() *VariableTTLBuilder[K, V] {
+	b.enableRuntimeTrace()
+	return b
+}
+
+// PreExpiryCallback specifies a function that is called once for each item approximately lead before
+// the item expires, e.g. to refresh it in the background. The callback is called by the cleanup goroutine
+// for the items that expire within lead, so it should be fast. The items with a ttl shorter than lead are notified
+// by the first cleanup cycle after they are inserted, and the callback is not called at all if ManualCleanup is set.
+func (b *VariableTTLBuilder[K, V]) PreExpiryCallback(lead time.Duration, callback func(key K, value V)) *VariableTTLBuilder[K, V] {
+	b.setPreExpiryCallback(lead, callback)
+	return b
+}
+
+// MinCleanupInterval sets the lower bound of the interval between the cycles of the background cleanup
+// of the expired items. The interval is shortened down to it when many items expire in a cycle.
+//
+// By default, it is 100ms.
+func (b *VariableTTLBuilder[K, V]) MinCleanupInterval(interval time.Duration) *VariableTTLBuilder[K, V] {
+	b.setMinCleanupInterval(interval)
+	return b
+}
+
+// MaxCleanupInterval sets the upper bound of the interval between the cycles of the background cleanup
+// of the expired items, which is used when few items expire.
+//
+// By default, it is 1s.
+func (b *VariableTTLBuilder[K, V]) MaxCleanupInterval(interval time.Duration) *VariableTTLBuilder[K, V] {
+	b.setMaxCleanupInterval(interval)
+	return b
+}
+
+// CleanupBatchSize limits the number of the expired items removed by a cycle of the background cleanup.
+// If a cycle hits the limit, then the next one starts right away, so the limit bounds the time the cleanup
+// holds the internal lock and the memory it allocates, but the expired items may stay in the cache longer.
+//
+// By default, it is 0, which means no limit.
+func (b *VariableTTLBuilder[K, V]) CleanupBatchSize(batchSize int) *VariableTTLBuilder[K, V] {
+	b.setCleanupBatchSize(batchSize)
+	return b
+}
+
+// CleanupConcurrency sets the number of the goroutines that remove the expired items in a cycle
+// of the background cleanup. Each goroutine walks its own partition of the timer wheel under the internal lock,
+// which is released between the partitions, so the writes are not blocked for the whole walk. Then each goroutine
+// deletes its items from the hash table and notifies the listeners in parallel with the others, so it pays off
+// for the caches in which many items expire at once. The batch size set by CleanupBatchSize is split
+// between the goroutines.
+//
+// The deletion listener is called concurrently if the concurrency is greater than 1.
+// By default, the expired items are removed by the cleanup goroutine itself.
+func (b *VariableTTLBuilder[K, V]) CleanupConcurrency(concurrency int) *VariableTTLBuilder[K, V] {
+	b.setCleanupConcurrency(concurrency)
+	return b
+}
+
 // InitialCapacity sets the minimum total size for the internal data structures. Providing a large enough estimate
 // at construction time avoids the need for expensive resizing operations later, but setting this
 // value unnecessarily high wastes memory.
@@ -284,6 +1316,30 @@ func (b *VariableTTLBuilder[K, V]) InitialCapacity(initialCapacity int) *Variabl
 	return b
 }
 
+// LoadFactor sets the occupancy of the internal hash table at which it is expanded. It must be
+// in the [MinLoadFactor, MaxLoadFactor] range, and 0.75 is used by default.
+//
+// A lower load factor means fewer collisions and faster lookups at the cost of a larger table,
+// which suits the latency-critical caches. A higher one packs the entries tighter and saves memory,
+// but the lookups walk longer bucket chains.
+func (b *VariableTTLBuilder[K, V]) LoadFactor(loadFactor float64) *VariableTTLBuilder[K, V] {
+	b.setLoadFactor(loadFactor)
+	return b
+}
+
+// Hasher sets the function used to hash the keys in the internal hash table, e.g. a faster
+// or better distributed one for the particular key type. The function must be deterministic,
+// and the keys equal according to == must have the same hash.
+//
+// Unlike the default hasher, it is not seeded randomly, so the keys controlled by an attacker can be
+// chosen to collide. The low bits of the hash select the bucket, so they must be well distributed.
+//
+// By default, the keys are hashed with the runtime hash function used by the Go maps.
+func (b *VariableTTLBuilder[K, V]) Hasher(hash func(key K) uint64) *VariableTTLBuilder[K, V] {
+	b.setHasher(hash)
+	return b
+}
+
 // Cost sets a function to dynamically calculate the cost of an item.
 //
 // By default, this function always returns 1.
@@ -300,6 +1356,202 @@ func (b *VariableTTLBuilder[K, V]) DeletionListener(deletionListener func(key K,
 	return b
 }
 
+// DeletionBatchListener specifies a listener instance that caches should notify once per batch of deleted entries.
+//
+// The batches are collected by the background goroutines, so the listener is called once for all entries
+// evicted, expired or deleted during one drain, which allows bulk downstream operations.
+// The entries slice is owned by the listener. It can be used together with DeletionListener.
+func (b *VariableTTLBuilder[K, V]) DeletionBatchListener(deletionBatchListener func(entries []DeletedEntry[K, V])) *VariableTTLBuilder[K, V] {
+	b.setDeletionBatchListener(deletionBatchListener)
+	return b
+}
+
+// OnPanic specifies a handler that is notified about the panics in the user callbacks: the cost function,
+// the deletion listeners, the event bus and the loaders. The stage is one of the PanicStage constants.
+//
+// If the handler is set, the panics are recovered and the cache keeps operating, otherwise a panic
+// in a callback called by a background goroutine crashes the program.
+func (b *VariableTTLBuilder[K, V]) OnPanic(handler func(stage string, r any)) *VariableTTLBuilder[K, V] {
+	b.setPanicHandler(handler)
+	return b
+}
+
+// LoaderRateLimit limits the calls of the loaders passed to GetMultiOrSet to rps calls per second
+// on average with the bursts of up to rps calls. The limiter belongs to the cache and is shared by all its loads.
+// The concurrent loads of the same keys are deduplicated before the limit is applied.
+//
+// When the limit is exceeded, the load waits for its turn with the RateLimitWait behavior
+// or fails with ErrRateLimited with the RateLimitReject behavior.
+//
+// By default, the loader calls are not limited.
+func (b *VariableTTLBuilder[K, V]) LoaderRateLimit(rps float64, behavior RateLimitBehavior) *VariableTTLBuilder[K, V] {
+	b.setLoaderRateLimit(rps, behavior)
+	return b
+}
+
+// LoaderCircuitBreaker stops calling the loaders passed to GetMultiOrSet after threshold consecutive failures,
+// so that the downstream service has time to recover. While the circuit is open, the loads of the missing keys
+// fail with ErrCircuitOpen right away. After the cooldown, a single trial call is allowed: the circuit is closed
+// if it succeeds and opened again otherwise. The state is reported by Cache.CircuitState.
+//
+// By default, the loaders are always called.
+func (b *VariableTTLBuilder[K, V]) LoaderCircuitBreaker(threshold int, cooldown time.Duration) *VariableTTLBuilder[K, V] {
+	b.setLoaderCircuitBreaker(threshold, cooldown)
+	return b
+}
+
+// LoaderRetry retries the failed calls of the loaders passed to GetMultiOrSet, so that a load makes up to
+// maxAttempts calls in total and waits for the duration returned by the backoff policy before each retry.
+// FixedBackoff and ExponentialBackoff provide the common policies. The waits are interrupted by the cancellation
+// of the context passed to GetMultiOrSetContext. If all the attempts fail, the error of the last one is returned.
+//
+// The retries happen inside the circuit breaker, so they are counted as a single failure, and each of them
+// is subject to the rate limit.
+//
+// By default, the failed loads are not retried.
+func (b *VariableTTLBuilder[K, V]) LoaderRetry(maxAttempts int, backoff BackoffPolicy) *VariableTTLBuilder[K, V] {
+	b.setLoaderRetry(maxAttempts, backoff)
+	return b
+}
+
+// CloneValues specifies a function that copies the values, which gives the value semantics to the mutable values
+// like slices and maps. The writes like Set, GetOrSet and Transact store a clone of the given value, and the reads
+// like Get, Range, GetAndDelete, NextEvictions and the snapshots return a clone of the cached value,
+// so the callers can't corrupt the values seen by others.
+//
+// By default, the values are not cloned, because it costs CPU and is not needed for the immutable values.
+func (b *VariableTTLBuilder[K, V]) CloneValues(cloneFunc func(value V) V) *VariableTTLBuilder[K, V] {
+	b.setCloneFunc(cloneFunc)
+	return b
+}
+
+// Equal specifies a function that compares the values in CompareAndSwap, CompareAndDelete and SetIfChanged.
+//
+// By default, the values are compared with == via the interface conversion, which panics for
+// the non-comparable values, so the function must be set for them. Use NewComparableBuilder
+// to compare the comparable values with the native ==.
+func (b *VariableTTLBuilder[K, V]) Equal(equalFunc func(a, b V) bool) *VariableTTLBuilder[K, V] {
+	b.setEqualFunc(equalFunc)
+	return b
+}
+
+// ValueInterning specifies that the equal values stored in the cache should share a single canonical copy.
+// When a stored value is equal to an interned one, the cache keeps the interned value instead of the new one,
+// so the memory referenced by the values (the bytes of strings, the backing arrays of slices, the pointed-to
+// structs) is not duplicated. It saves memory for the caches where many entries share the same value,
+// like the configuration flags or the status strings.
+//
+// The equal and hash functions are called on each write. The intern table is bounded by the capacity
+// of the cache, and a value can displace the interned one with the same hash slot, so the deduplication
+// is best-effort. The values without pointers are copied anyway, so they don't benefit from the interning.
+func (b *VariableTTLBuilder[K, V]) ValueInterning(equal func(a, b V) bool, hash func(v V) uint64) *VariableTTLBuilder[K, V] {
+	b.setValueInterning(equal, hash)
+	return b
+}
+
+// EventBus specifies an EventBus to which the cache should publish the events about the changes of its entries.
+// The events are published in the background goroutine after the corresponding operation has completed.
+func (b *VariableTTLBuilder[K, V]) EventBus(eventBus *EventBus[K, V]) *VariableTTLBuilder[K, V] {
+	b.setEventBus(eventBus)
+	return b
+}
+
+// EventChannel specifies that the cache should deliver the events about the changes of its entries
+// to the channel returned by Events, which buffers at most bufferSize events. overflow determines
+// what happens to a new event when the channel is full. The channel is closed when the cache is closed.
+//
+// Unlike the listeners, the events are consumed in a goroutine of the caller, so the slow processing
+// doesn't stall the cache unless BlockOnFullEvents is used.
+func (b *VariableTTLBuilder[K, V]) EventChannel(bufferSize int, overflow EventOverflow) *VariableTTLBuilder[K, V] {
+	b.setEventChannel(bufferSize, overflow)
+	return b
+}
+
+// DryRun enables the dry-run mode. In this mode the cache works as usual, but the entries selected
+// for eviction due to size constraints are not deleted and the deletion listener is not notified.
+// Instead, the cache counts them, and the result is available via DryRunStats.
+//
+// This mode is intended for capacity planning: the cache can grow beyond its capacity in it.
+func (b *VariableTTLBuilder[K, V]) DryRun() *VariableTTLBuilder[K, V] {
+	b.enableDryRun()
+	return b
+}
+
+// TrackLastAccess specifies that the cache should record the time of the last access to each item.
+// The time is available via LastAccess.
+//
+// By default, the access time is not tracked, because it requires an additional field in each item
+// and a write on each read.
+func (b *VariableTTLBuilder[K, V]) TrackLastAccess() *VariableTTLBuilder[K, V] {
+	b.trackLastAccess()
+	return b
+}
+
+// TrackCreatedAt specifies that the cache should record the time when each item was inserted.
+// The time is available via CreatedAt.
+//
+// By default, the creation time is not tracked, because it requires an additional field in each item.
+func (b *VariableTTLBuilder[K, V]) TrackCreatedAt() *VariableTTLBuilder[K, V] {
+	b.trackCreatedAt()
+	return b
+}
+
+// TrackWriteLatency specifies that the cache should record the time the writes wait in the write buffer
+// before they are applied to the eviction policy. The latency distribution is available via Stats
+// and is recorded only if the statistics are collected.
+//
+// By default, the write latency is not tracked, because it costs a time.Now call per write.
+func (b *VariableTTLBuilder[K, V]) TrackWriteLatency() *VariableTTLBuilder[K, V] {
+	b.enableWriteLatencyTracking()
+	return b
+}
+
+// TimeResolution sets how often the internal clock used for expiration and access times is updated.
+// A finer resolution makes the expiration more precise at the cost of a more frequent background update.
+// A resolution below a millisecond makes the clock read the current time on every access instead.
+//
+// By default, the clock is updated every second.
+func (b *VariableTTLBuilder[K, V]) TimeResolution(timeResolution time.Duration) *VariableTTLBuilder[K, V] {
+	b.setTimeResolution(timeResolution)
+	return b
+}
+
+// WarmUpThreshold sets the fraction of the capacity that the cache should be filled to be considered warm.
+// The channel returned by WarmUpDone is closed once the number of items reaches this fraction of the capacity.
+func (b *VariableTTLBuilder[K, V]) WarmUpThreshold(threshold float64) *VariableTTLBuilder[K, V] {
+	b.setWarmUpThreshold(threshold)
+	return b
+}
+
+// EvictionPolicy sets the algorithm used to select the entries to evict.
+//
+// By default, the S3FIFO policy is used. LRU, an approximate LRU as the reads are recorded through lossy buffers,
+// can be used for the workloads that need the recently used entries to survive rather than the scan resistance,
+// and LFU for the stable working sets dominated by the frequently used entries.
+func (b *VariableTTLBuilder[K, V]) EvictionPolicy(evictionPolicy EvictionPolicy) *VariableTTLBuilder[K, V] {
+	b.setEvictionPolicy(evictionPolicy)
+	return b
+}
+
+// Compact specifies that the cache should use the minimal number and size of the internal buffers
+// regardless of the number of available CPUs. It lowers the baseline memory usage of the cache
+// at the cost of the throughput under contention, so it suits the processes with many small caches.
+func (b *VariableTTLBuilder[K, V]) Compact() *VariableTTLBuilder[K, V] {
+	b.enableCompact()
+	return b
+}
+
+// ManualCleanup specifies that the cache should not start the background goroutine that removes
+// the expired items every second. The expired items are still never returned by the reads,
+// but their memory is reclaimed only when PurgeExpired is called or they are evicted.
+//
+// Note that the internal clock used for the expiration is still updated by a background goroutine
+// shared by all caches.
+func (b *VariableTTLBuilder[K, V]) ManualCleanup() *VariableTTLBuilder[K, V] {
+	b.enableManualCleanup()
+	return b
+}
+
 // Build creates a configured cache or
 // returns an error if invalid parameters were passed to the builder.
 func (b *VariableTTLBuilder[K, V]) Build() (CacheWithVariableTTL[K, V], error) {
@@ -307,5 +1559,5 @@ func (b *VariableTTLBuilder[K, V]) Build() (CacheWithVariableTTL[K, V], error) {
 		return CacheWithVariableTTL[K, V]{}, err
 	}
 
-	return newCacheWithVariableTTL(b.toConfig()), nil
+	return newCacheWithVariableTTL(b.toConfig(), b.events), nil
 }
@@ -17,6 +17,7 @@ package otter
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -61,11 +62,116 @@ func TestBuilder_NewFailed(t *testing.T) {
 		t.Fatalf("should fail with an error %v, but got %v", ErrIllegalInitialCapacity, err)
 	}
 
+	// nil stats recorder
+	_, err = MustBuilder[int, int](capacity).RecordStats(nil).Build()
+	if err == nil || !errors.Is(err, ErrNilStatsRecorder) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrNilStatsRecorder, err)
+	}
+
+	// nil value interning funcs
+	_, err = MustBuilder[int, string](capacity).ValueInterning(nil, nil).Build()
+	if err == nil || !errors.Is(err, ErrNilInterningFunc) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrNilInterningFunc, err)
+	}
+
+	// nil hasher
+	_, err = MustBuilder[int, int](capacity).Hasher(nil).Build()
+	if err == nil || !errors.Is(err, ErrNilHasher) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrNilHasher, err)
+	}
+
+	// illegal loader rate limit
+	for _, tt := range []struct {
+		rps      float64
+		behavior RateLimitBehavior
+	}{
+		{rps: 0, behavior: RateLimitWait},
+		{rps: math.NaN(), behavior: RateLimitReject},
+		{rps: 10, behavior: RateLimitBehavior(10)},
+	} {
+		_, err = MustBuilder[int, int](capacity).LoaderRateLimit(tt.rps, tt.behavior).Build()
+		if err == nil || !errors.Is(err, ErrIllegalLoaderRateLimit) {
+			t.Fatalf("should fail with an error %v, but got %v", ErrIllegalLoaderRateLimit, err)
+		}
+	}
+
+	// illegal loader circuit breaker
+	for _, tt := range []struct {
+		threshold int
+		cooldown  time.Duration
+	}{
+		{threshold: 0, cooldown: time.Second},
+		{threshold: 3, cooldown: 0},
+	} {
+		_, err = MustBuilder[int, int](capacity).LoaderCircuitBreaker(tt.threshold, tt.cooldown).Build()
+		if err == nil || !errors.Is(err, ErrIllegalLoaderCircuitBreaker) {
+			t.Fatalf("should fail with an error %v, but got %v", ErrIllegalLoaderCircuitBreaker, err)
+		}
+	}
+
+	// illegal loader retry
+	for _, tt := range []struct {
+		maxAttempts int
+		backoff     BackoffPolicy
+	}{
+		{maxAttempts: 0, backoff: FixedBackoff(time.Second)},
+		{maxAttempts: 3, backoff: nil},
+	} {
+		_, err = MustBuilder[int, int](capacity).LoaderRetry(tt.maxAttempts, tt.backoff).Build()
+		if err == nil || !errors.Is(err, ErrIllegalLoaderRetry) {
+			t.Fatalf("should fail with an error %v, but got %v", ErrIllegalLoaderRetry, err)
+		}
+	}
+
+	// illegal cpu affinity
+	for _, cpus := range [][]int{nil, {0, -1}} {
+		_, err = MustBuilder[int, int](capacity).CPUAffinity(cpus).Build()
+		if err == nil || !errors.Is(err, ErrIllegalCPUAffinity) {
+			t.Fatalf("should fail with an error %v, but got %v", ErrIllegalCPUAffinity, err)
+		}
+	}
+
+	// illegal load factor
+	for _, loadFactor := range []float64{0, 0.2, 1.5, math.NaN()} {
+		_, err = MustBuilder[int, int](capacity).LoadFactor(loadFactor).Build()
+		if err == nil || !errors.Is(err, ErrIllegalLoadFactor) {
+			t.Fatalf("should fail with an error %v, but got %v", ErrIllegalLoadFactor, err)
+		}
+	}
+
 	// nil cost func
 	_, err = MustBuilder[int, int](capacity).Cost(nil).Build()
 	if err == nil || !errors.Is(err, ErrNilCostFunc) {
 		t.Fatalf("should fail with an error %v, but got %v", ErrNilCostFunc, err)
 	}
+
+	// illegal time resolution
+	_, err = MustBuilder[int, int](capacity).WithTTL(time.Minute).TimeResolution(2 * time.Second).Build()
+	if err == nil || !errors.Is(err, ErrIllegalTimeResolution) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrIllegalTimeResolution, err)
+	}
+
+	// illegal warm-up threshold
+	_, err = MustBuilder[int, int](capacity).WarmUpThreshold(1.5).Build()
+	if err == nil || !errors.Is(err, ErrIllegalWarmUpThreshold) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrIllegalWarmUpThreshold, err)
+	}
+
+	// unknown eviction policy
+	_, err = MustBuilder[int, int](capacity).EvictionPolicy(EvictionPolicy(100)).Build()
+	if err == nil || !errors.Is(err, ErrIllegalEvictionPolicy) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrIllegalEvictionPolicy, err)
+	}
+
+	// min cleanup interval greater than max
+	_, err = MustBuilder[int, int](capacity).
+		WithTTL(time.Minute).
+		MinCleanupInterval(time.Second).
+		MaxCleanupInterval(time.Millisecond).
+		Build()
+	if err == nil || !errors.Is(err, ErrIllegalCleanupInterval) {
+		t.Fatalf("should fail with an error %v, but got %v", ErrIllegalCleanupInterval, err)
+	}
 }
 
 func TestBuilder_BuildSuccess(t *testing.T) {
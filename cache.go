@@ -15,6 +15,10 @@
 package otter
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/maypok86/otter/internal/core"
@@ -34,13 +38,168 @@ const (
 	Expired = core.Expired
 )
 
+// EvictionPolicy is the algorithm used to select the entries to evict.
+type EvictionPolicy = core.EvictionPolicy
+
+const (
+	// S3FIFO the scan-resistant S3-FIFO eviction policy. It is used by default.
+	S3FIFO = core.S3FIFO
+	// LRU the approximate LRU policy: the entries are evicted in the order of recency of their use.
+	//
+	// The reads are applied to the policy in batches and may be dropped under contention,
+	// so the order of recency is approximate.
+	LRU = core.LRU
+	// Sampled the least recently used entry among several randomly sampled ones is evicted (like in Redis).
+	//
+	// It doesn't maintain any eviction queues, but it requires the cache to track the last access time
+	// of the entries, and the eviction quality is lower than with the other policies.
+	Sampled = core.Sampled
+	// LFU the S3-FIFO variant that gives most of the capacity to the frequently used entries
+	// at the cost of the scan resistance.
+	//
+	// It suits the stable working sets with long-lived hot entries, where the frequency of use
+	// predicts the future accesses better than the recency.
+	LFU = core.LFU
+)
+
+// RateLimitBehavior determines what a load does when the rate limit of the loader is exceeded.
+type RateLimitBehavior = core.RateLimitBehavior
+
+const (
+	// RateLimitWait the load waits until the loader can be called. It is used by default.
+	RateLimitWait = core.RateLimitWait
+	// RateLimitReject the load fails with ErrRateLimited without calling the loader.
+	RateLimitReject = core.RateLimitReject
+)
+
+// CircuitBreakerState is the state of the circuit breaker of the loader.
+type CircuitBreakerState = core.CircuitBreakerState
+
+const (
+	// CircuitClosed the loader is called as usual.
+	CircuitClosed = core.CircuitClosed
+	// CircuitOpen the loader has failed too many times in a row, and the loads fail with ErrCircuitOpen
+	// without calling it until the cooldown passes.
+	CircuitOpen = core.CircuitOpen
+	// CircuitHalfOpen the cooldown has passed and a single trial call of the loader is in progress.
+	CircuitHalfOpen = core.CircuitHalfOpen
+)
+
+// BackoffPolicy determines how long to wait before the retry of a failed loader call.
+type BackoffPolicy = core.BackoffPolicy
+
+// FixedBackoff returns the backoff policy that waits the same interval before each retry.
+func FixedBackoff(interval time.Duration) BackoffPolicy {
+	return core.FixedBackoff(interval)
+}
+
+// ExponentialBackoff returns the backoff policy that waits base before the first retry
+// and doubles the wait before each next one, but never waits longer than max.
+func ExponentialBackoff(base, max time.Duration) BackoffPolicy {
+	return core.ExponentialBackoff(base, max)
+}
+
+// ConflictPolicy determines which item is kept when merging the caches with the same key.
+type ConflictPolicy = core.ConflictPolicy
+
+const (
+	// ConflictKeepExisting the item of the receiver is kept.
+	ConflictKeepExisting = core.ConflictKeepExisting
+	// ConflictOverwrite the item of the receiver is overwritten.
+	ConflictOverwrite = core.ConflictOverwrite
+	// ConflictKeepNewerTTL the item that expires later is kept.
+	ConflictKeepNewerTTL = core.ConflictKeepNewerTTL
+)
+
+// The stages of the user callbacks passed to the OnPanic handler.
+const (
+	// PanicStageCost the cost function has panicked. The item is rejected.
+	PanicStageCost = core.PanicStageCost
+	// PanicStageDeletionListener the deletion listener has panicked.
+	PanicStageDeletionListener = core.PanicStageDeletionListener
+	// PanicStageSetListener the set listener of the event bus has panicked.
+	PanicStageSetListener = core.PanicStageSetListener
+	// PanicStageLoader the loader has panicked. ErrLoaderPanicked is returned to the caller.
+	PanicStageLoader = core.PanicStageLoader
+	// PanicStagePreExpiry the pre-expiry callback has panicked.
+	PanicStagePreExpiry = core.PanicStagePreExpiry
+)
+
+var (
+	// ErrFrozen means that a write has been rejected because the cache is in the read-only mode.
+	ErrFrozen = core.ErrFrozen
+	// ErrAlreadyFrozen means that Freeze has been called on the cache that is already in the read-only mode.
+	ErrAlreadyFrozen = core.ErrAlreadyFrozen
+	// ErrUnlockedKey means that the Transact function has returned a key that was not passed to Transact.
+	ErrUnlockedKey = core.ErrUnlockedKey
+	// ErrCostTooLarge means that the cost of an item exceeds the maximum available cost.
+	ErrCostTooLarge = core.ErrCostTooLarge
+	// ErrLoaderPanicked means that the loader has panicked and the panic has been passed to the OnPanic handler.
+	ErrLoaderPanicked = core.ErrLoaderPanicked
+	// ErrRateLimited means that the loader has not been called because its rate limit has been exceeded.
+	ErrRateLimited = core.ErrRateLimited
+	// ErrCircuitOpen means that the loader has not been called because it has failed too many times in a row.
+	ErrCircuitOpen = core.ErrCircuitOpen
+	// ErrClosed means that the cache has been closed.
+	ErrClosed = errors.New("cache is closed")
+	// ErrWriteBufferOverload means that the write buffer is almost full and the writes may soon be blocked.
+	ErrWriteBufferOverload = errors.New("write buffer is overloaded")
+	// ErrProcessStalled means that the background goroutine applying the writes has not made progress for too long.
+	ErrProcessStalled = errors.New("write processing goroutine is stalled")
+	// ErrCleanupStalled means that the background goroutine removing the expired entries has not made progress
+	// for too long.
+	ErrCleanupStalled = errors.New("cleanup goroutine is stalled")
+)
+
+// ctxCheckInterval is the number of items processed by bulk operations between the context checks.
+const ctxCheckInterval = 1024
+
+// Entry is a key-value pair.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Item is a key-value pair with its own ttl.
+type Item[K comparable, V any] struct {
+	Key   K
+	Value V
+	TTL   time.Duration
+}
+
+// Cursor is an opaque position of the paginated iteration over the cache. The zero Cursor is the start
+// of the iteration. It is a plain integer, so it can be passed across the request boundaries.
+type Cursor uint64
+
+// KeyFrequency is a key with the estimated frequency of accesses to it.
+type KeyFrequency[K comparable] struct {
+	Key                K
+	EstimatedFrequency int
+}
+
+// DeletedEntry is an item deleted from the cache with the cause of the deletion.
+type DeletedEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Cause DeletionCause
+}
+
+// EvictionCandidate is an item that is likely to be evicted soon.
+type EvictionCandidate[K comparable, V any] struct {
+	Key   K
+	Value V
+	Cost  uint32
+}
+
 type baseCache[K comparable, V any] struct {
-	cache *core.Cache[K, V]
+	cache  *core.Cache[K, V]
+	events *eventChannel[K, V]
 }
 
-func newBaseCache[K comparable, V any](c core.Config[K, V]) baseCache[K, V] {
+func newBaseCache[K comparable, V any](c core.Config[K, V], events *eventChannel[K, V]) baseCache[K, V] {
 	return baseCache[K, V]{
-		cache: core.NewCache(c),
+		cache:  core.NewCache(c),
+		events: events,
 	}
 }
 
@@ -50,18 +209,164 @@ func (bs baseCache[K, V]) Has(key K) bool {
 }
 
 // Get returns the value associated with the key in this cache.
+//
+// An expired item is never returned, even if it has not yet been removed by the background cleanup:
+// the expiration is checked on each read, and the cleanup only reclaims the memory. Get, Has, Range and
+// the other read operations follow this contract. Note that the current time is read from the internal clock,
+// so an item can still be returned for up to the clock resolution (see TimeResolution) after its ttl
+// has elapsed. With a resolution below a millisecond, an item is never returned after its deadline.
 func (bs baseCache[K, V]) Get(key K) (V, bool) {
 	return bs.cache.Get(key)
 }
 
+// GetPtr returns the pointer to the value associated with the key in this cache instead of a copy of the value.
+// It avoids copying the large values on the read-heavy workloads.
+//
+// The pointer refers to the cache's internal storage, so the value must be treated as read-only:
+// modifying it is a data race with the other readers. The cache never changes the stored value in place,
+// so after the next Set of the key the pointer remains valid but refers to the stale value,
+// and the caller shouldn't retain it beyond the next modification of the key.
+// If CloneValues is set on the builder, the pointer to a clone of the value is returned.
+func (bs baseCache[K, V]) GetPtr(key K) (*V, bool) {
+	return bs.cache.GetPtr(key)
+}
+
+// LastAccess returns the time of the last read of the item with the given key. The time of the item's creation
+// is returned if it has not been read yet.
+//
+// The time is tracked with the granularity set by TimeResolution, one second by default.
+// It returns false if the item is not found or the access time tracking is not enabled via TrackLastAccess.
+func (bs baseCache[K, V]) LastAccess(key K) (time.Time, bool) {
+	return bs.cache.LastAccess(key)
+}
+
+// CreatedAt returns the time when the item with the given key was inserted into the cache. Unlike LastAccess,
+// it is not updated by the reads, but every Set creates a new item, so an update resets the creation time.
+//
+// The time is tracked with the granularity set by TimeResolution, one second by default.
+// It returns false if the item is not found or the creation time tracking is not enabled via TrackCreatedAt.
+func (bs baseCache[K, V]) CreatedAt(key K) (time.Time, bool) {
+	return bs.cache.CreatedAt(key)
+}
+
+// GetAll returns the values associated with the keys in this cache.
+//
+// The returned map contains only the keys found in the cache.
+func (bs baseCache[K, V]) GetAll(keys []K) map[K]V {
+	result, _, _ := bs.GetAllContext(context.Background(), keys)
+	return result
+}
+
+// GetAllContext is like GetAll, but stops early if the context is done.
+//
+// It returns the values found so far, the number of processed keys (keys[:processed] were looked up)
+// and the context error if the operation was aborted.
+func (bs baseCache[K, V]) GetAllContext(ctx context.Context, keys []K) (result map[K]V, processed int, err error) {
+	result = make(map[K]V, len(keys))
+	processed, err = forEachWithContext(ctx, keys, func(key K) {
+		if value, ok := bs.cache.Get(key); ok {
+			result[key] = value
+		}
+	})
+	return result, processed, err
+}
+
+// Transact atomically reads the given keys, calls f with a snapshot of their values and writes the items
+// returned by f. The concurrent writes of these keys wait until the transaction is completed.
+// The keys that are absent in the cache are absent in the snapshot, and the keys that are not returned by f
+// are left unchanged.
+//
+// f can return only the keys passed to Transact, otherwise nothing is written and ErrUnlockedKey is returned.
+// If the cost of any returned item is too large, nothing is written and ErrCostTooLarge is returned.
+// Returns ErrFrozen if the cache is in the read-only mode.
+//
+// NOTE: f is called while the internal locks are held, so it must be fast and must not call the cache methods.
+// The items written by Transact get the default ttl of the cache or no ttl for a cache with a variable ttl.
+func (bs baseCache[K, V]) Transact(keys []K, f func(snapshot map[K]V) map[K]V) error {
+	return bs.cache.Transact(keys, f)
+}
+
+// Pin marks the item with the given key as non-evictable until the matching Unpin, for example,
+// while the cached value is borrowed by a caller. It reports whether the key was present in the cache.
+// The pins are counted, so each successful Pin needs its own Unpin.
+//
+// The pinned items are skipped by the eviction policy regardless of the capacity pressure. They don't count
+// towards the capacity, so the cache may hold more than the capacity if too many items are pinned.
+// The pin belongs to the key: if the key is updated, the new value stays pinned. The pinned items
+// still expire and can be deleted explicitly. When the key is deleted, expires or the cache is cleared,
+// its pins are dropped, so Unpin returns false and the later inserts of the key are not pinned.
+func (bs baseCache[K, V]) Pin(key K) bool {
+	return bs.cache.Pin(key)
+}
+
+// Unpin removes a pin added by Pin. It reports whether the key was pinned.
+//
+// When the last pin is removed, the item is returned to the eviction policy, so it or the other items
+// may be evicted right away if the cache is over capacity.
+func (bs baseCache[K, V]) Unpin(key K) bool {
+	return bs.cache.Unpin(key)
+}
+
 // Delete removes the association for this key from the cache.
+//
+// The delete is ignored if the cache is in the read-only mode, use TryDelete to detect it.
 func (bs baseCache[K, V]) Delete(key K) {
-	bs.cache.Delete(key)
+	_ = bs.cache.Delete(key)
+}
+
+// TryDelete is like Delete, but returns ErrFrozen if the cache is in the read-only mode.
+func (bs baseCache[K, V]) TryDelete(key K) error {
+	return bs.cache.Delete(key)
+}
+
+// DeleteWithResult removes the association for this key from the cache and
+// reports whether a live entry was removed. It returns false if the key was absent or the entry has already expired.
+func (bs baseCache[K, V]) DeleteWithResult(key K) bool {
+	return bs.cache.DeleteWithResult(key)
+}
+
+// GetAndDelete removes the association for this key from the cache and returns the value of the removed entry.
+// It returns false if the key was absent or the entry has already expired.
+func (bs baseCache[K, V]) GetAndDelete(key K) (V, bool) {
+	return bs.cache.GetAndDelete(key)
+}
+
+// CompareAndDelete removes the association for this key from the cache if its value is equal to oldValue.
+// It reports whether the entry was removed.
+//
+// The values are compared with the function set by Equal or with == if it is not set.
+func (bs baseCache[K, V]) CompareAndDelete(key K, oldValue V) bool {
+	return bs.cache.CompareAndDelete(key, oldValue)
+}
+
+// DeleteAll removes the associations for these keys from the cache.
+func (bs baseCache[K, V]) DeleteAll(keys []K) {
+	_, _ = bs.DeleteAllContext(context.Background(), keys)
+}
+
+// DeleteAllContext is like DeleteAll, but stops early if the context is done.
+//
+// It returns the number of processed keys (keys[:processed] were deleted)
+// and the context error if the operation was aborted. Returns ErrFrozen if the cache is in the read-only mode.
+func (bs baseCache[K, V]) DeleteAllContext(ctx context.Context, keys []K) (processed int, err error) {
+	if bs.cache.IsFrozen() {
+		return 0, ErrFrozen
+	}
+	return forEachWithContext(ctx, keys, func(key K) {
+		_ = bs.cache.Delete(key)
+	})
 }
 
 // DeleteByFunc removes the association for this key from the cache when the given function returns true.
+//
+// The delete is ignored if the cache is in the read-only mode, use TryDeleteByFunc to detect it.
 func (bs baseCache[K, V]) DeleteByFunc(f func(key K, value V) bool) {
-	bs.cache.DeleteByFunc(f)
+	_ = bs.cache.DeleteByFunc(f)
+}
+
+// TryDeleteByFunc is like DeleteByFunc, but returns ErrFrozen if the cache is in the read-only mode.
+func (bs baseCache[K, V]) TryDeleteByFunc(f func(key K, value V) bool) error {
+	return bs.cache.DeleteByFunc(f)
 }
 
 // Range iterates over all items in the cache.
@@ -71,9 +376,59 @@ func (bs baseCache[K, V]) Range(f func(key K, value V) bool) {
 	bs.cache.Range(f)
 }
 
+// RangePage returns at most limit items starting from the position encoded by cursor and the cursor
+// to request the next page with. The iteration starts with the zero Cursor and is complete when
+// the zero Cursor is returned. It is intended for listing the cache contents page by page, e.g. in an admin UI,
+// without holding a Range callback open or copying the whole cache for each page.
+//
+// The pages are best-effort under the concurrent modifications: the items written or deleted between
+// the calls, or moved by a resize of the hash table, may be skipped or returned more than once.
+//
+// If limit is not positive, RangePage returns no items and the given cursor.
+func (bs baseCache[K, V]) RangePage(cursor Cursor, limit int) ([]Entry[K, V], Cursor) {
+	var entries []Entry[K, V]
+	next := bs.cache.RangePage(uint64(cursor), limit, func(key K, value V) {
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+	})
+	return entries, Cursor(next)
+}
+
+// RangeKeys iterates over the keys of all items in the cache. Unlike Range, it doesn't read
+// and clone the values, so it is cheaper when only the keys are needed.
+//
+// Iteration stops early when the given function returns false.
+func (bs baseCache[K, V]) RangeKeys(f func(key K) bool) {
+	bs.cache.RangeKeys(f)
+}
+
+// Freeze switches the cache to the read-only mode. While the cache is frozen, the writes keep their signatures:
+// Set, SetIfAbsent and the other writes that report the result with a bool return false, and Delete and
+// DeleteByFunc do nothing. The writes that return an error, such as TryDelete, TryDeleteByFunc,
+// DeleteAllContext, Transact and Prime, return ErrFrozen. Check IsFrozen to tell a rejected write
+// from a dropped one. Get, Has, Range and Stats continue to work and the items still expire and can be evicted.
+//
+// Returns ErrAlreadyFrozen if the cache is already frozen.
+func (bs baseCache[K, V]) Freeze() error {
+	if !bs.cache.Freeze() {
+		return ErrAlreadyFrozen
+	}
+	return nil
+}
+
+// Unfreeze switches the cache back from the read-only mode.
+func (bs baseCache[K, V]) Unfreeze() {
+	bs.cache.Unfreeze()
+}
+
+// IsFrozen returns true if the cache is in the read-only mode.
+func (bs baseCache[K, V]) IsFrozen() bool {
+	return bs.cache.IsFrozen()
+}
+
 // Clear clears the hash table, all policies, buffers, etc.
 //
-// NOTE: this operation must be performed when no requests are made to the cache otherwise the behavior is undefined.
+// It is safe to call Clear concurrently with other operations: in-flight reads complete against the old items
+// and new reads observe the empty cache.
 func (bs baseCache[K, V]) Clear() {
 	bs.cache.Clear()
 }
@@ -82,7 +437,177 @@ func (bs baseCache[K, V]) Clear() {
 //
 // NOTE: this operation must be performed when no requests are made to the cache otherwise the behavior is undefined.
 func (bs baseCache[K, V]) Close() {
+	if bs.events != nil {
+		// the consumer may have stopped reading the events, so the cache goroutine must not wait for it.
+		bs.events.stop()
+	}
 	bs.cache.Close()
+	if bs.events != nil {
+		bs.events.close()
+	}
+}
+
+// Events returns the channel of the events about the changes of the cache entries.
+// It returns nil if the cache was built without EventChannel.
+//
+// The channel is closed when the cache is closed.
+func (bs baseCache[K, V]) Events() <-chan CacheEvent[K, V] {
+	if bs.events == nil {
+		return nil
+	}
+	return bs.events.events
+}
+
+// DroppedEventCount returns the number of events dropped because the channel returned by Events was full.
+func (bs baseCache[K, V]) DroppedEventCount() int64 {
+	if bs.events == nil {
+		return 0
+	}
+	return bs.events.dropped.Load()
+}
+
+// IsClosed returns true if the cache has been closed. The writes to a closed cache are dropped.
+func (bs baseCache[K, V]) IsClosed() bool {
+	return bs.cache.IsClosed()
+}
+
+// ExpiringWithin returns the approximate number of items that will expire within the given duration.
+//
+// The result is approximate, because expiration times are tracked with the granularity set by TimeResolution
+// and the most recent writes may not yet be taken into account.
+func (bs baseCache[K, V]) ExpiringWithin(d time.Duration) int {
+	return bs.cache.ExpiringWithin(d)
+}
+
+// TTLDistribution returns a histogram of the remaining ttl of the items in the cache.
+//
+// The keys of the histogram are the lower bounds of the buckets: 0 (0-1s), 1s (1-10s), 10s (10-60s),
+// 1m (1-5m) and 5m (5m+). The items without a ttl are not counted, and the histogram is empty
+// if the cache has no expiration policy.
+//
+// This method iterates over all items in the cache, so it's an O(n) operation.
+func (bs baseCache[K, V]) TTLDistribution() map[time.Duration]int {
+	return bs.cache.TTLDistribution()
+}
+
+// CostDistribution returns a histogram of the costs of the items in the cache.
+//
+// This method iterates over all items in the cache, so it's an O(n) operation.
+func (bs baseCache[K, V]) CostDistribution() CostHistogram {
+	return newCostHistogram(bs.cache.CostDistribution())
+}
+
+// TopN returns at most n keys with the highest estimated access frequency in descending order.
+//
+// The frequency is estimated by the eviction policy, so it's saturated at a small value
+// and is reset when an item moves between the policy queues.
+//
+// This method iterates over all items in the cache while holding the eviction lock,
+// so it's an expensive O(n log n) operation.
+func (bs baseCache[K, V]) TopN(n int) []KeyFrequency[K] {
+	top := bs.cache.TopN(n)
+	result := make([]KeyFrequency[K], 0, len(top))
+	for _, kf := range top {
+		result = append(result, KeyFrequency[K]{
+			Key:                kf.Key,
+			EstimatedFrequency: kf.Frequency,
+		})
+	}
+	return result
+}
+
+// NextEvictions returns at most n items that are most likely to be evicted next in the order of eviction.
+//
+// The result is an estimation based on the current state of the eviction policy: the items that have been
+// written recently may not yet be taken into account, and the subsequent reads can save the items from eviction.
+func (bs baseCache[K, V]) NextEvictions(n int) []EvictionCandidate[K, V] {
+	candidates := bs.cache.NextEvictions(n)
+	result := make([]EvictionCandidate[K, V], 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, EvictionCandidate[K, V](c))
+	}
+	return result
+}
+
+// NextVictim returns the key of the item that would be evicted next. Unlike NextEvictions, it applies
+// the buffered writes to the eviction policy first, so the result is deterministic for a single goroutine
+// and can be used to test the eviction order.
+//
+// It returns false if the cache is empty.
+func (bs baseCache[K, V]) NextVictim() (K, bool) {
+	return bs.cache.NextVictim()
+}
+
+// Evict evicts at most n items in the order of the eviction policy right away, even though the cache
+// is not full, and returns the number of the evicted items. The capacity is not changed.
+// The frequently used items are evicted too, and the expired or deleted items are skipped.
+// The deletion listener is notified with the Size cause as for the regular evictions.
+//
+// Evict applies the buffered writes first, so together with NextVictim it makes the eviction
+// deterministic for a single goroutine. It can also be used to free memory on demand.
+func (bs baseCache[K, V]) Evict(n int) int {
+	return bs.cache.Evict(n)
+}
+
+// IsHealthy returns nil if the cache is operating normally, otherwise it returns an error describing the problem:
+// ErrClosed, ErrWriteBufferOverload, ErrProcessStalled or ErrCleanupStalled.
+//
+// IsHealthy is cheap and does not block, so it can be used in liveness probes.
+func (bs baseCache[K, V]) IsHealthy() error {
+	h := bs.cache.Health()
+	switch {
+	case h.Closed:
+		return ErrClosed
+	case h.ProcessStalled:
+		return ErrProcessStalled
+	case h.CleanupStalled:
+		return ErrCleanupStalled
+	case h.WriteBufferOverload:
+		return ErrWriteBufferOverload
+	default:
+		return nil
+	}
+}
+
+// AffinityFailed reports whether the background goroutines of the cache couldn't be bound to the CPUs
+// set by Builder.CPUAffinity, e.g. because the platform doesn't support it. The cache works normally
+// in this case, and the failure is not considered unhealthy by IsHealthy.
+//
+// The goroutines are bound right after they start, so the result is false until then.
+func (bs baseCache[K, V]) AffinityFailed() bool {
+	return bs.cache.Health().AffinityFailed
+}
+
+// CircuitState returns the state of the loader circuit breaker set by Builder.LoaderCircuitBreaker.
+// It is always CircuitClosed if the circuit breaker is not enabled.
+func (bs baseCache[K, V]) CircuitState() CircuitBreakerState {
+	return bs.cache.CircuitState()
+}
+
+// WarmUpDone returns a channel that is closed once the number of items in the cache reaches
+// the warm-up threshold fraction of its capacity. It can be used to hold the incoming traffic
+// until the cache is warm enough after a cold start.
+//
+// If the warm-up threshold has not been set, the returned channel is already closed.
+func (bs baseCache[K, V]) WarmUpDone() <-chan struct{} {
+	return bs.cache.WarmUpDone()
+}
+
+// Verify checks the internal invariants of the cache: all items are accounted for in the eviction
+// and expiration policies, there are no deleted items left in them, and the total cost doesn't exceed
+// the capacity. It returns an error describing the first violated invariant.
+//
+// NOTE: Verify is O(n), blocks the background processing and is reliable only without concurrent writes,
+// so it is intended only for tests and debugging.
+func (bs baseCache[K, V]) Verify() error {
+	return bs.cache.Verify()
+}
+
+// PurgeExpired removes all expired items from the cache and returns their number.
+//
+// It is useful when the background cleanup is disabled via ManualCleanup.
+func (bs baseCache[K, V]) PurgeExpired() int {
+	return bs.cache.PurgeExpired()
 }
 
 // Size returns the current number of items in the cache.
@@ -90,25 +615,98 @@ func (bs baseCache[K, V]) Size() int {
 	return bs.cache.Size()
 }
 
+// WeightedSize returns the total cost of the items in the cache.
+//
+// The writes are applied to the eviction policy asynchronously, so the recent writes may not be taken into account.
+func (bs baseCache[K, V]) WeightedSize() int {
+	return bs.cache.WeightedSize()
+}
+
 // Capacity returns the cache capacity.
 func (bs baseCache[K, V]) Capacity() int {
 	return bs.cache.Capacity()
 }
 
+// PolicyStats returns the number of entries in the small (probationary) and main queues of the S3-FIFO
+// eviction policy and the number of evicted keys remembered by its ghost queue. It is a diagnostic
+// for tuning the admission: for example, a large share of the entries returning from the ghost queue
+// means that they are evicted from the small queue too early.
+//
+// The numbers are instantaneous and don't include the writes still waiting in the write buffer.
+// All the entries are in the main queue with the LRU policy, and the Sampled policy has no queues,
+// so zeros are returned for it.
+func (bs baseCache[K, V]) PolicyStats() (small, main, ghost int) {
+	return bs.cache.PolicyStats()
+}
+
 // Stats returns a current snapshot of this cache's cumulative statistics.
 func (bs baseCache[K, V]) Stats() Stats {
-	return newStats(bs.cache.Stats())
+	s := newStats(bs.cache.Stats())
+	s.inserted, s.admissionRejections = bs.cache.AdmissionStats()
+	s.grows, s.shrinks = bs.cache.Resizes()
+	if current, capacity := bs.WriteBufferUsage(); capacity > 0 {
+		s.writeBufferUtilization = float64(current) / float64(capacity)
+	}
+	return s
+}
+
+// ReadBufferDrops returns the number of reads that were dropped by the read buffers and not applied
+// to the eviction policy. The drops are intended, but if their number is high relative to Stats().Hits(),
+// then the read buffers are undersized and the eviction policy sees only a small sample of the reads.
+func (bs baseCache[K, V]) ReadBufferDrops() int64 {
+	return bs.cache.ReadBufferDrops()
+}
+
+// WriteBufferUsage returns the number of the write operations waiting to be applied to the eviction policy
+// and the capacity of the write buffer. The writes are blocked when the buffer is full.
+func (bs baseCache[K, V]) WriteBufferUsage() (current, capacity int) {
+	return bs.cache.WriteBufferUsage()
+}
+
+// Metrics returns a snapshot of the cache metrics that can be serialized to JSON.
+func (bs baseCache[K, V]) Metrics() Metrics {
+	s := bs.Stats()
+	return Metrics{
+		Timestamp:    time.Now(),
+		Hits:         s.Hits(),
+		Misses:       s.Misses(),
+		Ratio:        s.Ratio(),
+		Size:         bs.Size(),
+		WeightedSize: bs.WeightedSize(),
+		Capacity:     bs.Capacity(),
+		Evictions:    s.EvictedCount(),
+		Expirations:  s.ExpiredCount(),
+	}
+}
+
+// MetricsJSON returns a snapshot of the cache metrics serialized to JSON.
+func (bs baseCache[K, V]) MetricsJSON() ([]byte, error) {
+	return json.Marshal(bs.Metrics())
+}
+
+// DryRunStats returns a current snapshot of the evictions simulated in the dry-run mode.
+//
+// If the dry-run mode is disabled, then all values are zero.
+func (bs baseCache[K, V]) DryRunStats() DryRunStats {
+	return DryRunStats{
+		evictedCount: bs.cache.DryRunEvictedCount(),
+		evictedCost:  bs.cache.DryRunEvictedCost(),
+	}
 }
 
 // Cache is a structure performs a best-effort bounding of a hash table using eviction algorithm
 // to determine which entries to evict when the capacity is exceeded.
+//
+// The writes of the same key are linearized by the hash table: the last write to the table wins,
+// and the eviction and expiration policies always end up tracking the entry stored in the table,
+// even if the policy updates of the concurrent writes are applied in a different order.
 type Cache[K comparable, V any] struct {
 	baseCache[K, V]
 }
 
-func newCache[K comparable, V any](c core.Config[K, V]) Cache[K, V] {
+func newCache[K comparable, V any](c core.Config[K, V], events *eventChannel[K, V]) Cache[K, V] {
 	return Cache[K, V]{
-		baseCache: newBaseCache(c),
+		baseCache: newBaseCache(c, events),
 	}
 }
 
@@ -122,21 +720,151 @@ func (c Cache[K, V]) Set(key K, value V) bool {
 // SetIfAbsent if the specified key is not already associated with a value associates it with the given value.
 //
 // If the specified key is not already associated with a value, then it returns false.
+// An expired item is treated as absent even if it has not yet been removed by the cleanup.
 //
 // Also, it returns false if the key-value item had too much setCostFunc and the SetIfAbsent was dropped.
 func (c Cache[K, V]) SetIfAbsent(key K, value V) bool {
 	return c.cache.SetIfAbsent(key, value)
 }
 
+// CompareAndSwap associates newValue with the key if the cached value is equal to oldValue.
+// It reports whether the value was swapped.
+//
+// The values are compared with the function set by Equal or with == if it is not set.
+// Also, it returns false if the new key-value item had too much setCostFunc and the CompareAndSwap was dropped.
+func (c Cache[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	return c.cache.CompareAndSwap(key, oldValue, newValue)
+}
+
+// SetIfChanged associates the value with the key in this cache if the key is absent or the cached value
+// is not equal to the given one, so the repeated writes of the same value are deduplicated.
+// It reports whether the value was written.
+//
+// The values are compared with the function set by Equal or with == if it is not set.
+// Also, it returns false if the key-value item had too much setCostFunc and the SetIfChanged was dropped.
+func (c Cache[K, V]) SetIfChanged(key K, value V) bool {
+	return c.cache.SetIfChanged(key, value)
+}
+
+// Clone creates a new cache using the given builder (Builder or ConstTTLBuilder) and copies all live items of c
+// into it. The items get the ttl and the cost calculated by the new cache, and the items that don't fit
+// into its capacity are evicted by its policy.
+//
+// It allows testing another configuration against the same dataset without a cold start.
+func (c Cache[K, V]) Clone(b interface{ Build() (Cache[K, V], error) }) (Cache[K, V], error) {
+	clone, err := b.Build()
+	if err != nil {
+		return Cache[K, V]{}, err
+	}
+
+	c.cache.CopyTo(clone.cache, false)
+	return clone, nil
+}
+
+// Merge copies all live items of other into c. If a key is present in both caches, then the item is chosen
+// according to the conflict policy. The copied items get the ttl and the cost calculated by c.
+//
+// It returns the number of copied items.
+func (c Cache[K, V]) Merge(other Cache[K, V], conflict ConflictPolicy) int {
+	return c.cache.Merge(other.cache, conflict)
+}
+
+// GetOrSet returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored. It is like sync.Map.LoadOrStore:
+// the concurrent callers agree on the stored value.
+//
+// If the value can't be stored because the cache is frozen or the item had too much cost,
+// then the given value is returned with false and the cache is not changed.
+func (c Cache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	return c.cache.GetOrSet(key, value)
+}
+
+// GetMultiOrSet returns the values associated with the keys and loads all the missing keys with a single call
+// of batchLoader. The loaded values are stored in the cache and merged into the result.
+//
+// The concurrent calls for the overlapping sets of missing keys are deduplicated, so batchLoader is called
+// at most once per distinct missing key at a time. The keys that batchLoader doesn't return are absent
+// from the result. If batchLoader returns an error, it is returned along with the values found so far.
+// If batchLoader panics, the calls waiting for its keys get ErrLoaderPanicked.
+func (c Cache[K, V]) GetMultiOrSet(keys []K, batchLoader func(keys []K) (map[K]V, error)) (map[K]V, error) {
+	return c.cache.GetMultiOrSet(keys, batchLoader)
+}
+
+// GetMultiOrSetContext is like GetMultiOrSet, but stops retrying the failed calls of batchLoader
+// set by Builder.LoaderRetry when the context is canceled and returns the context error.
+func (c Cache[K, V]) GetMultiOrSetContext(
+	ctx context.Context,
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+) (map[K]V, error) {
+	return c.cache.GetMultiOrSetContext(ctx, keys, batchLoader)
+}
+
+// Prime inserts the entries directly into the cache, bypassing the write buffer, which makes it faster
+// than SetAll for loading a known dataset at startup.
+//
+// It returns the entries that were dropped because they had too much cost and a single error wrapping
+// ErrCostTooLarge with the number of the dropped entries, so that the partial loads are not silent.
+// All entries are dropped with ErrFrozen or ErrClosed if the cache is frozen or closed.
+// The concurrent writes are blocked until it completes.
+func (c Cache[K, V]) Prime(entries []Entry[K, V]) ([]Entry[K, V], error) {
+	if c.IsFrozen() {
+		return entries, ErrFrozen
+	}
+	if c.IsClosed() {
+		return entries, ErrClosed
+	}
+
+	coreEntries := make([]core.Entry[K, V], 0, len(entries))
+	for _, e := range entries {
+		coreEntries = append(coreEntries, core.Entry[K, V](e))
+	}
+
+	indexes := c.cache.Prime(coreEntries)
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+
+	rejected := make([]Entry[K, V], 0, len(indexes))
+	for _, i := range indexes {
+		rejected = append(rejected, entries[i])
+	}
+	return rejected, fmt.Errorf("%w: %d of %d entries were not primed", ErrCostTooLarge, len(rejected), len(entries))
+}
+
+// SetAll associates the values with the keys in this cache.
+//
+// It returns the entries that had too much cost and were dropped.
+func (c Cache[K, V]) SetAll(entries []Entry[K, V]) []Entry[K, V] {
+	rejected, _, _ := c.SetAllContext(context.Background(), entries)
+	return rejected
+}
+
+// SetAllContext is like SetAll, but stops early if the context is done.
+//
+// It returns the dropped entries, the number of processed entries (entries[:processed] were set)
+// and the context error if the operation was aborted.
+func (c Cache[K, V]) SetAllContext(
+	ctx context.Context,
+	entries []Entry[K, V],
+) (rejected []Entry[K, V], processed int, err error) {
+	processed, err = forEachWithContext(ctx, entries, func(e Entry[K, V]) {
+		if !c.cache.Set(e.Key, e.Value) {
+			rejected = append(rejected, e)
+		}
+	})
+	return rejected, processed, err
+}
+
 // CacheWithVariableTTL is a structure performs a best-effort bounding of a hash table using eviction algorithm
 // to determine which entries to evict when the capacity is exceeded.
 type CacheWithVariableTTL[K comparable, V any] struct {
 	baseCache[K, V]
 }
 
-func newCacheWithVariableTTL[K comparable, V any](c core.Config[K, V]) CacheWithVariableTTL[K, V] {
+func newCacheWithVariableTTL[K comparable, V any](c core.Config[K, V], events *eventChannel[K, V]) CacheWithVariableTTL[K, V] {
 	return CacheWithVariableTTL[K, V]{
-		baseCache: newBaseCache(c),
+		baseCache: newBaseCache(c, events),
 	}
 }
 
@@ -147,12 +875,157 @@ func (c CacheWithVariableTTL[K, V]) Set(key K, value V, ttl time.Duration) bool
 	return c.cache.SetWithTTL(key, value, ttl)
 }
 
+// SetWithDeadline associates the value with the key in this cache and sets the absolute expiration time
+// for this key-value item, e.g. the exp claim of a token.
+//
+// If it returns false, then the deadline has already passed or the key-value item had too much setCostFunc
+// and the SetWithDeadline was dropped.
+func (c CacheWithVariableTTL[K, V]) SetWithDeadline(key K, value V, deadline time.Time) bool {
+	return c.cache.SetWithDeadline(key, value, deadline)
+}
+
+// SetAll associates the values with the keys in this cache and sets the custom ttl for these key-value items.
+//
+// It returns the entries that had too much cost and were dropped.
+func (c CacheWithVariableTTL[K, V]) SetAll(entries []Entry[K, V], ttl time.Duration) []Entry[K, V] {
+	rejected, _, _ := c.SetAllContext(context.Background(), entries, ttl)
+	return rejected
+}
+
+// SetAllContext is like SetAll, but stops early if the context is done.
+//
+// It returns the dropped entries, the number of processed entries (entries[:processed] were set)
+// and the context error if the operation was aborted.
+func (c CacheWithVariableTTL[K, V]) SetAllContext(
+	ctx context.Context,
+	entries []Entry[K, V],
+	ttl time.Duration,
+) (rejected []Entry[K, V], processed int, err error) {
+	processed, err = forEachWithContext(ctx, entries, func(e Entry[K, V]) {
+		if !c.cache.SetWithTTL(e.Key, e.Value, ttl) {
+			rejected = append(rejected, e)
+		}
+	})
+	return rejected, processed, err
+}
+
+// SetAllWithTTL associates the values with the keys in this cache and sets the ttl of each item.
+// It is available only for the caches built with WithVariableTTL, because the other caches use the same ttl
+// for all items.
+//
+// The items are inserted in one batch directly into the cache like with Prime, bypassing the write buffer,
+// so the concurrent writes are blocked until it completes.
+// It returns the items that had too much cost or a non-positive ttl and were dropped,
+// or all items if the cache is frozen or closed.
+func (c CacheWithVariableTTL[K, V]) SetAllWithTTL(items []Item[K, V]) []Item[K, V] {
+	dropped := make([]bool, len(items))
+	indexes := make([]int, 0, len(items))
+	entries := make([]core.Entry[K, V], 0, len(items))
+	ttls := make([]time.Duration, 0, len(items))
+	for i, item := range items {
+		if item.TTL <= 0 {
+			dropped[i] = true
+			continue
+		}
+		indexes = append(indexes, i)
+		entries = append(entries, core.Entry[K, V]{Key: item.Key, Value: item.Value})
+		ttls = append(ttls, item.TTL)
+	}
+	for _, i := range c.cache.PrimeWithTTL(entries, ttls) {
+		dropped[indexes[i]] = true
+	}
+
+	var rejected []Item[K, V]
+	for i, item := range items {
+		if dropped[i] {
+			rejected = append(rejected, item)
+		}
+	}
+	return rejected
+}
+
 // SetIfAbsent if the specified key is not already associated with a value associates it with the given value
 // and sets the custom ttl for this key-value item.
 //
 // If the specified key is not already associated with a value, then it returns false.
+// An expired item is treated as absent even if it has not yet been removed by the cleanup.
 //
 // Also, it returns false if the key-value item had too much setCostFunc and the SetIfAbsent was dropped.
 func (c CacheWithVariableTTL[K, V]) SetIfAbsent(key K, value V, ttl time.Duration) bool {
 	return c.cache.SetIfAbsentWithTTL(key, value, ttl)
 }
+
+// Clone creates a new cache using the given builder and copies all live items of c into it.
+// The items keep their expiration time and get the cost calculated by the new cache, and the items that don't fit
+// into its capacity are evicted by its policy.
+//
+// It allows testing another configuration against the same dataset without a cold start.
+func (c CacheWithVariableTTL[K, V]) Clone(b *VariableTTLBuilder[K, V]) (CacheWithVariableTTL[K, V], error) {
+	clone, err := b.Build()
+	if err != nil {
+		return CacheWithVariableTTL[K, V]{}, err
+	}
+
+	c.cache.CopyTo(clone.cache, true)
+	return clone, nil
+}
+
+// Merge copies all live items of other into c. If a key is present in both caches, then the item is chosen
+// according to the conflict policy. The copied items keep their expiration time and get the cost calculated by c.
+//
+// It returns the number of copied items.
+func (c CacheWithVariableTTL[K, V]) Merge(other CacheWithVariableTTL[K, V], conflict ConflictPolicy) int {
+	return c.cache.Merge(other.cache, conflict)
+}
+
+// GetOrSet returns the existing value for the key if present. Otherwise, it stores the given value with the ttl
+// and returns it. The loaded result is true if the value was loaded, false if stored. It is like
+// sync.Map.LoadOrStore: the concurrent callers agree on the stored value.
+//
+// If the value can't be stored because the cache is frozen or the item had too much cost,
+// then the given value is returned with false and the cache is not changed.
+func (c CacheWithVariableTTL[K, V]) GetOrSet(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	return c.cache.GetOrSetWithTTL(key, value, ttl)
+}
+
+// GetMultiOrSet returns the values associated with the keys and loads all the missing keys with a single call
+// of batchLoader. The loaded values are stored in the cache with the given ttl and merged into the result.
+//
+// The concurrent calls for the overlapping sets of missing keys are deduplicated, so batchLoader is called
+// at most once per distinct missing key at a time. The keys that batchLoader doesn't return are absent
+// from the result. If batchLoader returns an error, it is returned along with the values found so far.
+// If batchLoader panics, the calls waiting for its keys get ErrLoaderPanicked.
+func (c CacheWithVariableTTL[K, V]) GetMultiOrSet(
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+	ttl time.Duration,
+) (map[K]V, error) {
+	return c.cache.GetMultiOrSetWithTTL(keys, batchLoader, ttl)
+}
+
+// GetMultiOrSetContext is like GetMultiOrSet, but stops retrying the failed calls of batchLoader
+// set by VariableTTLBuilder.LoaderRetry when the context is canceled and returns the context error.
+func (c CacheWithVariableTTL[K, V]) GetMultiOrSetContext(
+	ctx context.Context,
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+	ttl time.Duration,
+) (map[K]V, error) {
+	return c.cache.GetMultiOrSetWithTTLContext(ctx, keys, batchLoader, ttl)
+}
+
+// forEachWithContext calls f for each item and checks the context every ctxCheckInterval items,
+// because checking it on each item is too costly.
+//
+// It returns the number of processed items and the context error if the context is done.
+func forEachWithContext[T any](ctx context.Context, items []T, f func(item T)) (int, error) {
+	for i, item := range items {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return i, err
+			}
+		}
+		f(item)
+	}
+	return len(items), nil
+}
@@ -15,12 +15,24 @@
 package otter
 
 import (
+	"bytes"
 	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/maphash"
 	"math/rand"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/maypok86/otter/internal/xruntime"
 )
@@ -222,98 +234,984 @@ func TestCache_SetWithTTL(t *testing.T) {
 	if misses := cc.Stats().Misses(); misses != int64(size) {
 		t.Fatalf("c.Stats().Misses() = %d, want = %d", misses, size)
 	}
+	if s := cc.Stats(); s.CleanupCycles() == 0 || s.MaxCleanupDuration() > s.TotalCleanupDuration() {
+		t.Fatalf("cleanup cycles are not recorded: %d, total: %s, max: %s",
+			s.CleanupCycles(), s.TotalCleanupDuration(), s.MaxCleanupDuration())
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(m) != 1 || m[Expired] != size {
+		t.Fatalf("cache was supposed to expire %d, but expired %d entries", size, m[Expired])
+	}
+}
+
+func TestCache_Delete(t *testing.T) {
+	size := 256
+	var mutex sync.Mutex
+	m := make(map[DeletionCause]int)
+	c, err := MustBuilder[int, int](size).
+		InitialCapacity(size).
+		WithTTL(time.Hour).
+		DeletionListener(func(key int, value int, cause DeletionCause) {
+			mutex.Lock()
+			m[cause]++
+			mutex.Unlock()
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create builder: %v", err)
+	}
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+
+	for i := 0; i < size; i++ {
+		if !c.Has(i) {
+			t.Fatalf("key should exists: %d", i)
+		}
+	}
+
+	for i := 0; i < size; i++ {
+		c.Delete(i)
+	}
+
+	for i := 0; i < size; i++ {
+		if c.Has(i) {
+			t.Fatalf("key should not exists: %d", i)
+		}
+	}
+
+	time.Sleep(time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(m) != 1 || m[Explicit] != size {
+		t.Fatalf("cache was supposed to delete %d, but deleted %d entries", size, m[Explicit])
+	}
+}
+
+func TestCache_DeletionBatchListener(t *testing.T) {
+	size := 100
+	var mutex sync.Mutex
+	batches := 0
+	m := make(map[DeletionCause]int)
+	c, err := MustBuilder[int, int](size).
+		DeletionBatchListener(func(entries []DeletedEntry[int, int]) {
+			mutex.Lock()
+			batches++
+			for _, e := range entries {
+				m[e.Cause]++
+			}
+			mutex.Unlock()
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create builder: %v", err)
+	}
+
+	for i := 0; i < 2*size; i++ {
+		c.Set(i, i)
+	}
+	for i := 0; i < 2*size; i++ {
+		c.Delete(i)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if m[Size] == 0 || m[Explicit] == 0 {
+		t.Fatalf("unexpected deletions: %v", m)
+	}
+	if batches >= 2*size {
+		t.Fatalf("the deletions should be batched, but got %d batches", batches)
+	}
+}
+
+func TestCache_DeleteByFunc(t *testing.T) {
+	size := 256
+	var mutex sync.Mutex
+	m := make(map[DeletionCause]int)
+	c, err := MustBuilder[int, int](size).
+		InitialCapacity(size).
+		WithTTL(time.Hour).
+		DeletionListener(func(key int, value int, cause DeletionCause) {
+			mutex.Lock()
+			m[cause]++
+			mutex.Unlock()
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create builder: %v", err)
+	}
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+
+	c.DeleteByFunc(func(key int, value int) bool {
+		return key%2 == 1
+	})
+
+	c.Range(func(key int, value int) bool {
+		if key%2 == 1 {
+			t.Fatalf("key should be odd, but got: %d", key)
+		}
+		return true
+	})
+
+	time.Sleep(time.Second)
+
+	expected := size / 2
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(m) != 1 || m[Explicit] != expected {
+		t.Fatalf("cache was supposed to delete %d, but deleted %d entries", expected, m[Explicit])
+	}
+}
+
+func TestCache_Metrics(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		CollectStats().
+		Cost(func(key int, value int) uint32 {
+			return 2
+		}).
+		WithTTL(time.Second).
+		ManualCleanup().
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		c.Set(i, i)
+	}
+	c.Get(0)
+	c.Get(100)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	m := c.Metrics()
+	if m.Hits != 1 || m.Misses != 1 || m.Size != 10 || m.WeightedSize != 20 || m.Capacity != 100 {
+		t.Fatalf("unexpected metrics: %+v", m)
+	}
+
+	time.Sleep(3 * time.Second)
+	c.PurgeExpired()
+	if expired := c.Stats().ExpiredCount(); expired != 10 {
+		t.Fatalf("all items should be counted as expired, but got %d", expired)
+	}
+
+	data, err := c.MetricsJSON()
+	if err != nil {
+		t.Fatalf("can not marshal metrics: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("can not unmarshal metrics: %v", err)
+	}
+	for _, name := range []string{"timestamp", "hits", "misses", "ratio", "size", "weighted_size", "capacity", "evictions", "expirations"} {
+		if _, ok := fields[name]; !ok {
+			t.Fatalf("metrics should contain %q: %s", name, data)
+		}
+	}
+}
+
+func TestCache_OnPanic(t *testing.T) {
+	var mutex sync.Mutex
+	stages := make(map[string]int)
+	c, err := MustBuilder[int, int](100).
+		Cost(func(key int, value int) uint32 {
+			if key < 0 {
+				panic("bad cost")
+			}
+			return 1
+		}).
+		DeletionListener(func(key int, value int, cause DeletionCause) {
+			panic("bad listener")
+		}).
+		OnPanic(func(stage string, r any) {
+			mutex.Lock()
+			stages[stage]++
+			mutex.Unlock()
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	if c.Set(-1, 1) {
+		t.Fatal("item with a panicking cost function should be rejected")
+	}
+	for i := 0; i < 10; i++ {
+		c.Set(i, i)
+		c.Delete(i)
+	}
+	if _, err := c.GetMultiOrSet([]int{1}, func(keys []int) (map[int]int, error) {
+		panic("bad loader")
+	}); !errors.Is(err, ErrLoaderPanicked) {
+		t.Fatalf("GetMultiOrSet error = %v, want = %v", err, ErrLoaderPanicked)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache should keep operating: %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if stages[PanicStageCost] != 1 || stages[PanicStageDeletionListener] != 10 || stages[PanicStageLoader] != 1 {
+		t.Fatalf("unexpected panics: %v", stages)
+	}
+}
+
+func TestCache_CloneValues(t *testing.T) {
+	c, err := MustBuilder[int, []int](100).
+		CloneValues(func(value []int) []int {
+			return append([]int(nil), value...)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	value := []int{1, 2, 3}
+	c.Set(1, value)
+	value[0] = 100
+
+	got, ok := c.Get(1)
+	if !ok || got[0] != 1 {
+		t.Fatalf("cached value should not be affected by the mutation of the stored one: %v", got)
+	}
+	got[1] = 100
+	if got, _ := c.Get(1); got[1] != 2 {
+		t.Fatalf("cached value should not be affected by the mutation of the returned one: %v", got)
+	}
+
+	// the other paths that hand out or store the values clone them too.
+	mutate := func(value []int) {
+		value[2] = 100
+	}
+	snapshot := c.ReadOnlySnapshot()
+	got, _ = snapshot.Get(1)
+	mutate(got)
+	if got, _ := snapshot.Get(1); got[2] != 3 {
+		t.Fatalf("snapshot value should not be affected by the mutation of the returned one: %v", got)
+	}
+	for _, candidate := range c.NextEvictions(1) {
+		mutate(candidate.Value)
+	}
+	var stored []int
+	if err := c.Transact([]int{1, 2}, func(snapshot map[int][]int) map[int][]int {
+		mutate(snapshot[1])
+		stored = []int{4, 5, 6}
+		return map[int][]int{2: stored}
+	}); err != nil {
+		t.Fatalf("c.Transact() = %v", err)
+	}
+	mutate(stored)
+	if got, _ := c.Get(1); got[2] != 3 {
+		t.Fatalf("cached value should not be affected by the mutation of the handed out ones: %v", got)
+	}
+	if got, _ := c.Get(2); got[2] != 6 {
+		t.Fatalf("value written by Transact should be cloned: %v", got)
+	}
+	if got, ok := c.GetAndDelete(1); !ok || got[2] != 3 {
+		t.Fatalf("c.GetAndDelete(1) = %v, %v", got, ok)
+	}
+}
+
+func TestCache_GetPtr(t *testing.T) {
+	type value struct {
+		id      int
+		payload [64]int
+	}
+
+	c, err := MustBuilder[int, value](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	if got, ok := c.GetPtr(1); ok || got != nil {
+		t.Fatalf("pointer should not be returned for the missing key: %v", got)
+	}
+
+	c.Set(1, value{id: 1})
+	got, ok := c.GetPtr(1)
+	if !ok || got.id != 1 {
+		t.Fatalf("pointer to the cached value should be returned: %v", got)
+	}
+	if again, _ := c.GetPtr(1); again != got {
+		t.Fatal("pointer should refer to the cache's internal storage")
+	}
+
+	c.Set(1, value{id: 2})
+	if got.id != 1 {
+		t.Fatalf("old pointer should still refer to the old value, but got %d", got.id)
+	}
+	if got, ok := c.GetPtr(1); !ok || got.id != 2 {
+		t.Fatalf("pointer to the new value should be returned: %v", got)
+	}
+}
+
+func TestCache_ValueInterning(t *testing.T) {
+	seed := maphash.MakeSeed()
+	c, err := MustBuilder[int, string](100).
+		ValueInterning(func(a, b string) bool {
+			return a == b
+		}, func(v string) uint64 {
+			return maphash.String(seed, v)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	data := func(s string) uintptr {
+		return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+	}
+
+	for i := 0; i < 10; i++ {
+		c.Set(i, string([]byte("enabled")))
+	}
+	c.SetIfAbsent(10, string([]byte("enabled")))
+
+	first, _ := c.Get(0)
+	for i := 1; i <= 10; i++ {
+		got, ok := c.Get(i)
+		if !ok || got != "enabled" {
+			t.Fatalf("value should be cached for key %d: %q", i, got)
+		}
+		if data(got) != data(first) {
+			t.Fatalf("equal values should share the canonical copy for key %d", i)
+		}
+	}
+
+	c.Set(11, string([]byte("disabled")))
+	if got, _ := c.Get(11); got != "disabled" || data(got) == data(first) {
+		t.Fatalf("different values should not be interned together: %q", got)
+	}
+}
+
+func TestCache_Prime(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		CollectStats().
+		Cost(func(key int, value int) uint32 {
+			return uint32(value)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set(1, 5)
+	entries := []Entry[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 1}, {Key: 3, Value: 1000}}
+	rejected, err := c.Prime(entries)
+	if !errors.Is(err, ErrCostTooLarge) || len(rejected) != 1 || rejected[0].Key != 3 {
+		t.Fatalf("Prime() = %v, %v, want the entry with too much cost to be rejected", rejected, err)
+	}
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("c.Get(1) = %d, %v, want = 1, true", v, ok)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	if c.WeightedSize() != 2 {
+		t.Fatalf("the replaced entry should be removed from the policy, weighted size: %d", c.WeightedSize())
+	}
+
+	c.Close()
+	if rejected, err := c.Prime(entries); !errors.Is(err, ErrClosed) || len(rejected) != len(entries) {
+		t.Fatalf("Prime() = %v, %v, want all entries to be rejected", rejected, err)
+	}
+}
+
+func TestCache_IsHealthy(t *testing.T) {
+	c, err := MustBuilder[int, int](100).WithTTL(time.Hour).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	if err := c.IsHealthy(); err != nil {
+		t.Fatalf("c.IsHealthy() = %v, want = nil", err)
+	}
+
+	c.Close()
+
+	if err := c.IsHealthy(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("c.IsHealthy() = %v, want = %v", err, ErrClosed)
+	}
+}
+
+func TestCache_WarmUpDone(t *testing.T) {
+	c, err := MustBuilder[int, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	select {
+	case <-c.WarmUpDone():
+	default:
+		t.Fatal("cache without warm-up threshold should be warm")
+	}
+
+	c, err = MustBuilder[int, int](100).WarmUpThreshold(0.5).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 40; i++ {
+		c.Set(i, i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-c.WarmUpDone():
+		t.Fatal("cache should not be warm yet")
+	default:
+	}
+
+	for i := 40; i < 50; i++ {
+		c.Set(i, i)
+	}
+	select {
+	case <-c.WarmUpDone():
+	case <-time.After(time.Second):
+		t.Fatal("cache should be warm")
+	}
+}
+
+func TestCache_SampledEviction(t *testing.T) {
+	size := 100
+	c, err := MustBuilder[int, int](size).EvictionPolicy(Sampled).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 10*size; i++ {
+		c.Set(i, i)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// the last write tasks may still be in the write buffer.
+	if got := c.Size(); got > size+64 {
+		t.Fatalf("size should be bounded by the capacity; got %d; want <= %d", got, size+64)
+	}
+}
+
+func TestCache_Pin(t *testing.T) {
+	const size = 10
+	for _, policy := range []EvictionPolicy{S3FIFO, LRU, Sampled, LFU} {
+		c, err := MustBuilder[int, int](size).EvictionPolicy(policy).Build()
+		if err != nil {
+			t.Fatalf("can not create cache: %v", err)
+		}
+
+		if c.Pin(0) {
+			t.Fatal("absent key should not be pinned")
+		}
+		for i := 0; i < 5; i++ {
+			c.Set(i, i)
+			if !c.Pin(i) {
+				t.Fatalf("key %d should be pinned", i)
+			}
+		}
+		c.Pin(0)
+
+		for i := 5; i < 20*size; i++ {
+			c.Set(i, i)
+			if i%size == 0 {
+				c.Set(0, i)
+			}
+		}
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache is inconsistent: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			if !c.Has(i) {
+				t.Fatalf("pinned key %d should not be evicted with %v policy", i, policy)
+			}
+		}
+
+		for i := 0; i < 5; i++ {
+			if !c.Unpin(i) {
+				t.Fatalf("key %d should be unpinned", i)
+			}
+		}
+		if !c.Unpin(0) || c.Unpin(0) {
+			t.Fatal("each pin should need its own unpin")
+		}
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache is inconsistent: %v", err)
+		}
+		if got := c.Size(); got > size {
+			t.Fatalf("size should be bounded by the capacity after unpinning; got %d; want <= %d", got, size)
+		}
+		c.Close()
+	}
+}
+
+func TestCache_PolicyStats(t *testing.T) {
+	const size = 100
+	c, err := MustBuilder[int, int](size).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 3*size; i++ {
+		c.Set(i, i)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	small, main, ghost := c.PolicyStats()
+	if small+main != c.Size() || ghost == 0 {
+		t.Fatalf("queues should hold all entries and remember the evicted keys, got %d, %d, %d", small, main, ghost)
+	}
+
+	sampled, err := MustBuilder[int, int](size).EvictionPolicy(Sampled).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	sampled.Set(1, 1)
+	if small, main, ghost := sampled.PolicyStats(); small != 0 || main != 0 || ghost != 0 {
+		t.Fatalf("sampled policy has no queues, got %d, %d, %d", small, main, ghost)
+	}
+}
+
+func TestCache_Transact(t *testing.T) {
+	c, err := MustBuilder[string, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	err = c.Transact([]string{"a", "b", "sum"}, func(snapshot map[string]int) map[string]int {
+		if _, ok := snapshot["sum"]; ok {
+			t.Fatal("absent key should not be in the snapshot")
+		}
+		return map[string]int{"sum": snapshot["a"] + snapshot["b"]}
+	})
+	if err != nil {
+		t.Fatalf("c.Transact() = %v, want = nil", err)
+	}
+	if v, ok := c.Get("sum"); !ok || v != 3 {
+		t.Fatalf("c.Get(sum) = %d, %v, want = 3, true", v, ok)
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatal("keys not returned by the transaction should be unchanged")
+	}
+
+	err = c.Transact([]string{"a"}, func(snapshot map[string]int) map[string]int {
+		return map[string]int{"a": 10, "c": 10}
+	})
+	if !errors.Is(err, ErrUnlockedKey) {
+		t.Fatalf("c.Transact() = %v, want = %v", err, ErrUnlockedKey)
+	}
+	if v, _ := c.Get("a"); v != 1 || c.Has("c") {
+		t.Fatal("failed transaction should not write anything")
+	}
+}
+
+func TestCacheWithVariableTTL_TransactNoTTL(t *testing.T) {
+	c, err := MustBuilder[string, int](100).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	err = c.Transact([]string{"a"}, func(snapshot map[string]int) map[string]int {
+		return map[string]int{"a": 1}
+	})
+	if err != nil {
+		t.Fatalf("c.Transact() = %v, want = nil", err)
+	}
+
+	// let the timer wheel pass the first bucket.
+	time.Sleep(2100 * time.Millisecond)
+	if purged := c.PurgeExpired(); purged != 0 {
+		t.Fatalf("c.PurgeExpired() = %d, want = 0", purged)
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("c.Get(a) = %d, %v, want = 1, true", v, ok)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("c.Verify() = %v", err)
+	}
+}
+
+func TestCache_Clone(t *testing.T) {
+	size := 100
+	c, err := MustBuilder[int, int](size).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+
+	clone, err := c.Clone(MustBuilder[int, int](2 * size).WithTTL(time.Hour))
+	if err != nil {
+		t.Fatalf("can not clone cache: %v", err)
+	}
+	if clone.Capacity() != 2*size || clone.Size() != c.Size() {
+		t.Fatalf("clone should have its own capacity and all items; capacity: %d, size: %d", clone.Capacity(), clone.Size())
+	}
+	clone.Set(0, 100)
+	if v, _ := c.Get(0); v != 0 {
+		t.Fatal("clone should not share items with the source")
+	}
+
+	if _, err := c.Clone(MustBuilder[int, int](size).Cost(nil)); !errors.Is(err, ErrNilCostFunc) {
+		t.Fatalf("c.Clone() = %v, want = %v", err, ErrNilCostFunc)
+	}
+
+	vc, err := MustBuilder[int, int](size).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	vc.Set(1, 1, time.Hour)
+	vclone, err := vc.Clone(MustBuilder[int, int](size).WithVariableTTL())
+	if err != nil {
+		t.Fatalf("can not clone cache: %v", err)
+	}
+	if v, ok := vclone.Get(1); !ok || v != 1 {
+		t.Fatalf("vclone.Get(1) = %d, %v, want = 1, true", v, ok)
+	}
+}
+
+func TestCache_Merge(t *testing.T) {
+	newCache := func() CacheWithVariableTTL[int, int] {
+		c, err := MustBuilder[int, int](100).WithVariableTTL().Build()
+		if err != nil {
+			t.Fatalf("can not create cache: %v", err)
+		}
+		return c
+	}
+
+	for _, tt := range []struct {
+		conflict ConflictPolicy
+		want     map[int]int
+	}{
+		{conflict: ConflictKeepExisting, want: map[int]int{1: 1, 2: 2, 3: 30}},
+		{conflict: ConflictOverwrite, want: map[int]int{1: 10, 2: 20, 3: 30}},
+		{conflict: ConflictKeepNewerTTL, want: map[int]int{1: 1, 2: 20, 3: 30}},
+	} {
+		c := newCache()
+		c.Set(1, 1, time.Hour)
+		c.Set(2, 2, time.Minute)
+
+		other := newCache()
+		other.Set(1, 10, time.Minute)
+		other.Set(2, 20, time.Hour)
+		other.Set(3, 30, time.Hour)
+
+		c.Merge(other, tt.conflict)
+		for k, want := range tt.want {
+			if got, ok := c.Get(k); !ok || got != want {
+				t.Fatalf("conflict policy %d: c.Get(%d) = %d, %v, want = %d, true", tt.conflict, k, got, ok, want)
+			}
+		}
+	}
+}
+
+func TestCache_PurgeExpired(t *testing.T) {
+	c, err := MustBuilder[int, int](100).WithTTL(time.Second).ManualCleanup().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if purged := c.PurgeExpired(); purged != 0 {
+		t.Fatalf("nothing should be purged yet, but got %d", purged)
+	}
+
+	time.Sleep(3 * time.Second)
+	if c.Size() == 0 {
+		t.Fatal("expired items should not be removed without cleanup")
+	}
+
+	c.PurgeExpired()
+	if c.Size() != 0 {
+		t.Fatalf("all items should be purged, but got %d", c.Size())
+	}
+	if err := c.IsHealthy(); err != nil {
+		t.Fatalf("c.IsHealthy() = %v, want = nil", err)
+	}
+}
+
+func TestCache_GetOrSet(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	if actual, loaded := c.GetOrSet(1, 1); loaded || actual != 1 {
+		t.Fatalf("c.GetOrSet(1, 1) = %d, %v, want = 1, false", actual, loaded)
+	}
+	if actual, loaded := c.GetOrSet(1, 2); !loaded || actual != 1 {
+		t.Fatalf("c.GetOrSet(1, 2) = %d, %v, want = 1, true", actual, loaded)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		stored atomic.Int64
+	)
+	winners := make([]int, 8)
+	for g := 0; g < len(winners); g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			actual, loaded := c.GetOrSet(2, g)
+			if !loaded {
+				stored.Add(1)
+			}
+			winners[g] = actual
+		}(g)
+	}
+	wg.Wait()
+
+	if stored.Load() != 1 {
+		t.Fatalf("only one value should be stored, but got %d", stored.Load())
+	}
+	for _, w := range winners {
+		if w != winners[0] {
+			t.Fatalf("concurrent callers should agree on the value: %v", winners)
+		}
+	}
+}
+
+func TestCache_GetMultiOrSet(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set(0, 0)
+
+	var (
+		mutex sync.Mutex
+		loads = make(map[int]int)
+	)
+	loader := func(keys []int) (map[int]int, error) {
+		time.Sleep(10 * time.Millisecond)
+		result := make(map[int]int, len(keys))
+		mutex.Lock()
+		for _, k := range keys {
+			loads[k]++
+			if k%10 != 9 {
+				result[k] = k
+			}
+		}
+		mutex.Unlock()
+		return result, nil
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			keys := make([]int, 0, 20)
+			for k := g; k < g+20; k++ {
+				keys = append(keys, k)
+			}
+			got, err := c.GetMultiOrSet(keys, loader)
+			if err != nil {
+				t.Errorf("GetMultiOrSet() error = %v", err)
+				return
+			}
+			for _, k := range keys {
+				if v, ok := got[k]; ok != (k%10 != 9) || (ok && v != k) {
+					t.Errorf("unexpected result for key %d: %d, %v", k, v, ok)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
 	mutex.Lock()
 	defer mutex.Unlock()
-	if len(m) != 1 || m[Expired] != size {
-		t.Fatalf("cache was supposed to expire %d, but expired %d entries", size, m[Expired])
+	if loads[0] != 0 {
+		t.Fatal("cached key should not be loaded")
+	}
+	for k, count := range loads {
+		// the keys that the loader didn't return can be loaded again.
+		if count > 1 && k%10 != 9 {
+			t.Fatalf("key %d was loaded %d times", k, count)
+		}
+	}
+
+	_, err = c.GetMultiOrSet([]int{100}, func(keys []int) (map[int]int, error) {
+		return nil, errors.New("failed")
+	})
+	if err == nil || c.Has(100) {
+		t.Fatal("loader error should be returned and nothing should be cached")
 	}
 }
 
-func TestCache_Delete(t *testing.T) {
-	size := 256
-	var mutex sync.Mutex
-	m := make(map[DeletionCause]int)
-	c, err := MustBuilder[int, int](size).
-		InitialCapacity(size).
-		WithTTL(time.Hour).
-		DeletionListener(func(key int, value int, cause DeletionCause) {
-			mutex.Lock()
-			m[cause]++
-			mutex.Unlock()
-		}).
-		Build()
+func TestCache_Freeze(t *testing.T) {
+	size := 100
+	c, err := MustBuilder[int, int](size).Build()
 	if err != nil {
-		t.Fatalf("can not create builder: %v", err)
+		t.Fatalf("can not create cache: %v", err)
 	}
 
-	for i := 0; i < size; i++ {
+	for i := 0; i < size/2; i++ {
 		c.Set(i, i)
 	}
 
-	for i := 0; i < size; i++ {
-		if !c.Has(i) {
+	if err := c.Freeze(); err != nil {
+		t.Fatalf("can not freeze cache: %v", err)
+	}
+	if err := c.Freeze(); !errors.Is(err, ErrAlreadyFrozen) {
+		t.Fatalf("c.Freeze() = %v, want = %v", err, ErrAlreadyFrozen)
+	}
+
+	if c.Set(size, size) || c.SetIfAbsent(size, size) {
+		t.Fatal("set should be dropped in the read-only mode")
+	}
+	c.Delete(0)
+	c.DeleteByFunc(func(key int, value int) bool {
+		return true
+	})
+	if err := c.TryDelete(0); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("c.TryDelete(0) = %v, want = %v", err, ErrFrozen)
+	}
+	if err := c.TryDeleteByFunc(func(key int, value int) bool {
+		return true
+	}); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("c.TryDeleteByFunc() = %v, want = %v", err, ErrFrozen)
+	}
+	if _, err := c.DeleteAllContext(context.Background(), []int{1}); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("c.DeleteAllContext() = %v, want = %v", err, ErrFrozen)
+	}
+	for i := 0; i < size/2; i++ {
+		if v, ok := c.Get(i); !ok || v != i {
 			t.Fatalf("key should exists: %d", i)
 		}
 	}
 
-	for i := 0; i < size; i++ {
-		c.Delete(i)
+	c.Unfreeze()
+	if c.IsFrozen() {
+		t.Fatal("cache shouldn't be frozen")
+	}
+	if !c.Set(size, size) || !c.Has(size) {
+		t.Fatalf("key should exists: %d", size)
 	}
+	if err := c.TryDelete(0); err != nil {
+		t.Fatalf("can not delete key: %v", err)
+	}
+	if c.Has(0) {
+		t.Fatalf("key should not exists: %d", 0)
+	}
+}
 
-	for i := 0; i < size; i++ {
-		if c.Has(i) {
-			t.Fatalf("key should not exists: %d", i)
-		}
+func TestCache_CostDistribution(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).
+		Cost(func(key int, value int) uint32 {
+			return uint32(key)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
 	}
 
-	time.Sleep(time.Second)
+	for _, k := range []int{0, 1, 2, 3, 4, 80} {
+		c.Set(k, k)
+	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-	if len(m) != 1 || m[Explicit] != size {
-		t.Fatalf("cache was supposed to delete %d, but deleted %d entries", size, m[Explicit])
+	h := c.CostDistribution()
+	expected := map[int]int{0: 2, 1: 2, 2: 1, 6: 1}
+	for i := 0; i < h.Buckets(); i++ {
+		if h.Count(i) != expected[i] {
+			t.Fatalf("h.Count(%d) = %d, want = %d", i, h.Count(i), expected[i])
+		}
+	}
+	if h.LowerBound(6) != 64 {
+		t.Fatalf("h.LowerBound(6) = %d, want = %d", h.LowerBound(6), 64)
+	}
+	if h.Total() != 6 {
+		t.Fatalf("h.Total() = %d, want = %d", h.Total(), 6)
 	}
 }
 
-func TestCache_DeleteByFunc(t *testing.T) {
-	size := 256
-	var mutex sync.Mutex
-	m := make(map[DeletionCause]int)
-	c, err := MustBuilder[int, int](size).
-		InitialCapacity(size).
-		WithTTL(time.Hour).
-		DeletionListener(func(key int, value int, cause DeletionCause) {
-			mutex.Lock()
-			m[cause]++
-			mutex.Unlock()
-		}).
-		Build()
+func TestCache_BulkContext(t *testing.T) {
+	size := 3 * ctxCheckInterval
+	c, err := MustBuilder[int, int](10 * size).Build()
 	if err != nil {
-		t.Fatalf("can not create builder: %v", err)
+		t.Fatalf("can not create cache: %v", err)
 	}
 
+	entries := make([]Entry[int, int], 0, size)
+	keys := make([]int, 0, size)
 	for i := 0; i < size; i++ {
-		c.Set(i, i)
+		entries = append(entries, Entry[int, int]{Key: i, Value: i})
+		keys = append(keys, i)
 	}
 
-	c.DeleteByFunc(func(key int, value int) bool {
-		return key%2 == 1
-	})
+	if rejected := c.SetAll(entries); len(rejected) != 0 {
+		t.Fatalf("got unexpected rejected entries: %v", rejected)
+	}
+	if got := c.GetAll(keys); len(got) != size {
+		t.Fatalf("len(c.GetAll(keys)) = %d, want = %d", len(got), size)
+	}
 
-	c.Range(func(key int, value int) bool {
-		if key%2 == 1 {
-			t.Fatalf("key should be odd, but got: %d", key)
-		}
-		return true
-	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	processed, err := c.DeleteAllContext(ctx, keys)
+	if !errors.Is(err, context.Canceled) || processed != 0 {
+		t.Fatalf("got unexpected result: processed = %d, err = %v", processed, err)
+	}
 
-	time.Sleep(time.Second)
+	ctx, cancel = context.WithCancel(context.Background())
+	got, processed, err := c.GetAllContext(ctx, keys)
+	if err != nil || processed != size || len(got) != size {
+		t.Fatalf("got unexpected result: processed = %d, len = %d, err = %v", processed, len(got), err)
+	}
+	cancel()
 
-	expected := size / 2
-	mutex.Lock()
-	defer mutex.Unlock()
-	if len(m) != 1 || m[Explicit] != expected {
-		t.Fatalf("cache was supposed to delete %d, but deleted %d entries", expected, m[Explicit])
+	c.DeleteAll(keys)
+	if got := c.GetAll(keys); len(got) != 0 {
+		t.Fatalf("len(c.GetAll(keys)) = %d, want = %d", len(got), 0)
+	}
+}
+
+func TestCacheWithVariableTTL_SetAllWithTTL(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		Cost(func(key int, value int) uint32 {
+			return uint32(value)
+		}).
+		WithVariableTTL().
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	items := []Item[int, int]{
+		{Key: 1, Value: 1, TTL: time.Minute},
+		{Key: 4, Value: 1000, TTL: time.Minute},
+		{Key: 2, Value: 2, TTL: time.Hour},
+		{Key: 3, Value: 3},
+	}
+	rejected := c.SetAllWithTTL(items)
+	if len(rejected) != 2 || rejected[0].Key != 4 || rejected[1].Key != 3 {
+		t.Fatalf("the costly item and the item without ttl should be rejected in order, got %v", rejected)
+	}
+	if !c.Has(1) || !c.Has(2) || c.Has(3) || c.Has(4) {
+		t.Fatal("the items with ttl should be set")
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	if within := c.ExpiringWithin(30 * time.Minute); within != 1 {
+		t.Fatalf("only one item should expire within 30 minutes, got %d", within)
 	}
 }
 
@@ -421,3 +1319,295 @@ func (h *optimalHeap) Pop() any {
 	*h = old[0 : n-1]
 	return x
 }
+
+func TestCache_CompareAndSwap(t *testing.T) {
+	c, err := MustComparableBuilder[int, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	if c.CompareAndSwap(1, 0, 1) {
+		t.Fatal("CompareAndSwap should fail for the absent key")
+	}
+	c.Set(1, 1)
+	if c.CompareAndSwap(1, 2, 3) {
+		t.Fatal("CompareAndSwap should fail for the different value")
+	}
+	if !c.CompareAndSwap(1, 1, 2) {
+		t.Fatal("CompareAndSwap should swap the equal value")
+	}
+	if v, ok := c.Get(1); !ok || v != 2 {
+		t.Fatalf("c.Get(1) = %d, %v, want = 2, true", v, ok)
+	}
+
+	if c.SetIfChanged(1, 2) {
+		t.Fatal("SetIfChanged should not write the same value")
+	}
+	if !c.SetIfChanged(1, 3) || !c.SetIfChanged(2, 2) {
+		t.Fatal("SetIfChanged should write the changed and the absent values")
+	}
+
+	if c.CompareAndDelete(1, 2) {
+		t.Fatal("CompareAndDelete should fail for the different value")
+	}
+	if !c.CompareAndDelete(1, 3) || c.Has(1) {
+		t.Fatal("CompareAndDelete should delete the equal value")
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+}
+
+func TestCache_Equal(t *testing.T) {
+	c, err := MustBuilder[int, []int](100).
+		Equal(func(a, b []int) bool {
+			return len(a) == len(b)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set(1, []int{1})
+	if !c.CompareAndSwap(1, []int{2}, []int{1, 2}) {
+		t.Fatal("CompareAndSwap should use the equal function")
+	}
+	if !c.CompareAndDelete(1, []int{3, 4}) {
+		t.Fatal("CompareAndDelete should use the equal function")
+	}
+}
+
+func TestCacheWithVariableTTL_SetWithDeadline(t *testing.T) {
+	c, err := MustBuilder[int, int](100).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	if c.SetWithDeadline(1, 1, time.Now().Add(-time.Second)) {
+		t.Fatal("the item with the passed deadline should be rejected")
+	}
+	if !c.SetWithDeadline(2, 2, time.Now().Add(time.Hour)) {
+		t.Fatal("the item should be set")
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	if c.Has(1) || !c.Has(2) {
+		t.Fatal("only the item with the future deadline should be in the cache")
+	}
+	if c.ExpiringWithin(30*time.Minute) != 0 || c.ExpiringWithin(2*time.Hour) != 1 {
+		t.Fatal("the item should expire at the deadline")
+	}
+}
+
+func TestCache_TrackWriteLatency(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		CollectStats().
+		TrackWriteLatency().
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		c.Set(i, i)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	s := c.Stats()
+	if s.MaxWriteLatency() <= 0 {
+		t.Fatalf("write latency should be recorded, but got max: %s", s.MaxWriteLatency())
+	}
+	if s.P50WriteLatency() > s.P99WriteLatency() || s.P99WriteLatency() > s.MaxWriteLatency() {
+		t.Fatalf("inconsistent write latency: p50: %s, p99: %s, max: %s",
+			s.P50WriteLatency(), s.P99WriteLatency(), s.MaxWriteLatency())
+	}
+}
+
+func TestCache_Hasher(t *testing.T) {
+	var calls atomic.Int64
+	c, err := MustBuilder[string, int](100).
+		Hasher(func(key string) uint64 {
+			calls.Add(1)
+			return uint64(len(key))
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprint(i), i)
+	}
+	for i := 0; i < 50; i++ {
+		if v, ok := c.Get(fmt.Sprint(i)); !ok || v != i {
+			t.Fatalf("c.Get(%d) = (%d, %v), want = (%d, true)", i, v, ok, i)
+		}
+	}
+	if calls.Load() == 0 {
+		t.Fatal("the keys should be hashed with the custom hasher")
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+}
+
+func TestCache_Name(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		Name("test-cache").
+		WithTTL(time.Hour).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	defer c.Close()
+
+	c.Set(1, 1)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		t.Fatalf("can not write the goroutine profile: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"otter_cache":"test-cache"`) {
+		t.Fatal("the goroutines of the cache should be labeled with its name")
+	}
+}
+
+func TestCache_RangePage(t *testing.T) {
+	const size = 500
+	c, err := MustBuilder[int, int](size).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	if entries, cursor := c.RangePage(0, 0); len(entries) != 0 || cursor != 0 {
+		t.Fatalf("c.RangePage(0, 0) = (%v, %d), want = ([], 0)", entries, cursor)
+	}
+
+	const limit = 32
+	seen := make(map[int]int, c.Size())
+	var cursor Cursor
+	for {
+		var entries []Entry[int, int]
+		entries, cursor = c.RangePage(cursor, limit)
+		if len(entries) > limit {
+			t.Fatalf("got %d entries, but the limit is %d", len(entries), limit)
+		}
+		for _, e := range entries {
+			if e.Key != e.Value {
+				t.Fatalf("got unexpected entry: %v", e)
+			}
+			seen[e.Key]++
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if len(seen) != c.Size() {
+		t.Fatalf("got %d keys, want = %d", len(seen), c.Size())
+	}
+	for k, count := range seen {
+		if count != 1 {
+			t.Fatalf("key %d is returned %d times", k, count)
+		}
+	}
+}
+
+func TestCache_CPUAffinity(t *testing.T) {
+	c, err := MustBuilder[int, int](100).
+		CPUAffinity([]int{0}).
+		WithTTL(time.Hour).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	defer c.Close()
+
+	c.Set(1, 1)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	want := runtime.GOOS != "linux"
+	if got := c.AffinityFailed(); got != want {
+		t.Fatalf("c.AffinityFailed() = %v, want = %v", got, want)
+	}
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("c.Get(1) = (%d, %v), want = (1, true)", v, ok)
+	}
+	if err := c.IsHealthy(); err != nil {
+		t.Fatalf("c.IsHealthy() = %v, want = nil", err)
+	}
+}
+
+func TestCache_RuntimeTrace(t *testing.T) {
+	c, err := MustBuilder[int, int](100).RuntimeTrace().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatalf("can not start the trace: %v", err)
+	}
+	c.Set(1, 1)
+	c.Get(1)
+	c.Delete(1)
+	trace.Stop()
+
+	for _, region := range []string{"otter.Set", "otter.Get", "otter.Delete"} {
+		if !bytes.Contains(buf.Bytes(), []byte(region)) {
+			t.Fatalf("the trace should contain the %s region", region)
+		}
+	}
+}
+
+func TestCache_PreExpiryCallback(t *testing.T) {
+	if _, err := MustBuilder[int, int](100).
+		WithVariableTTL().
+		PreExpiryCallback(0, func(key int, value int) {}).
+		Build(); !errors.Is(err, ErrIllegalPreExpiryLead) {
+		t.Fatalf("Build() = %v, want = %v", err, ErrIllegalPreExpiryLead)
+	}
+
+	var mutex sync.Mutex
+	notified := make(map[int]int)
+	c, err := MustBuilder[int, int](100).
+		WithVariableTTL().
+		PreExpiryCallback(2*time.Second, func(key int, value int) {
+			mutex.Lock()
+			notified[key]++
+			mutex.Unlock()
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set(1, 1, 4*time.Second)
+	c.Set(2, 2, time.Hour)
+
+	time.Sleep(4 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(notified) != 1 || notified[1] != 1 {
+		t.Fatalf("only the expiring item should be notified once, but got: %v", notified)
+	}
+}
@@ -26,10 +26,14 @@ func (f feature) alias() string {
 var (
 	expiration = newFeature("expiration")
 	cost       = newFeature("cost")
+	access     = newFeature("access")
+	insertion  = newFeature("insertion")
 
 	declaredFeatures = []feature{
 		expiration,
 		cost,
+		access,
+		insertion,
 	}
 
 	nodeTypes      []string
@@ -136,7 +140,7 @@ func (g *generator) printImports() {
 	g.in()
 	g.p("\"sync/atomic\"")
 	g.p("\"unsafe\"")
-	if g.features[expiration] {
+	if g.features[expiration] || g.features[access] || g.features[insertion] {
 		g.p("")
 		g.p("\"github.com/maypok86/otter/internal/unixtime\"")
 	}
@@ -166,6 +170,16 @@ func (g *generator) printStruct() {
 	// print struct definition
 	g.p("type %s[K comparable, V any] struct {", g.structName)
 	g.in()
+	// the 64-bit fields go first, so that they are aligned for the atomic operations on the 32-bit platforms.
+	if g.features[expiration] {
+		g.p("expiration int64")
+	}
+	if g.features[access] {
+		g.p("lastAccess int64")
+	}
+	if g.features[insertion] {
+		g.p("createdAt  int64")
+	}
 	g.p("key        K")
 	g.p("value      V")
 	g.p("prev       *%s[K, V]", g.structName)
@@ -174,7 +188,6 @@ func (g *generator) printStruct() {
 	if g.features[expiration] {
 		g.p("prevExp    *%s[K, V]", g.structName)
 		g.p("nextExp    *%s[K, V]", g.structName)
-		g.p("expiration uint32")
 	}
 	if g.features[cost] {
 		g.p("cost       uint32")
@@ -190,7 +203,7 @@ func (g *generator) printStruct() {
 
 func (g *generator) printConstructors() {
 	g.p("// New%s creates a new %s.", g.structName, g.structName)
-	g.p("func New%s[K comparable, V any](key K, value V, expiration, cost uint32) Node[K, V] {", g.structName)
+	g.p("func New%s[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {", g.structName)
 	g.in()
 	g.p("return &%s[K, V]{", g.structName)
 	g.in()
@@ -202,6 +215,12 @@ func (g *generator) printConstructors() {
 	if g.features[cost] {
 		g.p("cost:       cost,")
 	}
+	if g.features[access] {
+		g.p("lastAccess: unixtime.Now(),")
+	}
+	if g.features[insertion] {
+		g.p("createdAt:  unixtime.Now(),")
+	}
 	g.p("state:      aliveState,")
 	g.out()
 	g.p("}")
@@ -233,6 +252,13 @@ func (g *generator) printFunctions() {
 	g.p("}")
 	g.p("")
 
+	g.p("func (n *%s[K, V]) ValuePtr() *V {", g.structName)
+	g.in()
+	g.p("return &n.value")
+	g.out()
+	g.p("}")
+	g.p("")
+
 	g.p("func (n *%s[K, V]) AsPointer() unsafe.Pointer {", g.structName)
 	g.in()
 	g.p("return unsafe.Pointer(n)")
@@ -347,7 +373,7 @@ func (g *generator) printFunctions() {
 	g.p("}")
 	g.p("")
 
-	g.p("func (n *%s[K, V]) Expiration() uint32 {", g.structName)
+	g.p("func (n *%s[K, V]) Expiration() int64 {", g.structName)
 	g.in()
 	if g.features[expiration] {
 		g.p("return n.expiration")
@@ -367,16 +393,53 @@ func (g *generator) printFunctions() {
 	}
 	g.out()
 	g.p("}")
+	g.p("")
+
+	g.p("func (n *%s[K, V]) LastAccess() int64 {", g.structName)
+	g.in()
+	if g.features[access] {
+		g.p("return atomic.LoadInt64(&n.lastAccess)")
+	} else {
+		g.p("panic(\"not implemented\")")
+	}
+	g.out()
+	g.p("}")
+	g.p("")
+
+	g.p("func (n *%s[K, V]) SetLastAccess(t int64) {", g.structName)
+	g.in()
+	if g.features[access] {
+		g.p("atomic.StoreInt64(&n.lastAccess, t)")
+	} else {
+		g.p("panic(\"not implemented\")")
+	}
+	g.out()
+	g.p("}")
+	g.p("")
+
+	g.p("func (n *%s[K, V]) CreatedAt() int64 {", g.structName)
+	g.in()
+	if g.features[insertion] {
+		g.p("return n.createdAt")
+	} else {
+		g.p("panic(\"not implemented\")")
+	}
+	g.out()
+	g.p("}")
 
 	const otherFunctions = `
 func (n *%s[K, V]) IsAlive() bool {
-	return atomic.LoadUint32(&n.state) == aliveState
+	return atomic.LoadUint32(&n.state) != deadState
 }
 
 func (n *%s[K, V]) Die() {
 	atomic.StoreUint32(&n.state, deadState)
 }
 
+func (n *%s[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
 func (n *%s[K, V]) Frequency() uint8 {
 	return n.frequency
 }
@@ -475,6 +538,8 @@ const (
 const (
 	aliveState uint32 = iota
 	deadState
+	// expiredState means that the node is still in the hash-table, but its removal has already been requested.
+	expiredState
 )
 
 // Node is a cache entry.
@@ -483,6 +548,8 @@ type Node[K comparable, V any] interface {
 	Key() K
 	// Value returns the value.
 	Value() V
+	// ValuePtr returns the pointer to the value stored in the node.
+	ValuePtr() *V
 	// AsPointer returns the node as a pointer.
 	AsPointer() unsafe.Pointer
 	// Prev returns the previous node in the eviction policy.
@@ -503,14 +570,23 @@ type Node[K comparable, V any] interface {
 	SetNextExp(v Node[K, V])
 	// IsExpired returns true if node is expired.
 	IsExpired() bool
-	// Expiration returns the expiration time.
-	Expiration() uint32
+	// Expiration returns the expiration time in nanoseconds since the start of the clock.
+	Expiration() int64
 	// Cost returns the cost of the node.
 	Cost() uint32
+	// LastAccess returns the time of the last access to the node.
+	LastAccess() int64
+	// SetLastAccess sets the time of the last access to the node.
+	SetLastAccess(t int64)
+	// CreatedAt returns the time of the node creation.
+	CreatedAt() int64
 	// IsAlive returns true if the entry is available in the hash-table.
 	IsAlive() bool
 	// Die sets the node to the dead state.
 	Die()
+	// MarkExpired marks the alive node whose removal has been requested after the expiration.
+	// It returns true only for the first call, so the removal is requested once.
+	MarkExpired() bool
 	// Frequency returns the frequency of the node.
 	Frequency() uint8
 	// IncrementFrequency increments the frequency of the node.
@@ -544,10 +620,12 @@ func Equals[K comparable, V any](a, b Node[K, V]) bool {
 type Config struct {
 	WithExpiration bool
 	WithCost       bool
+	WithLastAccess bool
+	WithCreatedAt  bool
 }
 
 type Manager[K comparable, V any] struct {
-	create      func(key K, value V, expiration, cost uint32) Node[K, V]
+	create      func(key K, value V, expiration int64, cost uint32) Node[K, V]
 	fromPointer func(ptr unsafe.Pointer) Node[K, V]
 }
 
@@ -560,6 +638,12 @@ func NewManager[K comparable, V any](c Config) *Manager[K, V] {
 	if c.WithCost {
 		sb.WriteString("c")
 	}
+	if c.WithLastAccess {
+		sb.WriteString("a")
+	}
+	if c.WithCreatedAt {
+		sb.WriteString("i")
+	}
 	nodeType := sb.String()
 	m := &Manager[K, V]{}
 `
@@ -567,7 +651,7 @@ func NewManager[K comparable, V any](c Config) *Manager[K, V] {
 	const nodeFooter = `return m
 }
 
-func (m *Manager[K, V]) Create(key K, value V, expiration, cost uint32) Node[K, V] {
+func (m *Manager[K, V]) Create(key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return m.create(key, value, expiration, cost)
 }
 
@@ -0,0 +1,305 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of a cache event.
+type EventType uint8
+
+const (
+	// EventInsert a new entry was added to the cache.
+	EventInsert EventType = iota
+	// EventUpdate the value of an existing entry was replaced.
+	EventUpdate
+	// EventDelete the entry was manually deleted by the user.
+	EventDelete
+	// EventEvict the entry was evicted due to size constraints.
+	EventEvict
+	// EventExpire the entry's expiration timestamp has passed.
+	EventExpire
+)
+
+// CacheEvent is a change of a cache entry.
+//
+// OldValue is set for the EventUpdate, EventDelete, EventEvict and EventExpire events,
+// NewValue is set for the EventInsert and EventUpdate events.
+type CacheEvent[K comparable, V any] struct {
+	Type     EventType
+	Key      K
+	OldValue V
+	NewValue V
+	Time     time.Time
+}
+
+// EventBus publishes the cache events to multiple subscribers.
+//
+// Each subscriber receives the events asynchronously via its own bounded buffer. If a subscriber is too slow
+// and its buffer is full, then the new events for it are dropped and counted in DroppedEventCount.
+type EventBus[K comparable, V any] struct {
+	mutex       sync.RWMutex
+	subscribers map[int]*subscriber[K, V]
+	nextID      int
+	bufferSize  int
+}
+
+type subscriber[K comparable, V any] struct {
+	eventType EventType
+	events    chan CacheEvent[K, V]
+	dropped   atomic.Int64
+}
+
+// NewEventBus creates an EventBus in which each subscriber buffers at most bufferSize events.
+func NewEventBus[K comparable, V any](bufferSize int) *EventBus[K, V] {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	return &EventBus[K, V]{
+		subscribers: make(map[int]*subscriber[K, V]),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers f to be called for each event of the given type and returns the subscription id.
+//
+// f is called sequentially in a separate goroutine.
+func (b *EventBus[K, V]) Subscribe(eventType EventType, f func(e CacheEvent[K, V])) int {
+	s := &subscriber[K, V]{
+		eventType: eventType,
+		events:    make(chan CacheEvent[K, V], b.bufferSize),
+	}
+
+	b.mutex.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = s
+	b.mutex.Unlock()
+
+	go func() {
+		for e := range s.events {
+			f(e)
+		}
+	}()
+
+	return id
+}
+
+// Unsubscribe removes the subscription with the given id. The events already buffered for it are still delivered.
+func (b *EventBus[K, V]) Unsubscribe(id int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	s, ok := b.subscribers[id]
+	if !ok {
+		return
+	}
+	delete(b.subscribers, id)
+	close(s.events)
+}
+
+// DroppedEventCount returns the number of events dropped for the subscription with the given id
+// because its buffer was full.
+func (b *EventBus[K, V]) DroppedEventCount(id int) int64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	s, ok := b.subscribers[id]
+	if !ok {
+		return 0
+	}
+	return s.dropped.Load()
+}
+
+// Publish sends the event to all subscribers of its type without blocking.
+func (b *EventBus[K, V]) Publish(e CacheEvent[K, V]) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, s := range b.subscribers {
+		if s.eventType != e.Type {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func (b *EventBus[K, V]) onDeletion(key K, value V, cause DeletionCause) {
+	if e, ok := newDeletionEvent(key, value, cause); ok {
+		b.Publish(e)
+	}
+}
+
+func (b *EventBus[K, V]) onSet(key K, oldValue V, newValue V, replaced bool) {
+	b.Publish(newSetEvent(key, oldValue, newValue, replaced))
+}
+
+// newDeletionEvent returns the event for the deletion. It returns false for the replacements,
+// because they are published as EventUpdate by the set listener.
+func newDeletionEvent[K comparable, V any](key K, value V, cause DeletionCause) (CacheEvent[K, V], bool) {
+	var eventType EventType
+	switch cause {
+	case Explicit:
+		eventType = EventDelete
+	case Size:
+		eventType = EventEvict
+	case Expired:
+		eventType = EventExpire
+	default:
+		return CacheEvent[K, V]{}, false
+	}
+
+	return CacheEvent[K, V]{
+		Type:     eventType,
+		Key:      key,
+		OldValue: value,
+		Time:     time.Now(),
+	}, true
+}
+
+func newSetEvent[K comparable, V any](key K, oldValue V, newValue V, replaced bool) CacheEvent[K, V] {
+	eventType := EventInsert
+	if replaced {
+		eventType = EventUpdate
+	}
+
+	return CacheEvent[K, V]{
+		Type:     eventType,
+		Key:      key,
+		OldValue: oldValue,
+		NewValue: newValue,
+		Time:     time.Now(),
+	}
+}
+
+// EventOverflow determines what happens to a new event when the events channel of the cache is full.
+type EventOverflow uint8
+
+const (
+	// DropNewestEvent drops the new event.
+	DropNewestEvent EventOverflow = iota
+	// DropOldestEvent drops the oldest buffered event to make room for the new one.
+	DropOldestEvent
+	// BlockOnFullEvents blocks the cache goroutine that publishes the event until there is room for it.
+	// The writes and the cleanup of the cache are stalled while the consumer lags behind.
+	// The blocked events are dropped when the cache is closed.
+	BlockOnFullEvents
+)
+
+// eventChannel delivers the cache events to a single consumer via a buffered channel.
+type eventChannel[K comparable, V any] struct {
+	mutex    sync.RWMutex
+	events   chan CacheEvent[K, V]
+	overflow EventOverflow
+	isClosed bool
+	dropped  atomic.Int64
+	// done is closed by stop to unblock the publishers waiting for room in the full channel.
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newEventChannel[K comparable, V any](bufferSize int, overflow EventOverflow) *eventChannel[K, V] {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	return &eventChannel[K, V]{
+		events:   make(chan CacheEvent[K, V], bufferSize),
+		overflow: overflow,
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *eventChannel[K, V]) publish(e CacheEvent[K, V]) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.isClosed {
+		return
+	}
+
+	switch c.overflow {
+	case BlockOnFullEvents:
+		select {
+		case c.events <- e:
+			return
+		default:
+		}
+
+		select {
+		case c.events <- e:
+		case <-c.done:
+			c.dropped.Add(1)
+		}
+	case DropOldestEvent:
+		for {
+			select {
+			case c.events <- e:
+				return
+			default:
+			}
+
+			select {
+			case <-c.events:
+				c.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case c.events <- e:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+}
+
+func (c *eventChannel[K, V]) onDeletion(key K, value V, cause DeletionCause) {
+	if e, ok := newDeletionEvent(key, value, cause); ok {
+		c.publish(e)
+	}
+}
+
+func (c *eventChannel[K, V]) onSet(key K, oldValue V, newValue V, replaced bool) {
+	c.publish(newSetEvent(key, oldValue, newValue, replaced))
+}
+
+// stop unblocks the publishers waiting for room in the full channel and makes them drop their events.
+// It doesn't take the mutex, because the blocked publishers hold it.
+func (c *eventChannel[K, V]) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *eventChannel[K, V]) close() {
+	c.stop()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.isClosed {
+		return
+	}
+	c.isClosed = true
+	close(c.events)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus[int, int](1024)
+
+	var (
+		mutex   sync.Mutex
+		inserts int
+		updates []CacheEvent[int, int]
+		deletes int
+	)
+	bus.Subscribe(EventInsert, func(e CacheEvent[int, int]) {
+		mutex.Lock()
+		inserts++
+		mutex.Unlock()
+	})
+	bus.Subscribe(EventUpdate, func(e CacheEvent[int, int]) {
+		mutex.Lock()
+		updates = append(updates, e)
+		mutex.Unlock()
+	})
+	deleteID := bus.Subscribe(EventDelete, func(e CacheEvent[int, int]) {
+		mutex.Lock()
+		deletes++
+		mutex.Unlock()
+	})
+
+	c, err := MustBuilder[int, int](1000).EventBus(bus).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	size := 100
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	c.Set(0, 100)
+	for i := 1; i < size/2; i++ {
+		c.Delete(i)
+	}
+	bus.Unsubscribe(deleteID)
+	// flush the write buffer.
+	for i := size; i < 2*size; i++ {
+		c.Set(i, i)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if inserts < size {
+		t.Fatalf("inserts = %d, want >= %d", inserts, size)
+	}
+	if len(updates) != 1 || updates[0].Key != 0 || updates[0].OldValue != 0 || updates[0].NewValue != 100 {
+		t.Fatalf("unexpected update events: %+v", updates)
+	}
+	if deletes > size/2-1 {
+		t.Fatalf("deletes = %d, want <= %d", deletes, size/2-1)
+	}
+}
+
+func TestEventBus_DroppedEventCount(t *testing.T) {
+	bus := NewEventBus[int, int](1)
+
+	block := make(chan struct{})
+	id := bus.Subscribe(EventInsert, func(e CacheEvent[int, int]) {
+		<-block
+	})
+
+	for i := 0; i < 10; i++ {
+		bus.Publish(CacheEvent[int, int]{Type: EventInsert, Key: i})
+	}
+	close(block)
+
+	if dropped := bus.DroppedEventCount(id); dropped < 8 {
+		t.Fatalf("dropped = %d, want >= 8", dropped)
+	}
+
+	bus.Unsubscribe(id)
+	if dropped := bus.DroppedEventCount(id); dropped != 0 {
+		t.Fatalf("dropped = %d for unknown subscription, want 0", dropped)
+	}
+}
+
+func TestCache_Events(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).EventChannel(1024, DropNewestEvent).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	c.Set(1, 1)
+	c.Set(1, 2)
+	c.Delete(1)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	c.Close()
+
+	var types []EventType
+	for e := range c.Events() {
+		types = append(types, e.Type)
+	}
+	if len(types) != 3 || types[0] != EventInsert || types[1] != EventUpdate || types[2] != EventDelete {
+		t.Fatalf("unexpected events: %v", types)
+	}
+}
+
+func TestCache_CloseWithBlockedEvents(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).EventChannel(1, BlockOnFullEvents).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	// the consumer doesn't read the events, so the cache goroutine blocks on the full channel
+	// once it applies the first batch of the writes. The rest of the writes fit in the write buffer.
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		c.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close should not wait for the consumer of the events")
+	}
+	if c.DroppedEventCount() == 0 {
+		t.Fatal("the blocked events should be dropped on close")
+	}
+}
+
+func TestEventChannel_Overflow(t *testing.T) {
+	for _, tt := range []struct {
+		overflow EventOverflow
+		// want is the key of the last delivered event.
+		want int
+	}{
+		{overflow: DropNewestEvent, want: 1},
+		{overflow: DropOldestEvent, want: 2},
+	} {
+		c := newEventChannel[int, int](2, tt.overflow)
+		for i := 0; i < 3; i++ {
+			c.onSet(i, 0, i, false)
+		}
+		c.close()
+
+		if c.dropped.Load() != 1 {
+			t.Fatalf("dropped = %d, want = 1", c.dropped.Load())
+		}
+		var last CacheEvent[int, int]
+		for e := range c.events {
+			last = e
+		}
+		if last.Key != tt.want {
+			t.Fatalf("unexpected last event for overflow %d: %+v", tt.overflow, last)
+		}
+	}
+}
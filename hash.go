@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import "github.com/maypok86/otter/internal/xruntime"
+
+// integer is a constraint that permits any integer type.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// intHashSeed is chosen randomly at startup, so that the keys colliding in IntHash can't be predicted.
+var intHashSeed = uint64(xruntime.Fastrand())<<32 | uint64(xruntime.Fastrand())
+
+// IntHash is a hash function for the integer keys that can be passed to Builder.Hasher, e.g.
+// Hasher(otter.IntHash[int64]). It is cheaper than the default hasher, because it mixes the bits
+// of the key inline instead of calling the runtime hash function.
+//
+// The mixing is a bijection, so the distinct keys of the same type never have the same hash.
+//
+// There is no such function for the string keys: the default hasher uses the hardware-accelerated
+// runtime hash for them, which is faster than the hashes implemented in Go.
+func IntHash[K integer](key K) uint64 {
+	h := uint64(key) ^ intHashSeed
+	// the finalizer of MurmurHash3.
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"testing"
+)
+
+func TestIntHash(t *testing.T) {
+	const count = 1 << 16
+	const buckets = 1 << 8
+
+	seen := make(map[uint64]struct{}, count)
+	var distribution [buckets]int
+	for i := int64(0); i < count; i++ {
+		h := IntHash(i)
+		if h != IntHash(i) {
+			t.Fatalf("IntHash(%d) is not deterministic", i)
+		}
+		seen[h] = struct{}{}
+		distribution[h&(buckets-1)]++
+	}
+	if len(seen) != count {
+		t.Fatalf("got %d distinct hashes for %d keys", len(seen), count)
+	}
+
+	// the sequential keys should be spread over the buckets selected by the low bits.
+	want := count / buckets
+	for i, got := range distribution {
+		if got < want/2 || got > want*2 {
+			t.Fatalf("bucket %d has %d keys, want about %d", i, got, want)
+		}
+	}
+
+	if IntHash(uint8(1)) != IntHash(uint64(1)) {
+		t.Fatal("IntHash should not depend on the width of the key type")
+	}
+}
+
+func benchmarkGetInt64(b *testing.B, hash func(key int64) uint64) {
+	b.Helper()
+	const size = 1 << 16
+	builder := MustBuilder[int64, int](size)
+	if hash != nil {
+		builder.Hasher(hash)
+	}
+	c, err := builder.Build()
+	if err != nil {
+		b.Fatalf("can not create cache: %v", err)
+	}
+	defer c.Close()
+
+	for i := int64(0); i < size; i++ {
+		c.Set(i, 0)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Get(int64(i) & (size - 1))
+	}
+}
+
+func BenchmarkCache_GetInt64(b *testing.B) {
+	benchmarkGetInt64(b, nil)
+}
+
+func BenchmarkCache_GetInt64IntHash(b *testing.B) {
+	benchmarkGetInt64(b, IntHash[int64])
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen means that the loader has not been called because it has failed too many times in a row.
+var ErrCircuitOpen = errors.New("loader circuit breaker is open")
+
+// CircuitBreakerState is the state of the circuit breaker of the loader.
+type CircuitBreakerState uint8
+
+const (
+	// CircuitClosed the loader is called as usual.
+	CircuitClosed CircuitBreakerState = iota
+	// CircuitOpen the loader has failed too many times in a row and is not called until the cooldown passes.
+	CircuitOpen
+	// CircuitHalfOpen the cooldown has passed and a single trial call of the loader is in progress.
+	CircuitHalfOpen
+)
+
+// circuitBreaker stops calling the loader after threshold consecutive failures and allows a single trial call
+// after the cooldown. The circuit is closed again if the trial call succeeds.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     CircuitBreakerState
+	failures  int
+	openedAt  time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether the loader can be called.
+func (b *circuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	default:
+		// only the trial call is allowed until its result is known.
+		return false
+	}
+}
+
+// record updates the state of the circuit with the result of the loader call allowed by allow.
+func (b *circuitBreaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if errors.Is(err, ErrRateLimited) {
+		// the loader has not been called, so the trial call is left to the next load.
+		if b.state == CircuitHalfOpen {
+			b.state = CircuitOpen
+		}
+		return
+	}
+	if err == nil {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// currentState returns the current state of the circuit.
+func (b *circuitBreaker) currentState() CircuitBreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state
+}
+
+// withLoaderCircuitBreaker wraps the loader, so that it is not called while the circuit is open.
+func (c *Cache[K, V]) withLoaderCircuitBreaker(
+	batchLoader func(keys []K) (map[K]V, error),
+) func(keys []K) (map[K]V, error) {
+	if c.loaderBreaker == nil {
+		return batchLoader
+	}
+
+	return func(keys []K) (values map[K]V, err error) {
+		if !c.loaderBreaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		completed := false
+		defer func() {
+			// the panic of the loader is a failure too, otherwise the half-open circuit is never closed.
+			if !completed {
+				c.loaderBreaker.record(ErrLoaderPanicked)
+				return
+			}
+			c.loaderBreaker.record(err)
+		}()
+		values, err = batchLoader(keys)
+		completed = true
+		return values, err
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of the loader.
+// It is always CircuitClosed if the circuit breaker is not enabled.
+func (c *Cache[K, V]) CircuitState() CircuitBreakerState {
+	if c.loaderBreaker == nil {
+		return CircuitClosed
+	}
+	return c.loaderBreaker.currentState()
+}
@@ -15,7 +15,16 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"runtime"
+	"runtime/pprof"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/maypok86/otter/internal/expire"
@@ -24,6 +33,7 @@ import (
 	"github.com/maypok86/otter/internal/lossy"
 	"github.com/maypok86/otter/internal/queue"
 	"github.com/maypok86/otter/internal/s3fifo"
+	"github.com/maypok86/otter/internal/sampled"
 	"github.com/maypok86/otter/internal/stats"
 	"github.com/maypok86/otter/internal/unixtime"
 	"github.com/maypok86/otter/internal/xmath"
@@ -44,18 +54,116 @@ const (
 	Expired
 )
 
+var (
+	// ErrFrozen means that a write has been rejected because the cache is in the read-only mode.
+	ErrFrozen = errors.New("cache is frozen")
+	// ErrAlreadyFrozen means that the cache is already in the read-only mode.
+	ErrAlreadyFrozen = errors.New("cache is already frozen")
+	// ErrUnlockedKey means that a transaction has tried to write a key that was not locked by it.
+	ErrUnlockedKey = errors.New("transaction can write only the locked keys")
+	// ErrCostTooLarge means that the cost of an item exceeds the maximum available cost.
+	ErrCostTooLarge = errors.New("item cost is too large")
+)
+
+// EvictionPolicy is the algorithm used to select the entries to evict.
+type EvictionPolicy uint8
+
+const (
+	// S3FIFO the scan-resistant S3-FIFO eviction policy.
+	S3FIFO EvictionPolicy = iota
+	// LRU the approximate LRU policy: the entries are evicted in the order of recency of their use,
+	// but the reads are recorded through the lossy buffers, so some of them may be dropped under contention.
+	LRU
+	// Sampled the least recently used entry among several randomly sampled ones is evicted.
+	Sampled
+	// LFU the S3-FIFO variant that favors the frequently used entries over the scan resistance.
+	LFU
+)
+
+// ConflictPolicy determines which item is kept when merging the caches with the same key.
+type ConflictPolicy uint8
+
+const (
+	// ConflictKeepExisting the item of the receiver is kept.
+	ConflictKeepExisting ConflictPolicy = iota
+	// ConflictOverwrite the item of the receiver is overwritten.
+	ConflictOverwrite
+	// ConflictKeepNewerTTL the item that expires later is kept.
+	ConflictKeepNewerTTL
+)
+
+// CostDistributionBuckets is the number of buckets in the histogram returned by CostDistribution.
+const CostDistributionBuckets = 17
+
 const (
-	minWriteBufferCapacity uint32 = 4
+	minWriteBufferCapacity   uint32 = 4
+	minDeletedBufferCapacity        = 64
+	// compactWriteBufferCapacity is the maximum write buffer capacity in the compact mode.
+	// It is enough to hold one batch of the write tasks.
+	compactWriteBufferCapacity uint32 = 64
+
+	// stallTimeout is the time after which a background goroutine that has not made progress is considered stalled.
+	stallTimeout = 10 * time.Second
+	// DefaultMinCleanupInterval is the default lower bound of the interval between the cleanup cycles.
+	DefaultMinCleanupInterval = 100 * time.Millisecond
+	// DefaultMaxCleanupInterval is the default upper bound of the interval between the cleanup cycles.
+	DefaultMaxCleanupInterval = time.Second
+	// cleanupExpiredThreshold is the number of items expired in a cleanup cycle that halves the interval
+	// before the next cycle.
+	cleanupExpiredThreshold = 1024
+	// writeBufferOverloadPercent is the write buffer fill level after which the cache is considered overloaded.
+	writeBufferOverloadPercent = 80
+	// writeBufferOverflowPercent is the write buffer fill level after which an overflow is counted in the stats.
+	writeBufferOverflowPercent = 90
 )
 
+// ttlDistributionBounds are the lower bounds of the buckets used by TTLDistribution.
+var ttlDistributionBounds = []time.Duration{
+	0,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+}
+
 func zeroValue[V any]() V {
 	var zero V
 	return zero
 }
 
-func getExpiration(ttl time.Duration) uint32 {
-	ttlSecond := (ttl + time.Second - 1) / time.Second
-	return unixtime.Now() + uint32(ttlSecond)
+func getExpiration(ttl time.Duration) int64 {
+	return unixtime.Now() + int64(ttl)
+}
+
+// KeyFrequency is a key with the estimated frequency of accesses to it.
+type KeyFrequency[K comparable] struct {
+	Key       K
+	Frequency int
+}
+
+// EvictionCandidate is an entry that is likely to be evicted soon.
+type EvictionCandidate[K comparable, V any] struct {
+	Key   K
+	Value V
+	Cost  uint32
+}
+
+// DeletedEntry is an entry deleted from the cache.
+type DeletedEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Cause DeletionCause
+}
+
+// Health is a snapshot of the state of the cache internals.
+type Health struct {
+	Closed              bool
+	WriteBufferOverload bool
+	ProcessStalled      bool
+	CleanupStalled      bool
+	// AffinityFailed means that the background goroutines couldn't be bound to the configured CPUs,
+	// e.g. because the platform doesn't support it. The cache works normally without the binding.
+	AffinityFailed bool
 }
 
 // Config is a set of cache settings.
@@ -68,47 +176,208 @@ type Config[K comparable, V any] struct {
 	CostFunc         func(key K, value V) uint32
 	WithCost         bool
 	DeletionListener func(key K, value V, cause DeletionCause)
+	// DeletionBatchListener is notified once per batch of the deletions applied by the background goroutines.
+	DeletionBatchListener func(entries []DeletedEntry[K, V])
+	DryRun                bool
+	WithLastAccess        bool
+	WithCreatedAt         bool
+	TimeResolution        time.Duration
+	WarmUpThreshold       float64
+	SetListener           func(key K, oldValue V, newValue V, replaced bool)
+	// ReadBuffersCount is the number of read buffers. It is rounded up to a power of two,
+	// and the default value depends on the parallelism if it is not positive.
+	ReadBuffersCount int
+	EvictionPolicy   EvictionPolicy
+	ManualCleanup    bool
+	// Compact minimizes the number and the size of the internal buffers.
+	Compact bool
+	// CloneFunc is used to store and return the copies of the values, so that the callers can't mutate the cached ones.
+	CloneFunc func(value V) V
+	// EqualFunc is used to compare the values in CompareAndSwap, CompareAndDelete and SetIfChanged.
+	// If it is nil, then the values are compared with == via interface conversion.
+	EqualFunc func(a, b V) bool
+	// PanicHandler is notified about the panics in the user callbacks. If it is set, the panics are recovered.
+	PanicHandler func(stage string, r any)
+	// TrackWriteLatency enables the recording of the time the write tasks wait in the write buffer.
+	TrackWriteLatency bool
+	// Name is used to label the goroutines of the cache in the profiles.
+	Name string
+	// WithTrace enables the runtime/trace regions for Get, Set, Delete and the eviction mutex waits.
+	WithTrace bool
+	// PreExpiryCallback is called by the cleanup goroutine once for each item about PreExpiryLead before it expires.
+	PreExpiryCallback func(key K, value V)
+	PreExpiryLead     time.Duration
+	// MinCleanupInterval and MaxCleanupInterval bound the interval between the cleanup cycles,
+	// which is shortened when many items expire. The defaults are used if they are not positive.
+	MinCleanupInterval time.Duration
+	MaxCleanupInterval time.Duration
+	// CleanupBatchSize limits the number of the expired items removed by a cleanup cycle.
+	// There is no limit if it is not positive.
+	CleanupBatchSize int
+	// CleanupConcurrency is the number of the goroutines that remove the expired items in a cleanup cycle,
+	// each from its own partition of the expire policy.
+	CleanupConcurrency int
+	// LoadFactor is the occupancy of the hash table at which it is expanded.
+	// hashtable.DefaultLoadFactor is used if it is not positive.
+	LoadFactor float64
+	// InternEqual and InternHash enable the deduplication of the equal values stored in the cache.
+	InternEqual func(a, b V) bool
+	InternHash  func(v V) uint64
+	// StatsRecorder receives the statistics events if the stats are enabled.
+	StatsRecorder stats.Recorder
+	// CPUAffinity is the set of CPUs the background goroutines are bound to. They are not bound if it is empty.
+	CPUAffinity []int
+	// HashFunc is used to hash the keys instead of the default seeded hasher if it is set.
+	HashFunc func(key K) uint64
+	// LoaderRateLimit is the maximum number of the loader calls per second. The loader is not limited if it is zero.
+	LoaderRateLimit float64
+	// LoaderRateLimitBehavior determines what a load does when the loader rate limit is exceeded.
+	LoaderRateLimitBehavior RateLimitBehavior
+	// LoaderFailureThreshold is the number of the consecutive loader failures that opens the circuit breaker.
+	// The circuit breaker is disabled if it is zero.
+	LoaderFailureThreshold int
+	// LoaderCooldown is the time after which an open circuit breaker allows a trial call of the loader.
+	LoaderCooldown time.Duration
+	// LoaderMaxAttempts is the maximum number of the loader calls for a single load. The failed loads are not retried
+	// if it is zero or one.
+	LoaderMaxAttempts int
+	// LoaderBackoff determines the wait before each retry of the loader.
+	LoaderBackoff BackoffPolicy
+}
+
+type evictionPolicy[K comparable, V any] interface {
+	Read(nodes []node.Node[K, V])
+	Add(deleted []node.Node[K, V], n node.Node[K, V]) []node.Node[K, V]
+	Delete(n node.Node[K, V])
+	NextEvictions(n int) []node.Node[K, V]
+	Evict(deleted []node.Node[K, V], n int) []node.Node[K, V]
+	Cost() uint32
+	MaxAvailableCost() uint32
+	Clear()
+}
+
+// admissionPolicy is implemented by the eviction policies that filter the new entries.
+type admissionPolicy interface {
+	AdmissionStats() (added, rejected uint64)
+}
+
+// queuePolicy is implemented by the eviction policies based on the S3-FIFO queues.
+type queuePolicy interface {
+	QueueSizes() (small, main, ghost int)
 }
 
 type expirePolicy[K comparable, V any] interface {
 	Add(n node.Node[K, V])
 	Delete(n node.Node[K, V])
-	RemoveExpired(expired []node.Node[K, V]) []node.Node[K, V]
+	RemoveExpiredPartition(expired []node.Node[K, V], limit, partition, total int) []node.Node[K, V]
+	ExpiringBefore(deadline int64) int
+	ForEachExpiringBefore(deadline int64, f func(n node.Node[K, V]))
+	ForEach(f func(n node.Node[K, V]))
 	Clear()
 }
 
 // Cache is a structure performs a best-effort bounding of a hash table using eviction algorithm
 // to determine which entries to evict when the capacity is exceeded.
 type Cache[K comparable, V any] struct {
-	nodeManager      *node.Manager[K, V]
-	hashmap          *hashtable.Map[K, V]
-	policy           *s3fifo.Policy[K, V]
-	expirePolicy     expirePolicy[K, V]
-	stats            *stats.Stats
-	readBuffers      []*lossy.Buffer[K, V]
-	writeBuffer      *queue.Growable[task[K, V]]
+	nodeManager  *node.Manager[K, V]
+	hashmap      *hashtable.Map[K, V]
+	policy       evictionPolicy[K, V]
+	expirePolicy expirePolicy[K, V]
+	stats        *stats.Stats
+	readBuffers  []*lossy.Buffer[K, V]
+	writeBuffer  *queue.Growable[task[K, V]]
+	closeOnce    sync.Once
+	doneClear    chan struct{}
+	// doneClose is closed by the process goroutine when it stops, so that the pending flushes don't wait forever.
+	doneClose             chan struct{}
+	costFunc              func(key K, value V) uint32
+	deletionListener      func(key K, value V, cause DeletionCause)
+	deletionBatchListener func(entries []DeletedEntry[K, V])
+	panicHandler          func(stage string, r any)
+	cloneFunc             func(value V) V
+	equalFunc             func(a, b V) bool
+	setListener           func(key K, oldValue V, newValue V, replaced bool)
+	capacity              int
+	mask                  uint32
+	ttl                   int64
+	withExpiration        bool
+	manualCleanup         bool
+	withLastAccess        bool
+	withCreatedAt         bool
+	trackWriteLatency     bool
+	name                  string
+	withTrace             bool
+	preExpiryCallback     func(key K, value V)
+	preExpiryLead         int64
+	minCleanupInterval    time.Duration
+	maxCleanupInterval    time.Duration
+	cleanupBatchSize      int
+	cleanupConcurrency    int
+	timeResolution        time.Duration
+	warmUpThreshold       float64
+	warmUpDone            chan struct{}
+	isWarm                bool
+	isClosed              atomic.Bool
+	isFrozen              atomic.Bool
+	loadMutex             sync.Mutex
+	loads                 map[K]*loadCall[V]
+	// loaderLimiter limits the rate of the loader calls if the rate limit is set.
+	loaderLimiter     *rateLimiter
+	rateLimitBehavior RateLimitBehavior
+	// loaderBreaker stops calling the failing loader if the circuit breaker is enabled.
+	loaderBreaker *circuitBreaker
+	// loaderMaxAttempts and loaderBackoff control the retries of the failed loader calls.
+	loaderMaxAttempts int
+	loaderBackoff     BackoffPolicy
+	dryRun            bool
+	// preExpiryNotified is the expiration time up to which the pre-expiry callback has been called
+	// and preExpiryScanned is the time of the last scan. They are accessed only by the cleanup goroutine.
+	preExpiryNotified int64
+	preExpiryScanned  int64
+	// interner deduplicates the stored values if the value interning is enabled.
+	interner *valueInterner[V]
+	// pins counts the pins of the keys, and parked holds the nodes of the pinned keys withheld
+	// from the eviction policy. They are guarded by the eviction mutex.
+	pins   map[K]int
+	parked map[K]node.Node[K, V]
+	// cpuAffinity is the set of CPUs the background goroutines are bound to.
+	cpuAffinity    []int
+	affinityFailed atomic.Bool
+	// the fields below are written by the writers and the background goroutines, so they are kept
+	// off the cache lines of the fields above, which are mostly read on the read path.
+	writePathPadding [xruntime.CacheLineSize]byte
 	evictionMutex    sync.Mutex
-	closeOnce        sync.Once
-	doneClear        chan struct{}
-	costFunc         func(key K, value V) uint32
-	deletionListener func(key K, value V, cause DeletionCause)
-	capacity         int
-	mask             uint32
-	ttl              uint32
-	withExpiration   bool
-	isClosed         bool
+	processBusySince atomic.Int64
+	cleanupHeartbeat atomic.Int64
+	dryRunCount      atomic.Int64
+	dryRunCost       atomic.Int64
 }
 
 // NewCache returns a new cache instance based on the settings from Config.
 func NewCache[K comparable, V any](c Config[K, V]) *Cache[K, V] {
+	if c.PanicHandler != nil {
+		c = withPanicRecovery(c)
+	}
+
 	parallelism := xruntime.Parallelism()
 	roundedParallelism := int(xmath.RoundUpPowerOf2(parallelism))
 	maxWriteBufferCapacity := uint32(128 * roundedParallelism)
-	readBuffersCount := 4 * roundedParallelism
+	readBuffersCount := readBuffersCountFor(c.ReadBuffersCount, roundedParallelism)
+	if c.Compact {
+		maxWriteBufferCapacity = compactWriteBufferCapacity
+		readBuffersCount = 1
+	}
+
+	// the sampled policy compares the last access times of the nodes.
+	withLastAccess := c.WithLastAccess || c.EvictionPolicy == Sampled
+	// the pre-expiry callback tells the items inserted since the last scan by their creation times.
+	withCreatedAt := c.WithCreatedAt || c.PreExpiryCallback != nil
 
 	nodeManager := node.NewManager[K, V](node.Config{
 		WithExpiration: c.TTL != nil || c.WithVariableTTL,
 		WithCost:       c.WithCost,
+		WithLastAccess: withLastAccess,
+		WithCreatedAt:  withCreatedAt,
 	})
 
 	readBuffers := make([]*lossy.Buffer[K, V], 0, readBuffersCount)
@@ -116,11 +385,22 @@ func NewCache[K comparable, V any](c Config[K, V]) *Cache[K, V] {
 		readBuffers = append(readBuffers, lossy.New[K, V](nodeManager))
 	}
 
+	loadFactor := hashtable.DefaultLoadFactor
+	if c.LoadFactor > 0 {
+		loadFactor = c.LoadFactor
+	}
 	var hashmap *hashtable.Map[K, V]
-	if c.InitialCapacity == nil {
-		hashmap = hashtable.New[K, V](nodeManager)
-	} else {
-		hashmap = hashtable.NewWithSize[K, V](nodeManager, *c.InitialCapacity)
+	switch {
+	case c.HashFunc != nil:
+		size := 0
+		if c.InitialCapacity != nil {
+			size = *c.InitialCapacity
+		}
+		hashmap = hashtable.NewWithHasher[K, V](nodeManager, size, loadFactor, c.HashFunc)
+	case c.InitialCapacity == nil:
+		hashmap = hashtable.New[K, V](nodeManager, loadFactor)
+	default:
+		hashmap = hashtable.NewWithSize[K, V](nodeManager, *c.InitialCapacity, loadFactor)
 	}
 
 	var expPolicy expirePolicy[K, V]
@@ -133,31 +413,102 @@ func NewCache[K comparable, V any](c Config[K, V]) *Cache[K, V] {
 		expPolicy = expire.NewDisabled[K, V]()
 	}
 
+	var policy evictionPolicy[K, V]
+	switch c.EvictionPolicy {
+	case LRU:
+		policy = s3fifo.NewLRUPolicy[K, V](uint32(c.Capacity))
+	case LFU:
+		policy = s3fifo.NewLFUPolicy[K, V](uint32(c.Capacity))
+	case Sampled:
+		policy = sampled.NewPolicy[K, V](uint32(c.Capacity), sampled.DefaultSampleSize, func() node.Node[K, V] {
+			return hashmap.RandomNode(xruntime.Fastrand())
+		})
+	default:
+		policy = s3fifo.NewPolicy[K, V](uint32(c.Capacity))
+	}
+
 	cache := &Cache[K, V]{
-		nodeManager:      nodeManager,
-		hashmap:          hashmap,
-		policy:           s3fifo.NewPolicy[K, V](uint32(c.Capacity)),
-		expirePolicy:     expPolicy,
-		readBuffers:      readBuffers,
-		writeBuffer:      queue.NewGrowable[task[K, V]](minWriteBufferCapacity, maxWriteBufferCapacity),
-		doneClear:        make(chan struct{}),
-		mask:             uint32(readBuffersCount - 1),
-		costFunc:         c.CostFunc,
-		deletionListener: c.DeletionListener,
-		capacity:         c.Capacity,
+		nodeManager:           nodeManager,
+		hashmap:               hashmap,
+		policy:                policy,
+		expirePolicy:          expPolicy,
+		readBuffers:           readBuffers,
+		writeBuffer:           queue.NewGrowable[task[K, V]](minWriteBufferCapacity, maxWriteBufferCapacity),
+		doneClear:             make(chan struct{}),
+		doneClose:             make(chan struct{}),
+		loads:                 make(map[K]*loadCall[V]),
+		mask:                  uint32(readBuffersCount - 1),
+		costFunc:              c.CostFunc,
+		deletionListener:      c.DeletionListener,
+		panicHandler:          c.PanicHandler,
+		cloneFunc:             c.CloneFunc,
+		equalFunc:             c.EqualFunc,
+		deletionBatchListener: c.DeletionBatchListener,
+		setListener:           c.SetListener,
+		capacity:              c.Capacity,
+		dryRun:                c.DryRun,
 	}
 
 	if c.StatsEnabled {
-		cache.stats = stats.New()
+		cache.stats = stats.NewWithRecorder(c.StatsRecorder)
 	}
 	if c.TTL != nil {
-		cache.ttl = uint32((*c.TTL + time.Second - 1) / time.Second)
+		cache.ttl = int64(*c.TTL)
 	}
 
 	cache.withExpiration = c.TTL != nil || c.WithVariableTTL
+	cache.withLastAccess = withLastAccess
+	cache.withCreatedAt = withCreatedAt
+	cache.trackWriteLatency = c.TrackWriteLatency && c.StatsEnabled
+	cache.timeResolution = c.TimeResolution
+	cache.warmUpThreshold = c.WarmUpThreshold
+	cache.warmUpDone = make(chan struct{})
+	if cache.warmUpThreshold <= 0 {
+		cache.isWarm = true
+		close(cache.warmUpDone)
+	}
 
-	if cache.withExpiration {
-		unixtime.Start()
+	if cache.withUnixtime() {
+		unixtime.StartWithResolution(cache.timeResolution)
+	}
+	cache.manualCleanup = c.ManualCleanup
+	cache.name = c.Name
+	cache.cpuAffinity = append([]int(nil), c.CPUAffinity...)
+	if c.LoaderRateLimit > 0 {
+		cache.loaderLimiter = newRateLimiter(c.LoaderRateLimit)
+		cache.rateLimitBehavior = c.LoaderRateLimitBehavior
+	}
+	if c.LoaderFailureThreshold > 0 {
+		cache.loaderBreaker = newCircuitBreaker(c.LoaderFailureThreshold, c.LoaderCooldown)
+	}
+	if c.LoaderMaxAttempts > 1 {
+		cache.loaderMaxAttempts = c.LoaderMaxAttempts
+		cache.loaderBackoff = c.LoaderBackoff
+	}
+	cache.withTrace = c.WithTrace
+	cache.preExpiryCallback = c.PreExpiryCallback
+	if c.InternEqual != nil && c.InternHash != nil {
+		cache.interner = newValueInterner(c.Capacity, c.InternEqual, c.InternHash)
+	}
+	cache.minCleanupInterval = DefaultMinCleanupInterval
+	if c.MinCleanupInterval > 0 {
+		cache.minCleanupInterval = c.MinCleanupInterval
+	}
+	cache.maxCleanupInterval = DefaultMaxCleanupInterval
+	if c.MaxCleanupInterval > 0 {
+		cache.maxCleanupInterval = c.MaxCleanupInterval
+	}
+	if cache.minCleanupInterval > cache.maxCleanupInterval {
+		cache.minCleanupInterval = cache.maxCleanupInterval
+	}
+	cache.cleanupConcurrency = c.CleanupConcurrency
+	cache.cleanupBatchSize = math.MaxInt
+	if c.CleanupBatchSize > 0 {
+		cache.cleanupBatchSize = c.CleanupBatchSize
+	}
+	cache.preExpiryLead = int64(c.PreExpiryLead)
+	if cache.withExpiration && !cache.manualCleanup {
+		cache.cleanupHeartbeat.Store(time.Now().UnixNano())
 		go cache.cleanup()
 	}
 
@@ -166,46 +517,196 @@ func NewCache[K comparable, V any](c Config[K, V]) *Cache[K, V] {
 	return cache
 }
 
+// readBuffersCountFor returns the number of read buffers. It is always a power of two,
+// because the read buffer index is calculated using a bit mask.
+func readBuffersCountFor(requested, roundedParallelism int) int {
+	if requested <= 0 {
+		return 4 * roundedParallelism
+	}
+	return int(xmath.RoundUpPowerOf2(uint32(requested)))
+}
+
+func (c *Cache[K, V]) withUnixtime() bool {
+	return c.withExpiration || c.withLastAccess || c.withCreatedAt
+}
+
 func (c *Cache[K, V]) getReadBufferIdx() int {
 	return int(xruntime.Fastrand() & c.mask)
 }
 
 // Has checks if there is an item with the given key in the cache.
 func (c *Cache[K, V]) Has(key K) bool {
-	_, ok := c.Get(key)
+	_, ok := c.getNode(key)
 	return ok
 }
 
 // Get returns the value associated with the key in this cache.
 func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if c.withTrace {
+		defer startRegion("otter.Get").End()
+	}
+
+	got, ok := c.getNode(key)
+	if !ok {
+		return zeroValue[V](), false
+	}
+
+	return c.cloneValue(got.Value()), true
+}
+
+// GetPtr returns the pointer to the value associated with the key in this cache without copying the value.
+//
+// The value of a node is never changed after its creation, so the pointer remains valid after the next
+// modification of the key, but it points to the old value. If the clone function is set,
+// the pointer to a clone of the value is returned.
+func (c *Cache[K, V]) GetPtr(key K) (*V, bool) {
+	if c.withTrace {
+		defer startRegion("otter.Get").End()
+	}
+
+	got, ok := c.getNode(key)
+	if !ok {
+		return nil, false
+	}
+
+	if c.cloneFunc != nil {
+		v := c.cloneFunc(got.Value())
+		return &v, true
+	}
+	return got.ValuePtr(), true
+}
+
+func (c *Cache[K, V]) getNode(key K) (node.Node[K, V], bool) {
 	got, ok := c.hashmap.Get(key)
 	if !ok || !got.IsAlive() {
 		c.stats.IncMisses()
-		return zeroValue[V](), false
+		return nil, false
 	}
 
 	if got.IsExpired() {
-		c.writeBuffer.Push(newDeleteTask(got))
+		// only the first read of the expired node requests its removal, so the concurrent reads
+		// of a just expired hot key don't flood the write buffer.
+		if got.MarkExpired() {
+			c.pushWrite(newExpireTask(got))
+		}
 		c.stats.IncMisses()
-		return zeroValue[V](), false
+		c.stats.IncExpiredMisses()
+		return nil, false
 	}
 
 	c.afterGet(got)
 	c.stats.IncHits()
 
-	return got.Value(), ok
+	return got, true
+}
+
+// cloneValue returns a clone of the value if the clone function is set.
+func (c *Cache[K, V]) cloneValue(value V) V {
+	if c.cloneFunc == nil {
+		return value
+	}
+
+	return c.cloneFunc(value)
 }
 
 func (c *Cache[K, V]) afterGet(got node.Node[K, V]) {
+	if c.withLastAccess {
+		// avoid writing to the shared cache line if the time has not changed.
+		if now := unixtime.Now(); got.LastAccess() != now {
+			got.SetLastAccess(now)
+		}
+	}
+
 	idx := c.getReadBufferIdx()
 	pb := c.readBuffers[idx].Add(got)
-	if pb != nil {
-		c.evictionMutex.Lock()
-		c.policy.Read(pb.Returned)
+	if pb == nil {
+		return
+	}
+
+	// the reads never wait for the eviction mutex: if it is held, the batch is left
+	// to its holder, which applies the pending batches when releasing it.
+	if !c.evictionMutex.TryLock() {
+		c.readBuffers[idx].Defer()
+		// the holder may have checked the pending batches before the Defer,
+		// so the batch is applied here if the mutex has been released since then.
+		if c.evictionMutex.TryLock() {
+			c.unlockEvictionMutex()
+		}
+		return
+	}
+	c.policy.Read(pb.Returned)
+	c.readBuffers[idx].Free()
+	c.unlockEvictionMutex()
+}
+
+// unlockEvictionMutex applies the pending read batches and releases the eviction mutex.
+//
+// A reader can defer its batch after the pending batches have been applied, so they are checked again
+// after the release and applied here if the mutex is still free. Otherwise, they are left to the new holder.
+func (c *Cache[K, V]) unlockEvictionMutex() {
+	for {
+		c.applyPendingReads()
 		c.evictionMutex.Unlock()
+		if !c.hasPendingReads() || !c.evictionMutex.TryLock() {
+			return
+		}
+	}
+}
+
+// hasPendingReads reports whether any reader has deferred its batch.
+func (c *Cache[K, V]) hasPendingReads() bool {
+	for _, rb := range c.readBuffers {
+		if rb.HasPending() {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPendingReads applies the read batches deferred by the readers that found the eviction mutex held.
+//
+// It must be called under the eviction mutex.
+func (c *Cache[K, V]) applyPendingReads() {
+	for _, rb := range c.readBuffers {
+		if pb := rb.TakePending(); pb != nil {
+			c.policy.Read(pb.Returned)
+			rb.Free()
+		}
+	}
+}
+
+// LastAccess returns the time of the last access to the item with the given key.
+//
+// The time is tracked with the granularity of the configured time resolution. It returns false
+// if the item is not found or the cache does not track access times.
+func (c *Cache[K, V]) LastAccess(key K) (time.Time, bool) {
+	if !c.withLastAccess {
+		return time.Time{}, false
+	}
+
+	got, ok := c.hashmap.Get(key)
+	if !ok || !got.IsAlive() || got.IsExpired() {
+		return time.Time{}, false
+	}
+
+	return unixtime.ToTime(got.LastAccess()), true
+}
+
+// CreatedAt returns the time when the item with the given key was inserted into the cache.
+//
+// The time is tracked with the granularity of the configured time resolution. It returns false
+// if the item is not found or the cache does not track creation times.
+func (c *Cache[K, V]) CreatedAt(key K) (time.Time, bool) {
+	if !c.withCreatedAt {
+		return time.Time{}, false
+	}
 
-		c.readBuffers[idx].Free()
+	got, ok := c.hashmap.Get(key)
+	if !ok || !got.IsAlive() || got.IsExpired() {
+		return time.Time{}, false
 	}
+
+	return unixtime.ToTime(got.CreatedAt()), true
 }
 
 // Set associates the value with the key in this cache.
@@ -215,7 +716,7 @@ func (c *Cache[K, V]) Set(key K, value V) bool {
 	return c.set(key, value, c.defaultExpiration(), false)
 }
 
-func (c *Cache[K, V]) defaultExpiration() uint32 {
+func (c *Cache[K, V]) defaultExpiration() int64 {
 	if c.ttl == 0 {
 		return 0
 	}
@@ -249,41 +750,239 @@ func (c *Cache[K, V]) SetIfAbsentWithTTL(key K, value V, ttl time.Duration) bool
 	return c.set(key, value, getExpiration(ttl), true)
 }
 
-func (c *Cache[K, V]) set(key K, value V, expiration uint32, onlyIfAbsent bool) bool {
+// GetOrSet returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+//
+// If the value can't be stored because the cache is frozen or the cost of the item is too large,
+// then the given value is returned with false.
+func (c *Cache[K, V]) GetOrSet(key K, value V) (V, bool) {
+	return c.getOrSet(key, value, c.defaultExpiration())
+}
+
+// SetWithDeadline associates the value with the key in this cache and sets the absolute expiration time
+// for this key-value item.
+//
+// If it returns false, then the deadline has already passed or the key-value item had too much cost
+// and the SetWithDeadline was dropped.
+func (c *Cache[K, V]) SetWithDeadline(key K, value V, deadline time.Time) bool {
+	expiration := unixtime.FromTime(deadline)
+	if !deadline.After(time.Now()) || expiration < unixtime.Now() {
+		return false
+	}
+
+	return c.set(key, value, expiration, false)
+}
+
+// GetOrSetWithTTL is like GetOrSet, but sets the custom ttl for the stored item.
+func (c *Cache[K, V]) GetOrSetWithTTL(key K, value V, ttl time.Duration) (V, bool) {
+	return c.getOrSet(key, value, getExpiration(ttl))
+}
+
+func (c *Cache[K, V]) getOrSet(key K, value V, expiration int64) (V, bool) {
+	if c.isFrozen.Load() || c.isClosed.Load() {
+		if got, ok := c.Get(key); ok {
+			return got, true
+		}
+		return value, false
+	}
+
 	cost := c.costFunc(key, value)
 	if cost > c.policy.MaxAvailableCost() {
+		if got, ok := c.Get(key); ok {
+			return got, true
+		}
 		c.stats.IncRejectedSets()
-		return false
+		return value, false
 	}
 
-	n := c.nodeManager.Create(key, value, expiration, cost)
-	if onlyIfAbsent {
+	n := c.newNode(key, value, expiration, cost)
+	for {
 		res := c.hashmap.SetIfAbsent(n)
 		if res == nil {
 			// insert
-			c.writeBuffer.Push(newAddTask(n))
-			return true
+			c.pushWrite(newAddTask(n))
+			c.stats.IncMisses()
+			return value, false
+		}
+
+		if res.IsAlive() && !res.IsExpired() {
+			c.afterGet(res)
+			c.stats.IncHits()
+			return c.cloneValue(res.Value()), true
 		}
+
+		// the resident node is expired, so we replace it.
+		c.deleteNode(res)
+	}
+}
+
+func (c *Cache[K, V]) set(key K, value V, expiration int64, onlyIfAbsent bool) bool {
+	if c.withTrace {
+		defer startRegion("otter.Set").End()
+	}
+
+	// the writes after Close are dropped, because nobody applies them to the policies.
+	if c.isFrozen.Load() || c.isClosed.Load() {
+		return false
+	}
+
+	cost := c.costFunc(key, value)
+	if cost > c.policy.MaxAvailableCost() {
 		c.stats.IncRejectedSets()
 		return false
 	}
 
+	n := c.newNode(key, value, expiration, cost)
+	if onlyIfAbsent {
+		for {
+			res := c.hashmap.SetIfAbsent(n)
+			if res == nil {
+				// insert
+				c.pushWrite(newAddTask(n))
+				return true
+			}
+
+			if res.IsAlive() && !res.IsExpired() {
+				c.stats.IncRejectedSets()
+				return false
+			}
+
+			// the resident node is expired, so the key is logically absent and we replace the node.
+			c.deleteNode(res)
+		}
+	}
+
 	evicted := c.hashmap.Set(n)
 	if evicted != nil {
 		// update
 		evicted.Die()
-		c.writeBuffer.Push(newUpdateTask(n, evicted))
+		c.pushWrite(newUpdateTask(n, evicted))
 	} else {
 		// insert
-		c.writeBuffer.Push(newAddTask(n))
+		c.pushWrite(newAddTask(n))
 	}
 
 	return true
 }
 
+// Transact atomically reads the given keys, calls f with the snapshot of their live values and
+// writes the items returned by f. The concurrent modifications of these keys wait for the transaction.
+//
+// f can return only the given keys, otherwise nothing is written and ErrUnlockedKey is returned.
+// If the cost of any returned item is too large, nothing is written and ErrCostTooLarge is returned.
+// f must not call the methods of the cache, otherwise it can deadlock.
+func (c *Cache[K, V]) Transact(keys []K, f func(snapshot map[K]V) map[K]V) error {
+	if c.isFrozen.Load() {
+		return ErrFrozen
+	}
+
+	locked := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		locked[key] = struct{}{}
+	}
+
+	type write struct {
+		n    node.Node[K, V]
+		prev node.Node[K, V]
+	}
+	var (
+		writes []write
+		err    error
+	)
+	c.hashmap.Transact(keys, func(tx *hashtable.Tx[K, V]) {
+		snapshot := make(map[K]V, len(locked))
+		for key := range locked {
+			if got, ok := tx.Get(key); ok && got.IsAlive() && !got.IsExpired() {
+				snapshot[key] = c.cloneValue(got.Value())
+			}
+		}
+
+		result := f(snapshot)
+
+		nodes := make([]node.Node[K, V], 0, len(result))
+		for key, value := range result {
+			if _, ok := locked[key]; !ok {
+				err = ErrUnlockedKey
+				return
+			}
+			cost := c.costFunc(key, value)
+			if cost > c.policy.MaxAvailableCost() {
+				c.stats.IncRejectedSets()
+				err = ErrCostTooLarge
+				return
+			}
+			nodes = append(nodes, c.newNode(key, value, c.defaultExpiration(), cost))
+		}
+
+		writes = make([]write, 0, len(nodes))
+		for _, n := range nodes {
+			prev := tx.Set(n)
+			if prev != nil {
+				prev.Die()
+			}
+			writes = append(writes, write{n: n, prev: prev})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range writes {
+		if w.prev != nil {
+			c.pushWrite(newUpdateTask(w.n, w.prev))
+		} else {
+			c.pushWrite(newAddTask(w.n))
+		}
+	}
+	return nil
+}
+
 // Delete deletes the association for this key from the cache.
-func (c *Cache[K, V]) Delete(key K) {
+//
+// It returns ErrFrozen if the cache is in the read-only mode.
+func (c *Cache[K, V]) Delete(key K) error {
+	if c.withTrace {
+		defer startRegion("otter.Delete").End()
+	}
+
+	if c.isFrozen.Load() {
+		return ErrFrozen
+	}
+
 	c.afterDelete(c.hashmap.Delete(key))
+	return nil
+}
+
+// DeleteWithResult deletes the association for this key from the cache and
+// reports whether a live (not expired) entry was removed.
+func (c *Cache[K, V]) DeleteWithResult(key K) bool {
+	_, ok := c.deleteLive(key)
+	return ok
+}
+
+// GetAndDelete deletes the association for this key from the cache and
+// returns the value of the removed entry if it was live (not expired).
+func (c *Cache[K, V]) GetAndDelete(key K) (V, bool) {
+	deleted, ok := c.deleteLive(key)
+	if !ok {
+		return zeroValue[V](), false
+	}
+	return c.cloneValue(deleted.Value()), true
+}
+
+// deleteLive deletes the association for this key from the cache and returns the removed node
+// if it was live (not expired).
+func (c *Cache[K, V]) deleteLive(key K) (node.Node[K, V], bool) {
+	if c.isFrozen.Load() {
+		return nil, false
+	}
+
+	deleted := c.hashmap.Delete(key)
+	c.afterDelete(deleted)
+	if deleted == nil || deleted.IsExpired() {
+		return nil, false
+	}
+	return deleted, true
 }
 
 func (c *Cache[K, V]) deleteNode(n node.Node[K, V]) {
@@ -293,12 +992,18 @@ func (c *Cache[K, V]) deleteNode(n node.Node[K, V]) {
 func (c *Cache[K, V]) afterDelete(deleted node.Node[K, V]) {
 	if deleted != nil {
 		deleted.Die()
-		c.writeBuffer.Push(newDeleteTask(deleted))
+		c.pushWrite(newDeleteTask(deleted))
 	}
 }
 
 // DeleteByFunc deletes the association for this key from the cache when the given function returns true.
-func (c *Cache[K, V]) DeleteByFunc(f func(key K, value V) bool) {
+//
+// It returns ErrFrozen if the cache is in the read-only mode.
+func (c *Cache[K, V]) DeleteByFunc(f func(key K, value V) bool) error {
+	if c.isFrozen.Load() {
+		return ErrFrozen
+	}
+
 	c.hashmap.Range(func(n node.Node[K, V]) bool {
 		if !n.IsAlive() || n.IsExpired() {
 			return true
@@ -310,6 +1015,20 @@ func (c *Cache[K, V]) DeleteByFunc(f func(key K, value V) bool) {
 
 		return true
 	})
+	return nil
+}
+
+// lockEvictionMutex acquires the eviction mutex and counts the attempts that have to wait for it.
+func (c *Cache[K, V]) lockEvictionMutex() {
+	if c.evictionMutex.TryLock() {
+		return
+	}
+
+	c.stats.IncLockContention()
+	if c.withTrace {
+		defer startRegion("otter.EvictionMutexWait").End()
+	}
+	c.evictionMutex.Lock()
 }
 
 func (c *Cache[K, V]) notifyDeletion(key K, value V, cause DeletionCause) {
@@ -320,61 +1039,296 @@ func (c *Cache[K, V]) notifyDeletion(key K, value V, cause DeletionCause) {
 	c.deletionListener(key, value, cause)
 }
 
+// appendDeleted appends the deleted node to the batch if the batch listener is set.
+func (c *Cache[K, V]) appendDeleted(batch []DeletedEntry[K, V], n node.Node[K, V], cause DeletionCause) []DeletedEntry[K, V] {
+	if c.deletionBatchListener == nil {
+		return batch
+	}
+
+	return append(batch, DeletedEntry[K, V]{
+		Key:   n.Key(),
+		Value: n.Value(),
+		Cause: cause,
+	})
+}
+
+func (c *Cache[K, V]) notifyDeletionBatch(batch []DeletedEntry[K, V]) {
+	if len(batch) == 0 {
+		return
+	}
+
+	c.deletionBatchListener(batch)
+}
+
+func (c *Cache[K, V]) notifySet(key K, oldValue V, newValue V, replaced bool) {
+	if c.setListener == nil {
+		return
+	}
+
+	c.setListener(key, oldValue, newValue, replaced)
+}
+
+// labelGoroutine labels the current goroutine with the name of the cache, so that the goroutines
+// of the different caches can be told apart in the profiles.
+func (c *Cache[K, V]) labelGoroutine() {
+	if c.name == "" {
+		return
+	}
+
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("otter_cache", c.name)))
+}
+
+// bindGoroutine locks the current goroutine to its OS thread and binds the thread to the configured CPUs.
+// If the binding fails, the goroutine is unlocked and keeps running on any CPU, and the failure
+// is reported by Health.
+func (c *Cache[K, V]) bindGoroutine() {
+	if len(c.cpuAffinity) == 0 {
+		return
+	}
+
+	runtime.LockOSThread()
+	if err := xruntime.SetThreadAffinity(c.cpuAffinity); err != nil {
+		runtime.UnlockOSThread()
+		c.affinityFailed.Store(true)
+	}
+}
+
+// cleanup periodically removes the expired nodes to reclaim memory. The reads don't rely on it,
+// because they check the expiration of each node themselves.
 func (c *Cache[K, V]) cleanup() {
+	c.labelGoroutine()
+	c.bindGoroutine()
 	bufferCapacity := 64
 	expired := make([]node.Node[K, V], 0, bufferCapacity)
+	interval := c.maxCleanupInterval
 	for {
-		time.Sleep(time.Second)
+		time.Sleep(interval)
 
-		c.evictionMutex.Lock()
-		if c.isClosed {
+		start := time.Now()
+		var ok bool
+		expired, ok = c.removeExpired(expired, c.cleanupBatchSize)
+		if !ok {
 			return
 		}
-
-		expired = c.expirePolicy.RemoveExpired(expired)
-		for _, n := range expired {
-			c.policy.Delete(n)
-		}
-
-		c.evictionMutex.Unlock()
-
-		for _, n := range expired {
-			c.hashmap.DeleteNode(n)
-			n.Die()
-			c.notifyDeletion(n.Key(), n.Value(), Expired)
+		c.stats.RecordCleanup(time.Since(start))
+		c.notifyPreExpiry()
+
+		interval = c.cleanupInterval(len(expired))
+		if len(expired) == c.cleanupBatchSize {
+			// the batch is full, so more expired items may remain. Yield and continue without sleeping.
+			interval = 0
+			runtime.Gosched()
 		}
-
 		expired = clearBuffer(expired)
+		c.cleanupHeartbeat.Store(time.Now().UnixNano())
 		if cap(expired) > 3*bufferCapacity {
 			expired = make([]node.Node[K, V], 0, bufferCapacity)
 		}
 	}
 }
 
-func (c *Cache[K, V]) process() {
-	bufferCapacity := 64
-	buffer := make([]task[K, V], 0, bufferCapacity)
-	deleted := make([]node.Node[K, V], 0, bufferCapacity)
-	i := 0
-	for {
-		t := c.writeBuffer.Pop()
-
-		if t.isClear() || t.isClose() {
-			buffer = clearBuffer(buffer)
-			c.writeBuffer.Clear()
+// cleanupInterval returns the interval before the next cleanup cycle. The more items have expired
+// in the last cycle, the shorter the interval, so that the expired items don't linger under the high expiry rate.
+func (c *Cache[K, V]) cleanupInterval(expired int) time.Duration {
+	interval := c.maxCleanupInterval / time.Duration(1+expired/cleanupExpiredThreshold)
+	if interval < c.minCleanupInterval {
+		return c.minCleanupInterval
+	}
+	return interval
+}
 
-			c.evictionMutex.Lock()
-			c.policy.Clear()
-			c.expirePolicy.Clear()
-			if t.isClose() {
-				c.isClosed = true
-			}
-			c.evictionMutex.Unlock()
+// notifyPreExpiry calls the pre-expiry callback for the live items that expire within the lead time
+// and have not been notified by the previous cleanup cycles, so each item is notified at most once.
+//
+// An item is notified when its expiration enters the lead window. The items with a ttl shorter than the lead
+// are already within the window when they are inserted, so they are notified by the first scan after the insertion.
+func (c *Cache[K, V]) notifyPreExpiry() {
+	if c.preExpiryCallback == nil {
+		return
+	}
 
-			c.doneClear <- struct{}{}
-			if t.isClose() {
-				break
+	// the buffered writes are applied first, so that the expire policy knows about the new items.
+	c.flush()
+
+	now := unixtime.Now()
+	from := c.preExpiryNotified
+	scanned := c.preExpiryScanned
+	to := now + c.preExpiryLead
+	c.preExpiryNotified = to
+	c.preExpiryScanned = now
+
+	// the expire policy is guarded by the eviction mutex, but the callback must be called without it.
+	var expiring []node.Node[K, V]
+	c.lockEvictionMutex()
+	c.expirePolicy.ForEachExpiringBefore(to, func(n node.Node[K, V]) {
+		if n.Expiration() > from || n.CreatedAt() > scanned {
+			expiring = append(expiring, n)
+		}
+	})
+	c.unlockEvictionMutex()
+
+	for _, n := range expiring {
+		if n.IsAlive() && !n.IsExpired() {
+			c.preExpiryCallback(n.Key(), n.Value())
+		}
+	}
+}
+
+// removeExpired removes at most limit expired nodes from the cache and appends them to expired.
+// It returns false if the cache is closed.
+func (c *Cache[K, V]) removeExpired(expired []node.Node[K, V], limit int) ([]node.Node[K, V], bool) {
+	if c.cleanupConcurrency <= 1 {
+		start := len(expired)
+		var ok bool
+		expired, ok = c.removeExpiredPartition(expired, limit, 0, 1)
+		if ok {
+			c.deleteExpired(expired[start:])
+		}
+		return expired, ok
+	}
+
+	// each goroutine walks its own partition of the expire policy. The goroutines take turns holding
+	// the eviction mutex, so it is released between the partitions, and delete their nodes
+	// from the hash table in parallel without it.
+	total := c.cleanupConcurrency
+	partitions := make([][]node.Node[K, V], total)
+	var (
+		wg     sync.WaitGroup
+		closed atomic.Bool
+	)
+	for i := 0; i < total; i++ {
+		// the limit is split between the partitions, the first ones get the remainder.
+		partitionLimit := limit / total
+		if i < limit%total {
+			partitionLimit++
+		}
+
+		wg.Add(1)
+		go func(partition, partitionLimit int) {
+			defer wg.Done()
+			removed, ok := c.removeExpiredPartition(nil, partitionLimit, partition, total)
+			if !ok {
+				closed.Store(true)
+				return
 			}
+			c.deleteExpired(removed)
+			partitions[partition] = removed
+		}(i, partitionLimit)
+	}
+	wg.Wait()
+
+	for _, removed := range partitions {
+		expired = append(expired, removed...)
+	}
+	return expired, !closed.Load()
+}
+
+// removeExpiredPartition removes at most limit expired nodes of the partition of the expire policy
+// from the policies and appends them to expired. It returns false if the cache is closed.
+func (c *Cache[K, V]) removeExpiredPartition(
+	expired []node.Node[K, V],
+	limit, partition, total int,
+) ([]node.Node[K, V], bool) {
+	c.lockEvictionMutex()
+	defer c.unlockEvictionMutex()
+
+	if c.isClosed.Load() {
+		return expired, false
+	}
+
+	start := len(expired)
+	expired = c.expirePolicy.RemoveExpiredPartition(expired, limit, partition, total)
+	for _, n := range expired[start:] {
+		c.deleteFromPolicy(n)
+		c.dropPins(n)
+	}
+	return expired, true
+}
+
+// deleteExpired deletes the expired nodes removed from the policies from the hash table and notifies the listeners.
+func (c *Cache[K, V]) deleteExpired(expired []node.Node[K, V]) {
+	var batch []DeletedEntry[K, V]
+	for _, n := range expired {
+		// the node may have been deleted or replaced concurrently, then it is not an expiration.
+		if node.Equals(c.hashmap.DeleteNode(n), nil) {
+			continue
+		}
+		n.Die()
+		batch = c.notifyExpired(batch, n)
+	}
+	c.notifyDeletionBatch(batch)
+}
+
+// notifyExpired notifies the listener about the expired node and appends it to the batch.
+func (c *Cache[K, V]) notifyExpired(batch []DeletedEntry[K, V], n node.Node[K, V]) []DeletedEntry[K, V] {
+	c.notifyDeletion(n.Key(), n.Value(), Expired)
+	c.stats.IncExpiredCount()
+	return c.appendDeleted(batch, n, Expired)
+}
+
+// PurgeExpired applies the buffered writes, removes all expired items from the cache and returns their number.
+func (c *Cache[K, V]) PurgeExpired() int {
+	c.flush()
+	expired, _ := c.removeExpired(nil, math.MaxInt)
+	return len(expired)
+}
+
+// pushWrite pushes the write task to the write buffer.
+func (c *Cache[K, V]) pushWrite(t task[K, V]) {
+	if c.trackWriteLatency {
+		t.enqueuedAt = time.Now().UnixNano()
+	}
+	c.writeBuffer.Push(t)
+}
+
+func (c *Cache[K, V]) process() {
+	c.labelGoroutine()
+	c.bindGoroutine()
+	bufferCapacity := 64
+	buffer := make([]task[K, V], 0, bufferCapacity)
+	deleted := make([]node.Node[K, V], 0, minDeletedBufferCapacity)
+	i := 0
+	for {
+		t := c.writeBuffer.Pop()
+		if t.enqueuedAt != 0 {
+			c.stats.RecordWriteLatency(time.Duration(time.Now().UnixNano() - t.enqueuedAt))
+		}
+
+		if t.isClose() {
+			buffer = clearBuffer(buffer)
+			c.writeBuffer.Clear()
+
+			c.lockEvictionMutex()
+			c.policy.Clear()
+			c.expirePolicy.Clear()
+			c.isClosed.Store(true)
+			c.unlockEvictionMutex()
+
+			close(c.doneClose)
+			c.doneClear <- struct{}{}
+			break
+		}
+
+		if t.isClear() {
+			// the nodes of the old table have already been removed from the policies by Clear,
+			// so the buffered tasks are applied as usual: the tasks of the removed nodes are ignored.
+			deleted = c.applyTasks(buffer, deleted)
+			buffer = clearBuffer(buffer)
+			i = 0
+
+			c.doneClear <- struct{}{}
+			continue
+		}
+
+		if !c.isWarm && (t.isAdd() || t.isUpdate()) {
+			c.checkWarmUp()
+		}
+
+		if t.isFlush() {
+			deleted = c.applyTasks(buffer, deleted)
+			buffer = clearBuffer(buffer)
+			i = 0
+			close(t.done)
 			continue
 		}
 
@@ -383,62 +1337,222 @@ func (c *Cache[K, V]) process() {
 		if i >= bufferCapacity {
 			i -= bufferCapacity
 
-			c.evictionMutex.Lock()
-
-			for _, t := range buffer {
-				n := t.node()
-				switch {
-				case t.isDelete():
-					c.expirePolicy.Delete(n)
-					c.policy.Delete(n)
-				case t.isAdd():
-					if n.IsAlive() {
-						c.expirePolicy.Add(n)
-						deleted = c.policy.Add(deleted, n)
-					}
-				case t.isUpdate():
-					oldNode := t.oldNode()
-					c.expirePolicy.Delete(oldNode)
-					c.policy.Delete(oldNode)
-					if n.IsAlive() {
-						c.expirePolicy.Add(n)
-						deleted = c.policy.Add(deleted, n)
-					}
-				}
-			}
+			deleted = c.applyTasks(buffer, deleted)
+			buffer = clearBuffer(buffer)
+		}
+	}
+}
+
+// applyTasks applies the buffered write tasks to the policies, notifies the listeners and
+// evicts the nodes if needed. It returns the cleared buffer of the deleted nodes.
+func (c *Cache[K, V]) applyTasks(buffer []task[K, V], deleted []node.Node[K, V]) []node.Node[K, V] {
+	if len(buffer) == 0 {
+		return deleted
+	}
 
-			for _, n := range deleted {
+	c.processBusySince.Store(time.Now().UnixNano())
+	c.lockEvictionMutex()
+	c.applyPendingReads()
+
+	var expired []node.Node[K, V]
+	for _, t := range buffer {
+		n := t.node()
+		switch {
+		case t.isDelete():
+			c.expirePolicy.Delete(n)
+			c.deleteFromPolicy(n)
+			c.dropPins(n)
+		case t.isExpire():
+			// the node may have been deleted, replaced or removed by the cleanup since the read.
+			if !node.Equals(c.hashmap.DeleteNode(n), nil) {
+				n.Die()
 				c.expirePolicy.Delete(n)
+				c.deleteFromPolicy(n)
+				c.dropPins(n)
+				expired = append(expired, n)
 			}
-
-			c.evictionMutex.Unlock()
-
-			for _, t := range buffer {
-				switch {
-				case t.isDelete():
-					n := t.node()
-					c.notifyDeletion(n.Key(), n.Value(), Explicit)
-				case t.isUpdate():
-					n := t.oldNode()
-					c.notifyDeletion(n.Key(), n.Value(), Replaced)
-				}
+		case t.isAdd():
+			if n.IsAlive() && !c.isTracked(n) {
+				c.expirePolicy.Add(n)
+				deleted = c.addToPolicy(deleted, n)
+				c.stats.RecordInsertion(n.Cost())
 			}
-
-			for _, n := range deleted {
-				c.hashmap.DeleteNode(n)
-				n.Die()
-				c.notifyDeletion(n.Key(), n.Value(), Size)
-				c.stats.IncEvictedCount()
-				c.stats.AddEvictedCost(n.Cost())
+		case t.isUpdate():
+			oldNode := t.oldNode()
+			c.expirePolicy.Delete(oldNode)
+			c.deleteFromPolicy(oldNode)
+			if n.IsAlive() && !c.isTracked(n) {
+				c.expirePolicy.Add(n)
+				deleted = c.addToPolicy(deleted, n)
+				c.stats.RecordInsertion(n.Cost())
 			}
+		}
+	}
 
-			buffer = clearBuffer(buffer)
-			deleted = clearBuffer(deleted)
-			if cap(deleted) > 3*bufferCapacity {
-				deleted = make([]node.Node[K, V], 0, bufferCapacity)
-			}
+	if !c.dryRun {
+		for _, n := range deleted {
+			c.expirePolicy.Delete(n)
+		}
+	}
+
+	c.unlockEvictionMutex()
+
+	var batch []DeletedEntry[K, V]
+	for _, t := range buffer {
+		switch {
+		case t.isDelete():
+			n := t.node()
+			c.notifyDeletion(n.Key(), n.Value(), Explicit)
+			batch = c.appendDeleted(batch, n, Explicit)
+		case t.isAdd():
+			n := t.node()
+			c.notifySet(n.Key(), zeroValue[V](), n.Value(), false)
+		case t.isUpdate():
+			n := t.oldNode()
+			c.notifyDeletion(n.Key(), n.Value(), Replaced)
+			c.notifySet(n.Key(), n.Value(), t.node().Value(), true)
+			batch = c.appendDeleted(batch, n, Replaced)
+		}
+	}
+	for _, n := range expired {
+		batch = c.notifyExpired(batch, n)
+	}
+
+	deleted = c.evictNodes(deleted, batch)
+	c.countWriteBufferOverflow()
+	c.processBusySince.Store(0)
+	return deleted
+}
+
+// countWriteBufferOverflow increments the overflow counter if the write buffer is almost full.
+func (c *Cache[K, V]) countWriteBufferOverflow() {
+	if c.stats == nil {
+		return
+	}
+
+	if current, capacity := c.WriteBufferUsage(); current*100 > capacity*writeBufferOverflowPercent {
+		c.stats.IncWriteBufferOverflows()
+	}
+}
+
+// AdmissionStats returns the number of entries added to the eviction policy and the number of entries rejected
+// by its admission filter. It returns zeros if the stats are disabled or the policy doesn't filter the entries.
+func (c *Cache[K, V]) AdmissionStats() (added, rejected int64) {
+	ap, ok := c.policy.(admissionPolicy)
+	if c.stats == nil || !ok {
+		return 0, 0
+	}
+
+	c.evictionMutex.Lock()
+	a, r := ap.AdmissionStats()
+	c.unlockEvictionMutex()
+	return int64(a), int64(r)
+}
+
+// Resizes returns the number of times the hash table has been grown and shrunk.
+// It returns zeros if the stats are disabled.
+func (c *Cache[K, V]) Resizes() (grows, shrinks int64) {
+	if c.stats == nil {
+		return 0, 0
+	}
+
+	return c.hashmap.Resizes()
+}
+
+// PolicyStats returns the current number of entries in the small and main queues of the eviction policy
+// and the number of keys remembered by its ghost queue. It returns zeros if the policy has no queues.
+func (c *Cache[K, V]) PolicyStats() (small, main, ghost int) {
+	qp, ok := c.policy.(queuePolicy)
+	if !ok {
+		return 0, 0, 0
+	}
+
+	c.evictionMutex.Lock()
+	defer c.unlockEvictionMutex()
+	return qp.QueueSizes()
+}
+
+// ReadBufferDrops returns the number of reads that were not applied to the eviction policy,
+// because the read buffers were full or contended.
+func (c *Cache[K, V]) ReadBufferDrops() int64 {
+	var dropped int64
+	for _, rb := range c.readBuffers {
+		dropped += rb.DroppedCount()
+	}
+	return dropped
+}
+
+// WriteBufferUsage returns the number of the queued write tasks and the capacity of the write buffer.
+func (c *Cache[K, V]) WriteBufferUsage() (current, capacity int) {
+	return c.writeBuffer.Len(), c.writeBuffer.Cap()
+}
+
+// flush waits until all the write tasks pushed before the call are applied to the policies.
+//
+// It returns without waiting if the cache is closed concurrently, because the process goroutine
+// drops the buffered tasks when it stops.
+func (c *Cache[K, V]) flush() {
+	if c.isClosed.Load() {
+		return
+	}
+
+	done := make(chan struct{})
+	c.writeBuffer.Push(newFlushTask[K, V](done))
+	select {
+	case <-done:
+	case <-c.doneClose:
+	}
+}
+
+// checkWarmUp closes the warm-up channel once the cache is filled to the warm-up threshold.
+//
+// NOTE: it should be called only from the process goroutine.
+func (c *Cache[K, V]) checkWarmUp() {
+	if float64(c.hashmap.Size()) >= c.warmUpThreshold*float64(c.capacity) {
+		c.isWarm = true
+		close(c.warmUpDone)
+	}
+}
+
+// evictNodes deletes the nodes evicted by the policy from the hash table, notifies the batch listener
+// about them and the already collected deletions in batch and returns the cleared buffer.
+func (c *Cache[K, V]) evictNodes(deleted []node.Node[K, V], batch []DeletedEntry[K, V]) []node.Node[K, V] {
+	for _, n := range deleted {
+		if c.dryRun {
+			c.dryRunCount.Add(1)
+			c.dryRunCost.Add(int64(n.Cost()))
+			continue
 		}
+
+		batch, _ = c.evictNode(n, batch)
+	}
+	c.notifyDeletionBatch(batch)
+
+	deleted = clearBuffer(deleted)
+	if cap(deleted) > 3*minDeletedBufferCapacity {
+		deleted = make([]node.Node[K, V], 0, minDeletedBufferCapacity)
+	}
+	return deleted
+}
+
+// evictNode deletes the evicted node from the hash table and notifies about the eviction.
+// It returns false if the node has already been deleted or replaced, so it is not an eviction.
+func (c *Cache[K, V]) evictNode(n node.Node[K, V], batch []DeletedEntry[K, V]) ([]DeletedEntry[K, V], bool) {
+	if node.Equals(c.hashmap.DeleteNode(n), nil) {
+		return batch, false
 	}
+
+	n.Die()
+	c.notifyDeletion(n.Key(), n.Value(), Size)
+	batch = c.appendDeleted(batch, n, Size)
+	c.stats.IncEvictedCount()
+	c.stats.AddEvictedCost(n.Cost())
+	return batch, true
+}
+
+// isInPolicy returns true if the node has already been added to the eviction policy.
+func isInPolicy[K comparable, V any](n node.Node[K, V]) bool {
+	return n.IsSmall() || n.IsMain()
 }
 
 // Range iterates over all items in the cache.
@@ -450,24 +1564,249 @@ func (c *Cache[K, V]) Range(f func(key K, value V) bool) {
 			return true
 		}
 
-		return f(n.Key(), n.Value())
+		return f(n.Key(), c.cloneValue(n.Value()))
 	})
 }
 
+// RangeKeys iterates over the keys of all items in the cache without reading their values.
+//
+// Iteration stops early when the given function returns false.
+func (c *Cache[K, V]) RangeKeys(f func(key K) bool) {
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		return f(n.Key())
+	})
+}
+
+// RangePage calls f for at most limit items in the cache starting from the position encoded by cursor,
+// and returns the cursor to resume the iteration from. The zero cursor is the start of the iteration,
+// and the zero cursor is returned when it is complete.
+//
+// If limit is not positive, RangePage doesn't call f and returns the given cursor.
+func (c *Cache[K, V]) RangePage(cursor uint64, limit int, f func(key K, value V)) uint64 {
+	if limit <= 0 {
+		return cursor
+	}
+
+	count := 0
+	return c.hashmap.Scan(cursor, func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		f(n.Key(), c.cloneValue(n.Value()))
+		count++
+		return count < limit
+	})
+}
+
+// RangeWithExpiration iterates over all items in the cache and passes their expiration time to f.
+// The expiration time is zero if the cache doesn't expire the items or the item has no ttl.
+//
+// Iteration stops early when the given function returns false.
+func (c *Cache[K, V]) RangeWithExpiration(f func(key K, value V, expiresAt time.Time) bool) {
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		var expiresAt time.Time
+		if c.withExpiration && n.Expiration() > 0 {
+			expiresAt = unixtime.ToTime(n.Expiration())
+		}
+		return f(n.Key(), c.cloneValue(n.Value()), expiresAt)
+	})
+}
+
+// Verify checks the internal invariants of the cache and returns an error describing the first violated one.
+//
+// It applies all the buffered writes, acquires the eviction mutex and walks all the internal structures,
+// so it is O(n) and is intended only for tests and debugging. The result is reliable only if there are
+// no concurrent writes.
+func (c *Cache[K, V]) Verify() error {
+	c.flush()
+
+	c.evictionMutex.Lock()
+	defer c.unlockEvictionMutex()
+
+	var (
+		count      int
+		live       int
+		expirable  int
+		policyCost uint64
+		err        error
+	)
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		count++
+		if !n.IsAlive() {
+			err = fmt.Errorf("dead node in the hash table: %v", n.Key())
+			return false
+		}
+		live++
+		if c.withExpiration && n.Expiration() > 0 {
+			expirable++
+		}
+		if isInPolicy(n) {
+			policyCost += uint64(n.Cost())
+		} else if !c.dryRun && !c.isParked(n) {
+			err = fmt.Errorf("node is not in the eviction policy: %v", n.Key())
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if size := c.hashmap.Size(); size != count {
+		return fmt.Errorf("hash table size mismatch: size %d, nodes %d", size, count)
+	}
+	if cost := c.policy.Cost(); uint64(cost) != policyCost {
+		return fmt.Errorf("eviction policy cost mismatch: policy %d, nodes %d", cost, policyCost)
+	}
+	if !c.dryRun && policyCost > uint64(c.capacity) {
+		return fmt.Errorf("total cost %d exceeds the capacity %d", policyCost, c.capacity)
+	}
+
+	expiring := 0
+	c.expirePolicy.ForEach(func(n node.Node[K, V]) {
+		if err == nil && !n.IsAlive() {
+			err = fmt.Errorf("dead node in the expiration policy: %v", n.Key())
+		}
+		expiring++
+	})
+	if err != nil {
+		return err
+	}
+	if c.withExpiration && !c.dryRun && expiring != expirable {
+		return fmt.Errorf("expiration policy size mismatch: policy %d, nodes %d", expiring, expirable)
+	}
+
+	return nil
+}
+
+// Merge copies all live items of other into the cache resolving the conflicts according to the policy.
+// The items keep their expiration time if the cache has a variable ttl, otherwise they get the default ttl.
+//
+// It returns the number of copied items.
+func (c *Cache[K, V]) Merge(other *Cache[K, V], conflict ConflictPolicy) int {
+	keepTTL := c.withExpiration && c.ttl == 0 && other.withExpiration
+	merged := 0
+	other.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		expiration := c.defaultExpiration()
+		if keepTTL {
+			expiration = n.Expiration()
+		}
+
+		var ok bool
+		switch conflict {
+		case ConflictKeepExisting:
+			ok = c.set(n.Key(), n.Value(), expiration, true)
+		case ConflictKeepNewerTTL:
+			if existing, found := c.hashmap.Get(n.Key()); found && existing.IsAlive() && !existing.IsExpired() &&
+				!expiresBefore(existing, expiration, c.withExpiration) {
+				return true
+			}
+			ok = c.set(n.Key(), n.Value(), expiration, false)
+		default:
+			ok = c.set(n.Key(), n.Value(), expiration, false)
+		}
+		if ok {
+			merged++
+		}
+		return true
+	})
+	return merged
+}
+
+// expiresBefore reports whether the node expires before the expiration. Zero expiration means no expiration.
+func expiresBefore[K comparable, V any](n node.Node[K, V], expiration int64, withExpiration bool) bool {
+	if !withExpiration || n.Expiration() == 0 {
+		return false
+	}
+	return expiration == 0 || n.Expiration() < expiration
+}
+
+// CopyTo copies all live items of the cache into dst. The items are copied with the cost function of dst.
+// If keepTTL is true, then the items keep their expiration time, otherwise they get the default ttl of dst.
+//
+// It returns the number of copied items. The items that don't fit into dst are evicted by its policy.
+func (c *Cache[K, V]) CopyTo(dst *Cache[K, V], keepTTL bool) int {
+	copied := 0
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		expiration := dst.defaultExpiration()
+		if keepTTL && c.withExpiration {
+			expiration = n.Expiration()
+		}
+		if dst.set(n.Key(), n.Value(), expiration, false) {
+			copied++
+		}
+		return true
+	})
+	return copied
+}
+
+// Freeze switches the cache to the read-only mode. All subsequent write operations will be dropped
+// until Unfreeze is called. Expiration and eviction continue to work in the read-only mode.
+//
+// It returns false if the cache is already frozen.
+func (c *Cache[K, V]) Freeze() bool {
+	return c.isFrozen.CompareAndSwap(false, true)
+}
+
+// Unfreeze switches the cache back to the normal mode.
+func (c *Cache[K, V]) Unfreeze() {
+	c.isFrozen.Store(false)
+}
+
+// IsFrozen returns true if the cache is in the read-only mode.
+func (c *Cache[K, V]) IsFrozen() bool {
+	return c.isFrozen.Load()
+}
+
+// IsClosed returns true if the cache has been closed.
+func (c *Cache[K, V]) IsClosed() bool {
+	return c.isClosed.Load()
+}
+
 // Clear clears the hash table, all policies, buffers, etc.
 //
-// NOTE: this operation must be performed when no requests are made to the cache otherwise the behavior is undefined.
+// It is safe to call Clear concurrently with other operations. The hash table is replaced with an empty one
+// atomically, so in-flight reads complete against the old entries and new reads hit the empty table.
+// The old entries are then removed from the policies one by one, so the concurrent writes of them are ignored.
 func (c *Cache[K, V]) Clear() {
-	c.clear(newClearTask[K, V]())
-}
+	var cleared []node.Node[K, V]
+	c.hashmap.ClearAndRange(func(n node.Node[K, V]) {
+		n.Die()
+		cleared = append(cleared, n)
+	})
 
-func (c *Cache[K, V]) clear(t task[K, V]) {
-	c.hashmap.Clear()
+	// the nodes are removed from the policies one by one, so that the tasks of these nodes
+	// still in the write buffer find them already removed and don't corrupt the policies.
+	c.lockEvictionMutex()
+	for _, n := range cleared {
+		c.expirePolicy.Delete(n)
+		c.deleteFromPolicy(n)
+	}
+	c.pins = nil
+	c.parked = nil
+	c.unlockEvictionMutex()
 	for i := 0; i < len(c.readBuffers); i++ {
 		c.readBuffers[i].Clear()
 	}
 
-	c.writeBuffer.Push(t)
+	c.writeBuffer.Push(newClearTask[K, V]())
 	<-c.doneClear
 
 	c.stats.Clear()
@@ -478,18 +1817,237 @@ func (c *Cache[K, V]) clear(t task[K, V]) {
 // NOTE: this operation must be performed when no requests are made to the cache otherwise the behavior is undefined.
 func (c *Cache[K, V]) Close() {
 	c.closeOnce.Do(func() {
-		c.clear(newCloseTask[K, V]())
-		if c.withExpiration {
-			unixtime.Stop()
+		c.hashmap.Clear()
+		for i := 0; i < len(c.readBuffers); i++ {
+			c.readBuffers[i].Clear()
+		}
+
+		c.writeBuffer.Push(newCloseTask[K, V]())
+		<-c.doneClear
+
+		c.stats.Clear()
+		if c.withUnixtime() {
+			unixtime.StopWithResolution(c.timeResolution)
 		}
 	})
 }
 
+// ExpiringWithin returns the approximate number of live items that will expire within the given duration.
+//
+// Items that have been written recently may not yet be taken into account.
+func (c *Cache[K, V]) ExpiringWithin(d time.Duration) int {
+	if !c.withExpiration {
+		return 0
+	}
+
+	deadline := getExpiration(d)
+
+	c.evictionMutex.Lock()
+	count := c.expirePolicy.ExpiringBefore(deadline)
+	c.unlockEvictionMutex()
+
+	return count
+}
+
+// TTLDistribution returns a histogram of the remaining ttl of the live items in the cache.
+//
+// The keys of the histogram are the lower bounds of the buckets: 0, 1s, 10s, 1m and 5m.
+func (c *Cache[K, V]) TTLDistribution() map[time.Duration]int {
+	distribution := make(map[time.Duration]int, len(ttlDistributionBounds))
+	if !c.withExpiration {
+		return distribution
+	}
+
+	now := unixtime.Now()
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		// the items without a ttl are not counted.
+		if !n.IsAlive() || n.IsExpired() || n.Expiration() == 0 {
+			return true
+		}
+
+		// the item may expire between the check and the subtraction.
+		remaining := time.Duration(n.Expiration() - now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		idx := sort.Search(len(ttlDistributionBounds), func(i int) bool {
+			return ttlDistributionBounds[i] > remaining
+		})
+		distribution[ttlDistributionBounds[idx-1]]++
+		return true
+	})
+
+	return distribution
+}
+
+// CostDistribution returns a histogram of the costs of the live items in the cache.
+//
+// The i-th bucket contains the number of items with cost in [2^i, 2^(i+1)),
+// the last bucket contains the number of items with cost >= 2^(CostDistributionBuckets-1).
+func (c *Cache[K, V]) CostDistribution() []int {
+	distribution := make([]int, CostDistributionBuckets)
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+
+		idx := bits.Len32(n.Cost()) - 1
+		if idx < 0 {
+			idx = 0
+		} else if idx >= CostDistributionBuckets {
+			idx = CostDistributionBuckets - 1
+		}
+		distribution[idx]++
+		return true
+	})
+
+	return distribution
+}
+
+// TopN returns at most n keys with the highest estimated access frequency in descending order.
+//
+// It holds the eviction lock while iterating over all items, so it's an expensive O(n log n) operation.
+func (c *Cache[K, V]) TopN(n int) []KeyFrequency[K] {
+	if n <= 0 {
+		return nil
+	}
+
+	result := make([]KeyFrequency[K], 0, c.hashmap.Size())
+	c.evictionMutex.Lock()
+	c.hashmap.Range(func(got node.Node[K, V]) bool {
+		if !got.IsAlive() || got.IsExpired() {
+			return true
+		}
+
+		result = append(result, KeyFrequency[K]{
+			Key:       got.Key(),
+			Frequency: int(got.Frequency()),
+		})
+		return true
+	})
+	c.unlockEvictionMutex()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Frequency > result[j].Frequency
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// NextEvictions returns at most n entries that are most likely to be evicted next according to the current
+// state of the eviction policy. The policy is not modified.
+func (c *Cache[K, V]) NextEvictions(n int) []EvictionCandidate[K, V] {
+	c.evictionMutex.Lock()
+	defer c.unlockEvictionMutex()
+
+	nodes := c.policy.NextEvictions(n)
+	result := make([]EvictionCandidate[K, V], 0, len(nodes))
+	for _, got := range nodes {
+		result = append(result, EvictionCandidate[K, V]{
+			Key:   got.Key(),
+			Value: c.cloneValue(got.Value()),
+			Cost:  got.Cost(),
+		})
+	}
+	return result
+}
+
+// NextVictim applies the buffered writes and returns the key of the entry that the policy would evict next.
+// It returns false if the policy is empty.
+func (c *Cache[K, V]) NextVictim() (K, bool) {
+	c.flush()
+
+	candidates := c.NextEvictions(1)
+	if len(candidates) == 0 {
+		return zeroValue[K](), false
+	}
+	return candidates[0].Key, true
+}
+
+// Evict applies the buffered writes and evicts at most n live entries in the order of the eviction policy
+// regardless of the capacity and the frequencies of the entries. It returns the number of the evicted entries.
+func (c *Cache[K, V]) Evict(n int) int {
+	if n <= 0 || c.isClosed.Load() {
+		return 0
+	}
+
+	c.flush()
+
+	victims := c.evictVictims(n)
+	if c.dryRun {
+		evicted := len(victims)
+		c.evictNodes(victims, nil)
+		return evicted
+	}
+
+	evicted := 0
+	var batch []DeletedEntry[K, V]
+	for _, victim := range victims {
+		var ok bool
+		// the victim may have been deleted concurrently, then it is not an eviction.
+		if batch, ok = c.evictNode(victim, batch); ok {
+			evicted++
+		}
+	}
+	c.notifyDeletionBatch(batch)
+	return evicted
+}
+
+// evictVictims removes at most n live nodes from the policies in the order of eviction.
+func (c *Cache[K, V]) evictVictims(n int) []node.Node[K, V] {
+	c.evictionMutex.Lock()
+	defer c.unlockEvictionMutex()
+
+	victims := c.policy.Evict(nil, n)
+	if !c.dryRun {
+		for _, victim := range victims {
+			c.expirePolicy.Delete(victim)
+		}
+	}
+	return victims
+}
+
+// Health returns the current state of the cache internals.
+//
+// The background goroutine that applies the writes is considered stalled if it has been processing
+// a batch of tasks for too long, and the cleanup goroutine is considered stalled if it has not completed
+// a cleanup cycle for too long. Health does not acquire the locks, so it can be used when they are held forever.
+func (c *Cache[K, V]) Health() Health {
+	now := time.Now().UnixNano()
+	h := Health{
+		Closed:              c.isClosed.Load(),
+		WriteBufferOverload: c.writeBuffer.Len()*100 > c.writeBuffer.Cap()*writeBufferOverloadPercent,
+		AffinityFailed:      c.affinityFailed.Load(),
+	}
+	if since := c.processBusySince.Load(); since != 0 && now-since > int64(stallTimeout) {
+		h.ProcessStalled = true
+	}
+	if c.withExpiration && !c.manualCleanup && now-c.cleanupHeartbeat.Load() > int64(stallTimeout+c.maxCleanupInterval) {
+		h.CleanupStalled = true
+	}
+	return h
+}
+
+// WarmUpDone returns a channel that is closed once the cache has been filled to the warm-up threshold.
+func (c *Cache[K, V]) WarmUpDone() <-chan struct{} {
+	return c.warmUpDone
+}
+
 // Size returns the current number of items in the cache.
 func (c *Cache[K, V]) Size() int {
 	return c.hashmap.Size()
 }
 
+// WeightedSize returns the total cost of the entries in the eviction policy.
+func (c *Cache[K, V]) WeightedSize() int {
+	c.evictionMutex.Lock()
+	defer c.unlockEvictionMutex()
+
+	return int(c.policy.Cost())
+}
+
 // Capacity returns the cache capacity.
 func (c *Cache[K, V]) Capacity() int {
 	return c.capacity
@@ -500,6 +2058,16 @@ func (c *Cache[K, V]) Stats() *stats.Stats {
 	return c.stats
 }
 
+// DryRunEvictedCount returns the number of entries that would have been evicted in the dry-run mode.
+func (c *Cache[K, V]) DryRunEvictedCount() int64 {
+	return c.dryRunCount.Load()
+}
+
+// DryRunEvictedCost returns the sum of costs of entries that would have been evicted in the dry-run mode.
+func (c *Cache[K, V]) DryRunEvictedCost() int64 {
+	return c.dryRunCost.Load()
+}
+
 func clearBuffer[T any](buffer []T) []T {
 	var zero T
 	for i := 0; i < len(buffer); i++ {
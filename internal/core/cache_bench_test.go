@@ -0,0 +1,50 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+)
+
+func BenchmarkCache_ParallelGetSet(b *testing.B) {
+	const size = 1 << 14
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer c.Close()
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := i & (size - 1)
+			// 10% of the operations are writes, so the eviction mutex is taken concurrently with the reads.
+			if i%10 == 0 {
+				c.Set(key, i)
+			} else {
+				c.Get(key)
+			}
+			i++
+		}
+	})
+}
@@ -1,10 +1,15 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/maypok86/otter/internal/generated/node"
+	"github.com/maypok86/otter/internal/xmath"
 )
 
 func TestCache_SetWithCost(t *testing.T) {
@@ -69,6 +74,19 @@ func TestCache_Range(t *testing.T) {
 	if iters != aliveNodes {
 		t.Fatalf("got unexpected number of iterations: %d", iters)
 	}
+
+	iters = 0
+	c.RangeKeys(func(key int) bool {
+		if key == 2 {
+			t.Fatalf("got the key of the expired item for iteration %d", iters)
+			return false
+		}
+		iters++
+		return true
+	})
+	if iters != aliveNodes {
+		t.Fatalf("got unexpected number of key iterations: %d", iters)
+	}
 }
 
 func TestCache_Close(t *testing.T) {
@@ -95,7 +113,7 @@ func TestCache_Close(t *testing.T) {
 	if cacheSize := c.Size(); cacheSize != 0 {
 		t.Fatalf("c.Size() = %d, want = %d", cacheSize, 0)
 	}
-	if !c.isClosed {
+	if !c.isClosed.Load() {
 		t.Fatalf("cache should be closed")
 	}
 
@@ -104,9 +122,47 @@ func TestCache_Close(t *testing.T) {
 	if cacheSize := c.Size(); cacheSize != 0 {
 		t.Fatalf("c.Size() = %d, want = %d", cacheSize, 0)
 	}
-	if !c.isClosed {
+	if !c.IsClosed() {
 		t.Fatalf("cache should be closed")
 	}
+
+	// the writes to a closed cache should not block on the write buffer.
+	for i := 0; i < 10*int(compactWriteBufferCapacity); i++ {
+		if c.Set(i, i) {
+			t.Fatal("writes to a closed cache should be dropped")
+		}
+	}
+	if cacheSize := c.Size(); cacheSize != 0 {
+		t.Fatalf("c.Size() = %d, want = %d", cacheSize, 0)
+	}
+}
+
+func TestCache_FlushDuringClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		c := NewCache[int, int](Config[int, int]{
+			Capacity: 10,
+			CostFunc: func(key int, value int) uint32 {
+				return 1
+			},
+		})
+		c.Set(1, 1)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for !c.IsClosed() {
+				c.NextVictim()
+			}
+			c.NextVictim()
+		}()
+		c.Close()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the flush should not hang on a concurrent close, iteration: %d", i)
+		}
+	}
 }
 
 func TestCache_Clear(t *testing.T) {
@@ -133,7 +189,1200 @@ func TestCache_Clear(t *testing.T) {
 	if cacheSize := c.Size(); cacheSize != 0 {
 		t.Fatalf("c.Size() = %d, want = %d", cacheSize, 0)
 	}
-	if c.isClosed {
+	if c.isClosed.Load() {
 		t.Fatalf("cache shouldn't be closed")
 	}
 }
+
+func TestCache_ExpiringWithin(t *testing.T) {
+	size := 128
+	ttl := time.Hour
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 2 * size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		TTL: &ttl,
+	})
+	defer c.Close()
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if got := c.ExpiringWithin(time.Minute); got != 0 {
+		t.Fatalf("c.ExpiringWithin(time.Minute) = %d, want = %d", got, 0)
+	}
+	if got := c.ExpiringWithin(2 * time.Hour); got != size {
+		t.Fatalf("c.ExpiringWithin(2 * time.Hour) = %d, want = %d", got, size)
+	}
+}
+
+func TestCache_DryRun(t *testing.T) {
+	size := 10
+	count := 128
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		DryRun: true,
+	})
+	defer c.Close()
+
+	for i := 0; i < count; i++ {
+		c.Set(i, i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if cacheSize := c.Size(); cacheSize != count {
+		t.Fatalf("c.Size() = %d, want = %d", cacheSize, count)
+	}
+	if evicted := c.DryRunEvictedCount(); evicted != int64(count-size) {
+		t.Fatalf("c.DryRunEvictedCount() = %d, want = %d", evicted, count-size)
+	}
+	if cost := c.DryRunEvictedCost(); cost != int64(count-size) {
+		t.Fatalf("c.DryRunEvictedCost() = %d, want = %d", cost, count-size)
+	}
+}
+
+func TestCache_ConcurrentClear(t *testing.T) {
+	for _, policy := range []EvictionPolicy{S3FIFO, LRU, Sampled} {
+		size := 100
+		c := NewCache[int, int](Config[int, int]{
+			Capacity: size,
+			CostFunc: func(key int, value int) uint32 {
+				return 1
+			},
+			EvictionPolicy: policy,
+		})
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+
+				for i := 0; i < 10000; i++ {
+					k := g*100 + i%(2*size)
+					c.Set(k, k)
+					if v, ok := c.Get(k); ok && v != k && v != -k {
+						t.Errorf("c.Get(%d) = %d, want = %d", k, v, k)
+						return
+					}
+					if i%3 == 0 {
+						c.Delete(k)
+					} else {
+						c.Set(k, -k)
+					}
+				}
+			}(g)
+		}
+		for i := 0; i < 100; i++ {
+			c.Clear()
+		}
+		wg.Wait()
+
+		for i := 0; i < 10*size; i++ {
+			c.Set(i, i)
+		}
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache is inconsistent with the policy %v: %v", policy, err)
+		}
+		if cacheSize := c.Size(); cacheSize > size {
+			t.Fatalf("c.Size() = %d, want <= %d", cacheSize, size)
+		}
+		c.Close()
+	}
+}
+
+func TestCache_LastAccess(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		WithLastAccess: true,
+	})
+	defer c.Close()
+
+	c.Set(1, 1)
+	if _, ok := c.Get(1); !ok {
+		t.Fatalf("key should exists: %d", 1)
+	}
+
+	lastAccess, ok := c.LastAccess(1)
+	if !ok {
+		t.Fatalf("last access time should be tracked for key: %d", 1)
+	}
+	if d := time.Since(lastAccess); d < 0 || d > 2*time.Second {
+		t.Fatalf("got unexpected last access time: %v", lastAccess)
+	}
+	if _, ok := c.LastAccess(2); ok {
+		t.Fatalf("last access time should not be found for key: %d", 2)
+	}
+
+	cc := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer cc.Close()
+
+	cc.Set(1, 1)
+	if _, ok := cc.LastAccess(1); ok {
+		t.Fatal("last access time should not be tracked")
+	}
+}
+
+func TestCache_CreatedAt(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		WithCreatedAt: true,
+	})
+	defer c.Close()
+
+	c.Set(1, 1)
+	createdAt, ok := c.CreatedAt(1)
+	if !ok {
+		t.Fatalf("creation time should be tracked for key: %d", 1)
+	}
+	if d := time.Since(createdAt); d < 0 || d > 2*time.Second {
+		t.Fatalf("got unexpected creation time: %v", createdAt)
+	}
+	if _, ok := c.CreatedAt(2); ok {
+		t.Fatalf("creation time should not be found for key: %d", 2)
+	}
+	if _, ok := c.LastAccess(1); ok {
+		t.Fatal("last access time should not be tracked")
+	}
+}
+
+func TestCache_TTLDistribution(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		WithVariableTTL: true,
+	})
+	defer c.Close()
+
+	ttls := []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute, 2 * time.Minute, time.Hour}
+	for i, ttl := range ttls {
+		c.SetWithTTL(i, i, ttl)
+	}
+	// the items without a ttl are not counted.
+	if err := c.Transact([]int{100}, func(map[int]int) map[int]int {
+		return map[int]int{100: 100}
+	}); err != nil {
+		t.Fatalf("c.Transact() = %v", err)
+	}
+
+	distribution := c.TTLDistribution()
+	expected := map[time.Duration]int{
+		time.Second:      1,
+		10 * time.Second: 1,
+		time.Minute:      2,
+		5 * time.Minute:  1,
+	}
+	if len(distribution) != len(expected) {
+		t.Fatalf("got unexpected distribution: %v", distribution)
+	}
+	for bound, count := range expected {
+		if distribution[bound] != count {
+			t.Fatalf("got unexpected distribution: %v", distribution)
+		}
+	}
+}
+
+func TestCache_TopN(t *testing.T) {
+	size := 100
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer c.Close()
+
+	for i := 0; i < size/2; i++ {
+		c.Set(i, i)
+	}
+	c.evictionMutex.Lock()
+	for i := 0; i < 3; i++ {
+		got, _ := c.hashmap.Get(i)
+		for j := 0; j <= i; j++ {
+			got.IncrementFrequency()
+		}
+	}
+	c.evictionMutex.Unlock()
+
+	top := c.TopN(2)
+	if len(top) != 2 || top[0].Key != 2 || top[0].Frequency != 3 || top[1].Key != 1 || top[1].Frequency != 2 {
+		t.Fatalf("got unexpected top: %v", top)
+	}
+	if top := c.TopN(0); len(top) != 0 {
+		t.Fatalf("got unexpected top: %v", top)
+	}
+}
+
+func TestCache_NextVictim(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer c.Close()
+
+	if _, ok := c.NextVictim(); ok {
+		t.Fatal("empty cache should not have a victim")
+	}
+
+	for i := 0; i < 5; i++ {
+		c.Set(i, i)
+	}
+	if key, ok := c.NextVictim(); !ok || key != 0 {
+		t.Fatalf("NextVictim() = %d, %v, want = 0, true", key, ok)
+	}
+	if c.Size() != 5 {
+		t.Fatalf("NextVictim should not evict the entries, size: %d", c.Size())
+	}
+}
+
+func TestCache_PinDroppedWithKey(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		c.Set(i, i)
+	}
+	c.flush()
+	for i := 0; i < 3; i++ {
+		c.Pin(i)
+		c.Pin(i)
+	}
+
+	c.Delete(0)
+	c.flush()
+	if c.Unpin(0) {
+		t.Fatal("the pins of the deleted key should be dropped")
+	}
+	c.Set(0, 0)
+	c.flush()
+	if n, _ := c.hashmap.Get(0); c.isParked(n) || !isInPolicy(n) {
+		t.Fatal("the new node of the deleted key should not be pinned")
+	}
+
+	c.Clear()
+	if len(c.pins) != 0 || len(c.parked) != 0 {
+		t.Fatalf("the pins should be dropped by Clear, pins: %d, parked: %d", len(c.pins), len(c.parked))
+	}
+	if c.Unpin(1) {
+		t.Fatal("the pins of the cleared key should be dropped")
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+}
+
+func TestCache_LoaderRateLimit(t *testing.T) {
+	rps := 20
+	loader := func(keys []int) (map[int]int, error) {
+		values := make(map[int]int, len(keys))
+		for _, k := range keys {
+			values[k] = k
+		}
+		return values, nil
+	}
+
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		LoaderRateLimit:         float64(rps),
+		LoaderRateLimitBehavior: RateLimitReject,
+	})
+	for i := 0; i < rps; i++ {
+		if _, err := c.GetMultiOrSet([]int{i}, loader); err != nil {
+			t.Fatalf("the burst of %d loads should be allowed, but load %d failed: %v", rps, i, err)
+		}
+	}
+	if _, err := c.GetMultiOrSet([]int{rps}, loader); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("GetMultiOrSet error = %v, want = %v", err, ErrRateLimited)
+	}
+	if got, err := c.GetMultiOrSet([]int{0}, loader); err != nil || got[0] != 0 {
+		t.Fatalf("the cached keys should be returned without the loader, got %v, %v", got, err)
+	}
+	c.Close()
+
+	c = NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		LoaderRateLimit:         float64(rps),
+		LoaderRateLimitBehavior: RateLimitWait,
+	})
+	defer c.Close()
+	start := time.Now()
+	for i := 0; i < rps+5; i++ {
+		if _, err := c.GetMultiOrSet([]int{i}, loader); err != nil {
+			t.Fatalf("the load should wait for the limit, but got %v", err)
+		}
+	}
+	if elapsed, want := time.Since(start), 5*time.Second/time.Duration(rps); elapsed < want*9/10 {
+		t.Fatalf("the loads over the burst should be spread by the limit, elapsed %v, want >= %v", elapsed, want)
+	}
+}
+
+func TestCache_LoaderCircuitBreaker(t *testing.T) {
+	cooldown := 50 * time.Millisecond
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		LoaderFailureThreshold: 3,
+		LoaderCooldown:         cooldown,
+	})
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+	calls := 0
+	fail := true
+	loader := func(keys []int) (map[int]int, error) {
+		calls++
+		if fail {
+			return nil, errLoad
+		}
+		return map[int]int{keys[0]: keys[0]}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetMultiOrSet([]int{1}, loader); !errors.Is(err, errLoad) {
+			t.Fatalf("GetMultiOrSet error = %v, want = %v", err, errLoad)
+		}
+	}
+	if c.CircuitState() != CircuitOpen {
+		t.Fatalf("the circuit should be opened by the consecutive failures, state: %d", c.CircuitState())
+	}
+	if _, err := c.GetMultiOrSet([]int{1}, loader); !errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Fatalf("the open circuit should fail without calling the loader, error: %v, calls: %d", err, calls)
+	}
+
+	// the failed trial call opens the circuit again.
+	time.Sleep(cooldown)
+	if _, err := c.GetMultiOrSet([]int{1}, loader); !errors.Is(err, errLoad) || calls != 4 {
+		t.Fatalf("a trial call should be allowed after the cooldown, error: %v, calls: %d", err, calls)
+	}
+	if _, err := c.GetMultiOrSet([]int{1}, loader); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetMultiOrSet error = %v, want = %v", err, ErrCircuitOpen)
+	}
+
+	// the successful trial call closes the circuit.
+	time.Sleep(cooldown)
+	fail = false
+	if got, err := c.GetMultiOrSet([]int{1}, loader); err != nil || got[1] != 1 {
+		t.Fatalf("the trial call should succeed, got %v, %v", got, err)
+	}
+	if c.CircuitState() != CircuitClosed {
+		t.Fatalf("the circuit should be closed by the successful trial call, state: %d", c.CircuitState())
+	}
+
+	// the panicking trial call opens the circuit again.
+	fail = true
+	for i := 0; i < 3; i++ {
+		_, _ = c.GetMultiOrSet([]int{2}, loader)
+	}
+	time.Sleep(cooldown)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the panic of the loader should be propagated")
+			}
+		}()
+		_, _ = c.GetMultiOrSet([]int{2}, func(keys []int) (map[int]int, error) {
+			panic("load failed")
+		})
+	}()
+	if c.CircuitState() != CircuitOpen {
+		t.Fatalf("the circuit should be opened by the panicking trial call, state: %d", c.CircuitState())
+	}
+	time.Sleep(cooldown)
+	fail = false
+	if _, err := c.GetMultiOrSet([]int{2}, loader); err != nil {
+		t.Fatalf("the trial call should be allowed after the cooldown, error: %v", err)
+	}
+}
+
+func TestCache_LoaderPanicWaiters(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+	})
+	defer c.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		defer func() {
+			_ = recover()
+		}()
+		_, _ = c.GetMultiOrSet([]int{1}, func(keys []int) (map[int]int, error) {
+			close(started)
+			<-release
+			panic("load failed")
+		})
+	}()
+
+	<-started
+	go func() {
+		// let the waiter join the in-flight load.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	_, err := c.GetMultiOrSet([]int{1}, func(keys []int) (map[int]int, error) {
+		t.Error("the in-flight load should be awaited")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrLoaderPanicked) {
+		t.Fatalf("the waiters should get %v, but got %v", ErrLoaderPanicked, err)
+	}
+}
+
+func TestCache_LoaderRetry(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		LoaderMaxAttempts: 3,
+		LoaderBackoff:     FixedBackoff(time.Millisecond),
+	})
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+	calls := 0
+	failures := 2
+	loader := func(keys []int) (map[int]int, error) {
+		calls++
+		if calls <= failures {
+			return nil, errLoad
+		}
+		return map[int]int{keys[0]: keys[0]}, nil
+	}
+
+	// the load succeeds on the last attempt.
+	if got, err := c.GetMultiOrSet([]int{1}, loader); err != nil || got[1] != 1 || calls != 3 {
+		t.Fatalf("the load should succeed after the retries, got %v, %v, calls: %d", got, err, calls)
+	}
+
+	// all the attempts fail.
+	calls = 0
+	failures = 3
+	if _, err := c.GetMultiOrSet([]int{2}, loader); !errors.Is(err, errLoad) || calls != 3 {
+		t.Fatalf("the error of the last attempt should be returned, error: %v, calls: %d", err, calls)
+	}
+
+	// the cancellation of the context stops the retries.
+	c.loaderBackoff = FixedBackoff(time.Hour)
+	calls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetMultiOrSetContext(ctx, []int{3}, loader); !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+		t.Fatalf("the retries should stop on the cancellation, error: %v, calls: %d", err, calls)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(time.Millisecond, 5*time.Millisecond)
+	for attempt, want := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 5 * time.Millisecond} {
+		if got := b.Backoff(attempt + 1); got != want {
+			t.Fatalf("Backoff(%d) = %v, want = %v", attempt+1, got, want)
+		}
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	var evicted []int
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		DeletionListener: func(key int, value int, cause DeletionCause) {
+			if cause == Size {
+				evicted = append(evicted, key)
+			}
+		},
+		StatsEnabled: true,
+	})
+	defer c.Close()
+
+	if got := c.Evict(1); got != 0 {
+		t.Fatalf("Evict(1) = %d on an empty cache, want = 0", got)
+	}
+
+	for i := 0; i < 5; i++ {
+		c.Set(i, i)
+	}
+	victim, _ := c.NextVictim()
+	if got := c.Evict(2); got != 2 {
+		t.Fatalf("Evict(2) = %d, want = 2", got)
+	}
+	if len(evicted) != 2 || evicted[0] != victim {
+		t.Fatalf("evicted = %v, want the first victim %d", evicted, victim)
+	}
+	if c.Size() != 3 || c.Has(victim) {
+		t.Fatalf("the victims should be deleted, size: %d", c.Size())
+	}
+	if c.stats.EvictedCount() != 2 {
+		t.Fatalf("evicted count = %d, want = 2", c.stats.EvictedCount())
+	}
+	if got := c.Evict(0); got != 0 {
+		t.Fatalf("Evict(0) = %d, want = 0", got)
+	}
+	if got := c.Evict(10); got != 3 {
+		t.Fatalf("Evict(10) = %d, want = 3", got)
+	}
+
+	// a huge n is bounded by the policy size.
+	c.Set(1, 1)
+	c.flush()
+	if got := c.NextEvictions(math.MaxInt); len(got) != 1 {
+		t.Fatalf("NextEvictions(math.MaxInt) = %v, want one candidate", got)
+	}
+	if got := c.Evict(math.MaxInt); got != 1 {
+		t.Fatalf("Evict(math.MaxInt) = %d, want = 1", got)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+}
+
+func TestCache_EvictHot(t *testing.T) {
+	for _, policy := range []EvictionPolicy{S3FIFO, LRU, LFU, Sampled} {
+		var (
+			mutex   sync.Mutex
+			evicted []int
+		)
+		size := 100
+		c := NewCache[int, int](Config[int, int]{
+			Capacity: size,
+			CostFunc: func(key int, value int) uint32 {
+				return 1
+			},
+			EvictionPolicy:  policy,
+			WithVariableTTL: true,
+			TimeResolution:  time.Nanosecond,
+			DeletionListener: func(key int, value int, cause DeletionCause) {
+				if cause == Size {
+					mutex.Lock()
+					evicted = append(evicted, key)
+					mutex.Unlock()
+				}
+			},
+		})
+
+		// fill the cache and make all the items hot, then let the even ones expire.
+		for i := 0; i < size; i++ {
+			ttl := time.Hour
+			if i%2 == 0 {
+				ttl = time.Millisecond
+			}
+			c.SetWithTTL(i, i, ttl)
+		}
+		for r := 0; r < 5; r++ {
+			for i := 0; i < size; i++ {
+				c.Get(i)
+			}
+			c.flush()
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if got := c.Evict(10); got != 10 {
+			t.Fatalf("Evict(10) = %d on a hot cache with the %v policy, want = 10", got, policy)
+		}
+		mutex.Lock()
+		if len(evicted) != 10 {
+			t.Fatalf("%d items were evicted with the %v policy, want = 10", len(evicted), policy)
+		}
+		for _, k := range evicted {
+			if k%2 == 0 {
+				t.Fatalf("the expired item %d was reported as evicted with the %v policy", k, policy)
+			}
+		}
+		mutex.Unlock()
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache is inconsistent with the %v policy: %v", policy, err)
+		}
+
+		c.Close()
+	}
+}
+
+func TestCache_Health(t *testing.T) {
+	ttl := time.Hour
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		TTL: &ttl,
+	})
+
+	if h := c.Health(); h != (Health{}) {
+		t.Fatalf("the new cache should be healthy, but got %+v", h)
+	}
+
+	stalledAt := time.Now().Add(-2 * stallTimeout).UnixNano()
+	c.processBusySince.Store(stalledAt)
+	c.cleanupHeartbeat.Store(stalledAt)
+	h := c.Health()
+	if !h.ProcessStalled || !h.CleanupStalled {
+		t.Fatalf("the goroutines should be stalled, but got %+v", h)
+	}
+	c.processBusySince.Store(0)
+
+	c.Close()
+
+	if h := c.Health(); !h.Closed {
+		t.Fatalf("the closed cache should be reported, but got %+v", h)
+	}
+}
+
+func TestCache_DeleteWithResult(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		WithVariableTTL: true,
+	})
+	defer c.Close()
+
+	if c.DeleteWithResult(1) {
+		t.Fatal("absent key should not be reported as deleted")
+	}
+
+	c.SetWithTTL(1, 1, time.Hour)
+	if !c.DeleteWithResult(1) {
+		t.Fatal("live key should be reported as deleted")
+	}
+	if c.Has(1) {
+		t.Fatal("key should be deleted")
+	}
+
+	c.SetWithTTL(2, 2, time.Second)
+	time.Sleep(3 * time.Second)
+	if c.DeleteWithResult(2) {
+		t.Fatal("expired key should not be reported as deleted")
+	}
+}
+
+func TestCache_ReadBuffersCount(t *testing.T) {
+	for _, requested := range []int{0, 1, 3, 5, 8, 100} {
+		c := NewCache[int, int](Config[int, int]{
+			Capacity: 100,
+			CostFunc: func(key int, value int) uint32 {
+				return 1
+			},
+			ReadBuffersCount: requested,
+		})
+
+		count := len(c.readBuffers)
+		if !xmath.IsPowerOf2(uint32(count)) || count < requested {
+			t.Fatalf("read buffers count should be a power of two not less than %d, but got %d", requested, count)
+		}
+		if int(c.mask) != count-1 {
+			t.Fatalf("mask should cover all read buffers; mask: %d, count: %d", c.mask, count)
+		}
+
+		c.Close()
+	}
+}
+
+func TestCache_DeferredReadsApplied(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		ReadBuffersCount: 1,
+	})
+	defer c.Close()
+
+	c.Set(1, 1)
+	c.flush()
+
+	// the reads find the mutex held by a writer and defer their batch.
+	c.evictionMutex.Lock()
+	for i := 0; i < 10000 && !c.hasPendingReads(); i++ {
+		c.Get(1)
+	}
+	if !c.hasPendingReads() {
+		c.unlockEvictionMutex()
+		t.Fatal("the reads should defer their batch while the mutex is held")
+	}
+	// any holder applies the deferred batches when releasing the mutex.
+	c.unlockEvictionMutex()
+
+	if c.hasPendingReads() {
+		t.Fatal("the deferred batch should be applied when the mutex is released")
+	}
+	got, _ := c.hashmap.Get(1)
+	if got.Frequency() == 0 {
+		t.Fatal("the deferred reads should be applied to the policy")
+	}
+}
+
+func TestCache_ExpiredNeverReturned(t *testing.T) {
+	ttl := 5 * time.Millisecond
+	for _, cfg := range []Config[int, int]{
+		{TTL: &ttl},
+		{WithVariableTTL: true},
+	} {
+		cfg.Capacity = 100
+		cfg.CostFunc = func(key int, value int) uint32 {
+			return 1
+		}
+		// read the exact time, so that the expiration can be compared with the deadline of each item.
+		cfg.TimeResolution = time.Nanosecond
+		c := NewCache[int, int](cfg)
+
+		// block the cleanup goroutine to make sure that the expiration is checked on the read path.
+		c.evictionMutex.Lock()
+		for i := 0; i < 20; i++ {
+			// the deadline of the item is between earliest and latest.
+			start := time.Now()
+			if cfg.WithVariableTTL {
+				c.SetWithTTL(i, i, ttl)
+			} else {
+				c.Set(i, i)
+			}
+			earliest, latest := start.Add(ttl), time.Now().Add(ttl)
+
+			for {
+				before := time.Now()
+				_, ok := c.Get(i)
+				if ok && before.After(latest) {
+					t.Fatalf("item %d was returned %v after its deadline", i, before.Sub(latest))
+				}
+				if !ok {
+					if time.Now().Before(earliest) {
+						t.Fatalf("item %d was not returned before its deadline", i)
+					}
+					break
+				}
+			}
+			if c.Has(i) {
+				t.Fatalf("expired item %d should not be reported by Has", i)
+			}
+		}
+		c.Range(func(key int, value int) bool {
+			t.Fatalf("expired item should not be passed to Range: %d", key)
+			return true
+		})
+
+		c.evictionMutex.Unlock()
+		c.Close()
+	}
+}
+
+func TestCache_ExpiredByRead(t *testing.T) {
+	var (
+		mutex  sync.Mutex
+		causes = make(map[DeletionCause]int)
+	)
+	ttl := 20 * time.Millisecond
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		TTL:            &ttl,
+		TimeResolution: time.Nanosecond,
+		DeletionListener: func(key int, value int, cause DeletionCause) {
+			mutex.Lock()
+			causes[cause]++
+			mutex.Unlock()
+		},
+	})
+	defer c.Close()
+
+	c.Set(1, 1)
+	c.flush()
+
+	// block the goroutines that apply the writes and clean up the cache, so that only the reads see the item expire.
+	c.evictionMutex.Lock()
+	time.Sleep(2 * ttl)
+	for i := 0; i < 100; i++ {
+		if _, ok := c.Get(1); ok {
+			t.Fatal("expired item should not be returned by Get")
+		}
+	}
+	n, ok := c.hashmap.Get(1)
+	if !ok {
+		t.Fatal("the read should not delete the expired item from the hash table")
+	}
+	if n.MarkExpired() {
+		t.Fatal("the removal of the expired item should be requested by the first read")
+	}
+	c.evictionMutex.Unlock()
+
+	c.flush()
+	if c.Size() != 0 {
+		t.Fatalf("expired item should be removed after the read, size: %d", c.Size())
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if causes[Expired] != 1 || len(causes) != 1 {
+		t.Fatalf("expired item should be removed once with the Expired cause, but got %v", causes)
+	}
+}
+
+func TestCache_SetIfAbsentExpired(t *testing.T) {
+	ttl := time.Millisecond
+	for _, cfg := range []Config[int, int]{
+		{TTL: &ttl},
+		{WithVariableTTL: true},
+	} {
+		cfg.Capacity = 100
+		cfg.CostFunc = func(key int, value int) uint32 {
+			return 1
+		}
+		c := NewCache[int, int](cfg)
+
+		if cfg.WithVariableTTL {
+			c.SetWithTTL(1, 1, ttl)
+		} else {
+			c.Set(1, 1)
+		}
+
+		// block the cleanup goroutine, so that the expired node stays in the hash table.
+		c.evictionMutex.Lock()
+		time.Sleep(2 * time.Second)
+
+		var ok bool
+		if cfg.WithVariableTTL {
+			ok = c.SetIfAbsentWithTTL(1, 2, time.Hour)
+		} else {
+			ok = c.SetIfAbsent(1, 2)
+		}
+		if !ok {
+			t.Fatal("expired item should be treated as absent by SetIfAbsent")
+		}
+
+		c.evictionMutex.Unlock()
+		if v, ok := c.Get(1); !ok || v != 2 {
+			t.Fatalf("new value should be stored, got %d, %v", v, ok)
+		}
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache is inconsistent: %v", err)
+		}
+		c.Close()
+	}
+}
+
+func TestCache_Compact(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		Compact: true,
+	})
+	defer c.Close()
+
+	if len(c.readBuffers) != 1 {
+		t.Fatalf("compact cache should have one read buffer, but got %d", len(c.readBuffers))
+	}
+	if c.writeBuffer.Cap() != int(compactWriteBufferCapacity) {
+		t.Fatalf("compact cache should have a small write buffer, but got %d", c.writeBuffer.Cap())
+	}
+
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i)
+		c.Get(i)
+	}
+}
+
+func TestCache_WriteBufferOverflows(t *testing.T) {
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 1000,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		StatsEnabled: true,
+		Compact:      true,
+	})
+	defer c.Close()
+
+	// block the process goroutine to fill the write buffer.
+	c.evictionMutex.Lock()
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 500; i++ {
+			c.Set(i, i)
+		}
+		close(done)
+	}()
+
+	for {
+		if current, capacity := c.WriteBufferUsage(); current == capacity {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.evictionMutex.Unlock()
+	<-done
+
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	if c.stats.WriteBufferOverflows() == 0 {
+		t.Fatal("the overflows of the write buffer should be counted")
+	}
+}
+
+func TestCache_Verify(t *testing.T) {
+	ttl := time.Hour
+	for _, cfg := range []Config[int, int]{
+		{},
+		{TTL: &ttl},
+		{WithVariableTTL: true},
+		{EvictionPolicy: LRU},
+		{EvictionPolicy: Sampled},
+		{EvictionPolicy: LFU},
+	} {
+		cfg.Capacity = 100
+		cfg.CostFunc = func(key int, value int) uint32 {
+			return 1
+		}
+		c := NewCache[int, int](cfg)
+
+		for i := 0; i < 300; i++ {
+			if cfg.WithVariableTTL {
+				c.SetWithTTL(i, i, ttl)
+			} else {
+				c.Set(i, i)
+			}
+		}
+		for i := 250; i < 300; i++ {
+			c.Delete(i)
+			c.Get(i - 50)
+		}
+
+		if err := c.Verify(); err != nil {
+			t.Fatalf("cache should be consistent, config: %+v, error: %v", cfg, err)
+		}
+
+		// break the invariant.
+		c.evictionMutex.Lock()
+		c.hashmap.Range(func(n node.Node[int, int]) bool {
+			c.policy.Delete(n)
+			return false
+		})
+		c.evictionMutex.Unlock()
+		if err := c.Verify(); err == nil {
+			t.Fatalf("inconsistency should be detected, config: %+v", cfg)
+		}
+
+		c.Close()
+	}
+}
+
+func TestCache_SameKeyWritesOrder(t *testing.T) {
+	ttl := time.Hour
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 1000,
+		CostFunc: func(key int, value int) uint32 {
+			return uint32(value%7 + 1)
+		},
+		WithCost: true,
+		TTL:      &ttl,
+	})
+	defer c.Close()
+
+	// the goroutines replace and delete the same keys concurrently, so their tasks are pushed
+	// to the write buffer in a different order than the nodes are written to the hash table.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < 10000; i++ {
+				k := i % 4
+				if i%10 == g {
+					c.Delete(k)
+				} else {
+					c.Set(k, g*10000+i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if err := c.Verify(); err != nil {
+		t.Fatalf("the policies don't track the nodes of the hash table: %v", err)
+	}
+	for k := 0; k < 4; k++ {
+		c.Set(k, k)
+		if v, ok := c.Get(k); !ok || v != k {
+			t.Fatalf("c.Get(%d) = %d, %v, want = %d, true", k, v, ok, k)
+		}
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("the policies don't track the nodes of the hash table: %v", err)
+	}
+}
+
+func TestCache_CleanupInterval(t *testing.T) {
+	ttl := time.Hour
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 10,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		TTL:                &ttl,
+		MinCleanupInterval: 200 * time.Millisecond,
+	})
+	defer c.Close()
+
+	for _, tt := range []struct {
+		expired int
+		want    time.Duration
+	}{
+		{expired: 0, want: time.Second},
+		{expired: cleanupExpiredThreshold - 1, want: time.Second},
+		{expired: cleanupExpiredThreshold, want: 500 * time.Millisecond},
+		{expired: 100 * cleanupExpiredThreshold, want: 200 * time.Millisecond},
+	} {
+		if got := c.cleanupInterval(tt.expired); got != tt.want {
+			t.Fatalf("cleanupInterval(%d) = %s, want = %s", tt.expired, got, tt.want)
+		}
+	}
+}
+
+func TestCache_CleanupBatchSize(t *testing.T) {
+	size := 50
+	ttl := time.Second
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 2 * size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		TTL:              &ttl,
+		ManualCleanup:    true,
+		CleanupBatchSize: 10,
+	})
+	defer c.Close()
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	c.flush()
+
+	time.Sleep(2100 * time.Millisecond)
+
+	expired, _ := c.removeExpired(nil, c.cleanupBatchSize)
+	if len(expired) != 10 {
+		t.Fatalf("the cleanup cycle should remove 10 items, but removed %d", len(expired))
+	}
+	if got := c.PurgeExpired(); got != size-10 {
+		t.Fatalf("c.PurgeExpired() = %d, want = %d", got, size-10)
+	}
+}
+
+func TestCache_NotifyPreExpiry(t *testing.T) {
+	notified := make(map[int]int)
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 100,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		WithVariableTTL: true,
+		ManualCleanup:   true,
+		TimeResolution:  10 * time.Millisecond,
+		PreExpiryCallback: func(key int, value int) {
+			notified[key]++
+		},
+		PreExpiryLead: time.Minute,
+	})
+	defer c.Close()
+
+	c.SetWithTTL(1, 1, time.Hour)
+	// the ttl is shorter than the lead, so the item is notified by the first scan after the insertion.
+	c.SetWithTTL(2, 2, 10*time.Second)
+	c.notifyPreExpiry()
+
+	time.Sleep(50 * time.Millisecond)
+	c.SetWithTTL(3, 3, 10*time.Second)
+	c.notifyPreExpiry()
+	c.notifyPreExpiry()
+
+	if len(notified) != 2 || notified[2] != 1 || notified[3] != 1 {
+		t.Fatalf("the items expiring within the lead should be notified once, but got: %v", notified)
+	}
+}
+
+func TestCache_CleanupConcurrency(t *testing.T) {
+	size := 1024
+	ttl := time.Second
+	var (
+		mutex   sync.Mutex
+		deleted = make(map[int]struct{}, size)
+	)
+	c := NewCache[int, int](Config[int, int]{
+		Capacity: 2 * size,
+		CostFunc: func(key int, value int) uint32 {
+			return 1
+		},
+		DeletionListener: func(key int, value int, cause DeletionCause) {
+			mutex.Lock()
+			deleted[key] = struct{}{}
+			mutex.Unlock()
+		},
+		TTL:                &ttl,
+		ManualCleanup:      true,
+		CleanupConcurrency: 4,
+	})
+	defer c.Close()
+
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	c.flush()
+
+	time.Sleep(2100 * time.Millisecond)
+
+	if got := c.PurgeExpired(); got != size {
+		t.Fatalf("c.PurgeExpired() = %d, want = %d", got, size)
+	}
+	if c.Size() != 0 {
+		t.Fatalf("c.Size() = %d, want = 0", c.Size())
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(deleted) != size {
+		t.Fatalf("the listener should be notified about %d items, but got %d", size, len(deleted))
+	}
+}
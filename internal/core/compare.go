@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/maypok86/otter/internal/generated/node"
+	"github.com/maypok86/otter/internal/hashtable"
+)
+
+// equal reports whether the values are equal.
+//
+// Without the equal function it panics if the dynamic type of the values is not comparable.
+func (c *Cache[K, V]) equal(a, b V) bool {
+	if c.equalFunc == nil {
+		return any(a) == any(b)
+	}
+
+	return c.equalFunc(a, b)
+}
+
+// getLive returns the live node for the key without updating the statistics and the eviction policy.
+func (c *Cache[K, V]) getLive(key K) (node.Node[K, V], bool) {
+	got, ok := c.hashmap.Get(key)
+	if !ok || !got.IsAlive() || got.IsExpired() {
+		return nil, false
+	}
+
+	return got, true
+}
+
+// CompareAndSwap sets the value for the key to newValue if the cached value is equal to oldValue.
+// It reports whether the value was swapped.
+//
+// Also, it returns false if the new key-value item had too much cost and the CompareAndSwap was dropped.
+func (c *Cache[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	if c.isFrozen.Load() || c.isClosed.Load() {
+		return false
+	}
+
+	cost := c.costFunc(key, newValue)
+	if cost > c.policy.MaxAvailableCost() {
+		c.stats.IncRejectedSets()
+		return false
+	}
+
+	n := c.newNode(key, newValue, c.defaultExpiration(), cost)
+	var prev node.Node[K, V]
+	c.hashmap.Transact([]K{key}, func(tx *hashtable.Tx[K, V]) {
+		got, ok := tx.Get(key)
+		if !ok || !got.IsAlive() || got.IsExpired() || !c.equal(got.Value(), oldValue) {
+			return
+		}
+		prev = tx.Set(n)
+		prev.Die()
+	})
+	if prev == nil {
+		return false
+	}
+
+	c.pushWrite(newUpdateTask(n, prev))
+	return true
+}
+
+// CompareAndDelete deletes the entry for the key if its value is equal to oldValue.
+// It reports whether the entry was deleted.
+func (c *Cache[K, V]) CompareAndDelete(key K, oldValue V) bool {
+	if c.isFrozen.Load() {
+		return false
+	}
+
+	got, ok := c.getLive(key)
+	if !ok || !c.equal(got.Value(), oldValue) {
+		return false
+	}
+
+	// the node is deleted only if it has not been replaced since the comparison.
+	deleted := c.hashmap.DeleteNode(got)
+	c.afterDelete(deleted)
+	return deleted != nil
+}
+
+// SetIfChanged associates the value with the key in this cache only if the cached value is absent or not equal to it,
+// so the repeated writes of the same value don't replace the node and don't notify the listeners.
+// It reports whether the value was written.
+//
+// Also, it returns false if the key-value item had too much cost and the SetIfChanged was dropped.
+func (c *Cache[K, V]) SetIfChanged(key K, value V) bool {
+	if got, ok := c.getLive(key); ok && c.equal(got.Value(), value) {
+		return false
+	}
+
+	return c.Set(key, value)
+}
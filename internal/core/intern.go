@@ -0,0 +1,75 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/maypok86/otter/internal/generated/node"
+	"github.com/maypok86/otter/internal/xmath"
+)
+
+// maxInternSlots bounds the memory used by the intern table of a large cache.
+const maxInternSlots = 1 << 16
+
+// valueInterner is a bounded direct-mapped table of the canonical values.
+//
+// A new value replaces the canonical one with the same slot, so the interning is best-effort:
+// the equal values stored after the replacement get a new canonical copy. In exchange, the table
+// never grows and never keeps the values alive longer than the next collision.
+type valueInterner[V any] struct {
+	slots []atomic.Pointer[V]
+	mask  uint64
+	equal func(a, b V) bool
+	hash  func(v V) uint64
+}
+
+func newValueInterner[V any](capacity int, equal func(a, b V) bool, hash func(v V) uint64) *valueInterner[V] {
+	if capacity > maxInternSlots {
+		capacity = maxInternSlots
+	}
+	size := xmath.RoundUpPowerOf2(uint32(capacity))
+	return &valueInterner[V]{
+		slots: make([]atomic.Pointer[V], size),
+		mask:  uint64(size - 1),
+		equal: equal,
+		hash:  hash,
+	}
+}
+
+// intern returns the canonical value equal to v. If there is no such value, then v becomes the canonical one.
+func (vi *valueInterner[V]) intern(v V) V {
+	slot := &vi.slots[vi.hash(v)&vi.mask]
+	if canonical := slot.Load(); canonical != nil && vi.equal(*canonical, v) {
+		return *canonical
+	}
+
+	slot.Store(&v)
+	return v
+}
+
+// intern returns the canonical copy of the value if the value interning is enabled.
+func (c *Cache[K, V]) intern(value V) V {
+	if c.interner == nil {
+		return value
+	}
+
+	return c.interner.intern(value)
+}
+
+// newNode creates a node with the value that is stored in the cache instead of the given one.
+func (c *Cache[K, V]) newNode(key K, value V, expiration int64, cost uint32) node.Node[K, V] {
+	return c.nodeManager.Create(key, c.intern(c.cloneValue(value)), expiration, cost)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited means that the loader has not been called because its rate limit has been exceeded.
+var ErrRateLimited = errors.New("loader rate limit exceeded")
+
+// RateLimitBehavior determines what a load does when the rate limit of the loader is exceeded.
+type RateLimitBehavior uint8
+
+const (
+	// RateLimitWait the load waits until the loader can be called.
+	RateLimitWait RateLimitBehavior = iota
+	// RateLimitReject the load fails with ErrRateLimited without calling the loader.
+	RateLimitReject
+)
+
+// rateLimiter is a token bucket that allows rps calls per second on average and the bursts of up to rps calls.
+type rateLimiter struct {
+	mutex  sync.Mutex
+	rps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	burst := rps
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rps:    rps,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// take takes a token and returns how long the caller has to wait before using it. If wait is false and
+// there is no token available right away, it takes nothing and returns false.
+func (l *rateLimiter) take(wait bool) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rps
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	if !wait {
+		return 0, false
+	}
+
+	// the token is reserved in advance, so the waiting callers are served in the order of their arrival.
+	l.tokens--
+	return time.Duration(-l.tokens / l.rps * float64(time.Second)), true
+}
+
+// withLoaderRateLimit wraps the loader, so that it is called no more often than the rate limit allows.
+func (c *Cache[K, V]) withLoaderRateLimit(batchLoader func(keys []K) (map[K]V, error)) func(keys []K) (map[K]V, error) {
+	if c.loaderLimiter == nil {
+		return batchLoader
+	}
+
+	return func(keys []K) (map[K]V, error) {
+		delay, ok := c.loaderLimiter.take(c.rateLimitBehavior == RateLimitWait)
+		if !ok {
+			return nil, ErrRateLimited
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		return batchLoader(keys)
+	}
+}
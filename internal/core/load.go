@@ -0,0 +1,189 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// loadCall is an in-flight load of a key.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	ok    bool
+	err   error
+}
+
+// GetMultiOrSet returns the values associated with the keys in this cache and loads the missing ones
+// with a single call of batchLoader. The loaded values are stored in the cache.
+//
+// The concurrent calls for the same missing keys are deduplicated: each key is loaded by only one of them,
+// and the others wait for the result.
+func (c *Cache[K, V]) GetMultiOrSet(keys []K, batchLoader func(keys []K) (map[K]V, error)) (map[K]V, error) {
+	return c.getMultiOrSet(context.Background(), keys, batchLoader, c.defaultExpiration)
+}
+
+// GetMultiOrSetContext is like GetMultiOrSet, but stops retrying the failed loader calls
+// when the context is canceled.
+func (c *Cache[K, V]) GetMultiOrSetContext(
+	ctx context.Context,
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+) (map[K]V, error) {
+	return c.getMultiOrSet(ctx, keys, batchLoader, c.defaultExpiration)
+}
+
+// GetMultiOrSetWithTTL is like GetMultiOrSet, but sets the custom ttl for the loaded items.
+func (c *Cache[K, V]) GetMultiOrSetWithTTL(
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+	ttl time.Duration,
+) (map[K]V, error) {
+	return c.GetMultiOrSetWithTTLContext(context.Background(), keys, batchLoader, ttl)
+}
+
+// GetMultiOrSetWithTTLContext is like GetMultiOrSetWithTTL, but stops retrying the failed loader calls
+// when the context is canceled.
+func (c *Cache[K, V]) GetMultiOrSetWithTTLContext(
+	ctx context.Context,
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+	ttl time.Duration,
+) (map[K]V, error) {
+	return c.getMultiOrSet(ctx, keys, batchLoader, func() int64 {
+		return getExpiration(ttl)
+	})
+}
+
+func (c *Cache[K, V]) getMultiOrSet(
+	ctx context.Context,
+	keys []K,
+	batchLoader func(keys []K) (map[K]V, error),
+	expiration func() int64,
+) (map[K]V, error) {
+	result := make(map[K]V, len(keys))
+	missing := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := result[key]; ok {
+			continue
+		}
+		if value, ok := c.Get(key); ok {
+			result[key] = value
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	// register the calls for the missing keys that are not being loaded yet.
+	own := make([]K, 0, len(missing))
+	calls := make(map[K]*loadCall[V], len(missing))
+	waiting := make(map[K]*loadCall[V])
+	c.loadMutex.Lock()
+	for _, key := range missing {
+		if _, ok := calls[key]; ok {
+			continue
+		}
+		if call, ok := c.loads[key]; ok {
+			waiting[key] = call
+			continue
+		}
+		call := &loadCall[V]{
+			done: make(chan struct{}),
+		}
+		c.loads[key] = call
+		calls[key] = call
+		own = append(own, key)
+	}
+	c.loadMutex.Unlock()
+
+	var err error
+	if len(own) > 0 {
+		loader := c.withLoaderCircuitBreaker(
+			c.withLoaderRetry(ctx, c.withLoaderRateLimit(c.withLoaderPanicRecovery(batchLoader))),
+		)
+		err = c.load(own, calls, loader, expiration)
+		for key, call := range calls {
+			if call.ok {
+				result[key] = call.value
+			}
+		}
+	}
+
+	for key, call := range waiting {
+		<-call.done
+		if call.err != nil && err == nil {
+			err = call.err
+		}
+		if call.ok {
+			result[key] = call.value
+		}
+	}
+
+	return result, err
+}
+
+// load calls batchLoader for the keys, stores the loaded values and completes the calls.
+//
+// If batchLoader panics, the calls are completed with ErrLoaderPanicked and the panic is propagated.
+func (c *Cache[K, V]) load(
+	keys []K,
+	calls map[K]*loadCall[V],
+	batchLoader func(keys []K) (map[K]V, error),
+	expiration func() int64,
+) (err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			err = ErrLoaderPanicked
+		}
+
+		c.loadMutex.Lock()
+		for _, key := range keys {
+			delete(c.loads, key)
+		}
+		c.loadMutex.Unlock()
+
+		for _, call := range calls {
+			call.err = err
+			close(call.done)
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	values, err := batchLoader(keys)
+	if err != nil {
+		return err
+	}
+
+	exp := expiration()
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		c.set(key, value, exp, false)
+		call := calls[key]
+		call.value = value
+		call.ok = true
+	}
+	return nil
+}
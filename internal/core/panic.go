@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"math"
+)
+
+// The stages passed to the panic handler.
+const (
+	PanicStageCost             = "cost"
+	PanicStageDeletionListener = "deletion listener"
+	PanicStageSetListener      = "set listener"
+	PanicStageLoader           = "loader"
+	PanicStagePreExpiry        = "pre-expiry callback"
+)
+
+// ErrLoaderPanicked means that the loader has panicked and the panic has been reported to the panic handler.
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// withPanicRecovery wraps the user callbacks of the config, so that their panics are reported to the panic handler
+// instead of crashing the background goroutines. The items with a panicking cost function are rejected.
+func withPanicRecovery[K comparable, V any](c Config[K, V]) Config[K, V] {
+	handler := c.PanicHandler
+
+	if costFunc := c.CostFunc; costFunc != nil {
+		c.CostFunc = func(key K, value V) (cost uint32) {
+			defer func() {
+				if r := recover(); r != nil {
+					handler(PanicStageCost, r)
+					cost = math.MaxUint32
+				}
+			}()
+			return costFunc(key, value)
+		}
+	}
+
+	if listener := c.DeletionListener; listener != nil {
+		c.DeletionListener = func(key K, value V, cause DeletionCause) {
+			defer recoverPanic(handler, PanicStageDeletionListener)
+			listener(key, value, cause)
+		}
+	}
+
+	if listener := c.DeletionBatchListener; listener != nil {
+		c.DeletionBatchListener = func(entries []DeletedEntry[K, V]) {
+			defer recoverPanic(handler, PanicStageDeletionListener)
+			listener(entries)
+		}
+	}
+
+	if listener := c.SetListener; listener != nil {
+		c.SetListener = func(key K, oldValue V, newValue V, replaced bool) {
+			defer recoverPanic(handler, PanicStageSetListener)
+			listener(key, oldValue, newValue, replaced)
+		}
+	}
+
+	if callback := c.PreExpiryCallback; callback != nil {
+		c.PreExpiryCallback = func(key K, value V) {
+			defer recoverPanic(handler, PanicStagePreExpiry)
+			callback(key, value)
+		}
+	}
+
+	return c
+}
+
+// withLoaderPanicRecovery wraps the loader, so that its panic is reported to the panic handler
+// and returned as ErrLoaderPanicked.
+func (c *Cache[K, V]) withLoaderPanicRecovery(batchLoader func(keys []K) (map[K]V, error)) func(keys []K) (map[K]V, error) {
+	if c.panicHandler == nil {
+		return batchLoader
+	}
+
+	return func(keys []K) (values map[K]V, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				c.panicHandler(PanicStageLoader, r)
+				values, err = nil, ErrLoaderPanicked
+			}
+		}()
+		return batchLoader(keys)
+	}
+}
+
+// recoverPanic reports the recovered panic to the handler. It must be called directly by defer.
+func recoverPanic(handler func(stage string, r any), stage string) {
+	if r := recover(); r != nil {
+		handler(stage, r)
+	}
+}
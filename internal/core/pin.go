@@ -0,0 +1,134 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+// Pin marks the item with the given key as non-evictable until the matching Unpin.
+// It reports whether the key was present in the cache. The pins are counted, so each Pin needs its own Unpin.
+//
+// The pinned items are withheld from the eviction policy, so they don't count towards the capacity
+// and the cache may hold more than the capacity if many items are pinned. The pin belongs to the key:
+// the new values of the pinned key are pinned too. The pinned items still expire and can be deleted,
+// then the pins of the key are dropped.
+func (c *Cache[K, V]) Pin(key K) bool {
+	c.lockEvictionMutex()
+	defer c.unlockEvictionMutex()
+
+	n, ok := c.getLive(key)
+	if !ok {
+		return false
+	}
+
+	if c.pins == nil {
+		c.pins = make(map[K]int)
+		c.parked = make(map[K]node.Node[K, V])
+	}
+	c.pins[key]++
+	// the node that is not in the policy yet is parked when its write task is applied.
+	if c.pins[key] == 1 && isInPolicy(n) {
+		c.policy.Delete(n)
+		c.parked[key] = n
+	}
+	return true
+}
+
+// Unpin removes a pin added by Pin. It reports whether the key was pinned.
+//
+// When the last pin is removed, the item is returned to the eviction policy, which can evict it
+// or the other items right away if the cache is over capacity.
+func (c *Cache[K, V]) Unpin(key K) bool {
+	c.lockEvictionMutex()
+	count := c.pins[key]
+	if count == 0 {
+		c.unlockEvictionMutex()
+		return false
+	}
+	if count > 1 {
+		c.pins[key] = count - 1
+		c.unlockEvictionMutex()
+		return true
+	}
+
+	delete(c.pins, key)
+	n, ok := c.parked[key]
+	delete(c.parked, key)
+	var deleted []node.Node[K, V]
+	if ok && n.IsAlive() {
+		deleted = c.policy.Add(deleted, n)
+		if !c.dryRun {
+			for _, d := range deleted {
+				c.expirePolicy.Delete(d)
+			}
+		}
+	}
+	c.unlockEvictionMutex()
+
+	c.evictNodes(deleted, nil)
+	return true
+}
+
+// addToPolicy adds the node to the eviction policy or parks it if its key is pinned.
+//
+// It must be called under the eviction mutex.
+func (c *Cache[K, V]) addToPolicy(deleted []node.Node[K, V], n node.Node[K, V]) []node.Node[K, V] {
+	if c.pins[n.Key()] > 0 {
+		c.parked[n.Key()] = n
+		return deleted
+	}
+
+	return c.policy.Add(deleted, n)
+}
+
+// deleteFromPolicy deletes the node from the eviction policy or from the parked nodes.
+//
+// It must be called under the eviction mutex.
+func (c *Cache[K, V]) deleteFromPolicy(n node.Node[K, V]) {
+	if c.isParked(n) {
+		delete(c.parked, n.Key())
+		return
+	}
+
+	c.policy.Delete(n)
+}
+
+// dropPins drops the pins of the key of the removed node unless the key already has a new node,
+// so that the pins don't outlive the key and are not inherited by the later inserts of the key.
+//
+// It must be called under the eviction mutex.
+func (c *Cache[K, V]) dropPins(n node.Node[K, V]) {
+	if c.pins[n.Key()] == 0 {
+		return
+	}
+	if current, ok := c.hashmap.Get(n.Key()); ok && !node.Equals(current, n) && current.IsAlive() {
+		return
+	}
+
+	delete(c.pins, n.Key())
+	delete(c.parked, n.Key())
+}
+
+// isParked returns true if the node is withheld from the eviction policy because its key is pinned.
+func (c *Cache[K, V]) isParked(n node.Node[K, V]) bool {
+	parked, ok := c.parked[n.Key()]
+	return ok && node.Equals(parked, n)
+}
+
+// isTracked returns true if the node has already been added to the policies.
+func (c *Cache[K, V]) isTracked(n node.Node[K, V]) bool {
+	return isInPolicy(n) || c.isParked(n)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"time"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+// Entry is a key-value pair.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Prime inserts the entries directly into the hash table and the policies, bypassing the write buffer.
+// It returns the indexes of the entries rejected because of their cost.
+//
+// It holds the eviction mutex while inserting the entries, so the concurrent writes are applied
+// only after it returns. It is intended for loading a dataset at startup.
+func (c *Cache[K, V]) Prime(entries []Entry[K, V]) (rejected []int) {
+	return c.prime(entries, func(int) int64 {
+		return c.defaultExpiration()
+	})
+}
+
+// PrimeWithTTL is like Prime, but sets the ttl of each entry to the ttl with the same index.
+func (c *Cache[K, V]) PrimeWithTTL(entries []Entry[K, V], ttls []time.Duration) (rejected []int) {
+	return c.prime(entries, func(i int) int64 {
+		return getExpiration(ttls[i])
+	})
+}
+
+// prime inserts the entries with the expiration times returned by expiration for their indexes.
+func (c *Cache[K, V]) prime(entries []Entry[K, V], expiration func(i int) int64) (rejected []int) {
+	if c.isFrozen.Load() || c.isClosed.Load() {
+		for i := range entries {
+			rejected = append(rejected, i)
+		}
+		return rejected
+	}
+
+	maxCost := c.policy.MaxAvailableCost()
+	nodes := make([]node.Node[K, V], 0, len(entries))
+	for i, e := range entries {
+		cost := c.costFunc(e.Key, e.Value)
+		if cost > maxCost {
+			c.stats.IncRejectedSets()
+			rejected = append(rejected, i)
+			continue
+		}
+		nodes = append(nodes, c.newNode(e.Key, e.Value, expiration(i), cost))
+	}
+
+	// apply the pending writes, so that they don't refer to the nodes replaced here.
+	c.flush()
+
+	replaced := make([]node.Node[K, V], len(nodes))
+	deleted := make([]node.Node[K, V], 0, minDeletedBufferCapacity)
+	c.lockEvictionMutex()
+	for i, n := range nodes {
+		if old := c.hashmap.Set(n); old != nil {
+			old.Die()
+			// the node may not be added to the policies yet, then its add task is skipped as it is dead.
+			if c.isTracked(old) {
+				c.expirePolicy.Delete(old)
+				c.deleteFromPolicy(old)
+			}
+			replaced[i] = old
+		}
+		c.expirePolicy.Add(n)
+		deleted = c.addToPolicy(deleted, n)
+		c.stats.RecordInsertion(n.Cost())
+	}
+	if !c.dryRun {
+		for _, n := range deleted {
+			c.expirePolicy.Delete(n)
+		}
+	}
+	c.unlockEvictionMutex()
+
+	var batch []DeletedEntry[K, V]
+	for i, n := range nodes {
+		old := replaced[i]
+		if old == nil {
+			c.notifySet(n.Key(), zeroValue[V](), n.Value(), false)
+			continue
+		}
+		c.notifyDeletion(old.Key(), old.Value(), Replaced)
+		c.notifySet(n.Key(), old.Value(), n.Value(), true)
+		batch = c.appendDeleted(batch, old, Replaced)
+	}
+	c.evictNodes(deleted, batch)
+	return rejected
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BackoffPolicy determines how long to wait before the retry of a failed loader call.
+type BackoffPolicy interface {
+	// Backoff returns the time to wait before the given retry. The first retry has the attempt 1.
+	Backoff(attempt int) time.Duration
+}
+
+type fixedBackoff struct {
+	interval time.Duration
+}
+
+// FixedBackoff returns the backoff policy that waits the same interval before each retry.
+func FixedBackoff(interval time.Duration) BackoffPolicy {
+	return fixedBackoff{interval: interval}
+}
+
+func (b fixedBackoff) Backoff(int) time.Duration {
+	return b.interval
+}
+
+type exponentialBackoff struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// ExponentialBackoff returns the backoff policy that waits base before the first retry
+// and doubles the wait before each next one, but never waits longer than max.
+func ExponentialBackoff(base, max time.Duration) BackoffPolicy {
+	return exponentialBackoff{base: base, max: max}
+}
+
+func (b exponentialBackoff) Backoff(attempt int) time.Duration {
+	d := b.base
+	for i := 1; i < attempt && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		return b.max
+	}
+	return d
+}
+
+// withLoaderRetry wraps the loader, so that its failed calls are retried up to the maximum number of attempts.
+// The waits between the attempts are interrupted by the cancellation of ctx.
+func (c *Cache[K, V]) withLoaderRetry(
+	ctx context.Context,
+	batchLoader func(keys []K) (map[K]V, error),
+) func(keys []K) (map[K]V, error) {
+	if c.loaderMaxAttempts <= 1 {
+		return batchLoader
+	}
+
+	return func(keys []K) (map[K]V, error) {
+		values, err := batchLoader(keys)
+		for attempt := 1; err != nil && attempt < c.loaderMaxAttempts; attempt++ {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+
+			timer := time.NewTimer(c.loaderBackoff.Backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+			values, err = batchLoader(keys)
+		}
+		return values, err
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"time"
+
+	"github.com/maypok86/otter/internal/generated/node"
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// ReadOnlySnapshot is an immutable point-in-time view of the cache.
+//
+// It holds the references to the nodes that were live at the time of the snapshot,
+// so the values are not copied, and the items are never evicted or expired from it.
+// If the cache clones the values, the snapshot holds the clones instead and clones them again on each read.
+type ReadOnlySnapshot[K comparable, V any] struct {
+	nodes          map[K]node.Node[K, V]
+	withExpiration bool
+	cloneFunc      func(value V) V
+}
+
+// ReadOnlySnapshot captures the live items of the cache into an immutable snapshot.
+func (c *Cache[K, V]) ReadOnlySnapshot() *ReadOnlySnapshot[K, V] {
+	nodes := make(map[K]node.Node[K, V], c.Size())
+	c.hashmap.Range(func(n node.Node[K, V]) bool {
+		if !n.IsAlive() || n.IsExpired() {
+			return true
+		}
+		if c.cloneFunc != nil {
+			var expiration int64
+			if c.withExpiration {
+				expiration = n.Expiration()
+			}
+			n = c.nodeManager.Create(n.Key(), c.cloneFunc(n.Value()), expiration, n.Cost())
+		}
+		nodes[n.Key()] = n
+		return true
+	})
+
+	return &ReadOnlySnapshot[K, V]{
+		nodes:          nodes,
+		withExpiration: c.withExpiration,
+		cloneFunc:      c.cloneFunc,
+	}
+}
+
+// cloneValue returns a clone of the value if the clone function is set.
+func (s *ReadOnlySnapshot[K, V]) cloneValue(value V) V {
+	if s.cloneFunc == nil {
+		return value
+	}
+
+	return s.cloneFunc(value)
+}
+
+// Get returns the value associated with the key in the snapshot.
+func (s *ReadOnlySnapshot[K, V]) Get(key K) (V, bool) {
+	n, ok := s.nodes[key]
+	if !ok {
+		return zeroValue[V](), false
+	}
+	return s.cloneValue(n.Value()), true
+}
+
+// ExpiresAt returns the time when the item with the given key expires in the cache.
+// It returns false if the item is not found or doesn't expire.
+func (s *ReadOnlySnapshot[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	n, ok := s.nodes[key]
+	if !ok || !s.withExpiration || n.Expiration() == 0 {
+		return time.Time{}, false
+	}
+	return unixtime.ToTime(n.Expiration()), true
+}
+
+// Range iterates over all items in the snapshot.
+//
+// Iteration stops early when the given function returns false.
+func (s *ReadOnlySnapshot[K, V]) Range(f func(key K, value V) bool) {
+	for key, n := range s.nodes {
+		if !f(key, s.cloneValue(n.Value())) {
+			return
+		}
+	}
+}
+
+// Size returns the number of items in the snapshot.
+func (s *ReadOnlySnapshot[K, V]) Size() int {
+	return len(s.nodes)
+}
@@ -27,14 +27,25 @@ const (
 	updateReason
 	clearReason
 	closeReason
+	flushReason
+	expireReason
 )
 
 // task is a set of information to update the cache:
 // node, reason for write, difference after node cost change, etc.
+//
+// The tasks of the same key can be applied out of order, e.g. when two goroutines replace the key concurrently
+// and push their tasks in the reverse order. This is harmless: a node is killed under the bucket lock
+// of the hash table before the task replacing or deleting it is pushed, and only the alive nodes are added
+// to the policies, so the policies always converge to the node stored in the hash table.
 type task[K comparable, V any] struct {
 	n           node.Node[K, V]
 	old         node.Node[K, V]
 	writeReason reason
+	done        chan struct{}
+	// enqueuedAt is the time in nanoseconds when the task was pushed to the write buffer,
+	// it is set only if the write latency is tracked.
+	enqueuedAt int64
 }
 
 // newAddTask creates a task to add a node to policies.
@@ -53,6 +64,14 @@ func newDeleteTask[K comparable, V any](n node.Node[K, V]) task[K, V] {
 	}
 }
 
+// newExpireTask creates a task to remove the expired node found by a read from the hash table and policies.
+func newExpireTask[K comparable, V any](n node.Node[K, V]) task[K, V] {
+	return task[K, V]{
+		n:           n,
+		writeReason: expireReason,
+	}
+}
+
 // newUpdateTask creates a task to update the node in the policies.
 func newUpdateTask[K comparable, V any](n, oldNode node.Node[K, V]) task[K, V] {
 	return task[K, V]{
@@ -76,6 +95,14 @@ func newCloseTask[K comparable, V any]() task[K, V] {
 	}
 }
 
+// newFlushTask creates a task to apply all the buffered tasks. done is closed after that.
+func newFlushTask[K comparable, V any](done chan struct{}) task[K, V] {
+	return task[K, V]{
+		writeReason: flushReason,
+		done:        done,
+	}
+}
+
 // node returns the node contained in the task. If node was not specified, it returns nil.
 func (t *task[K, V]) node() node.Node[K, V] {
 	return t.n
@@ -96,6 +123,11 @@ func (t *task[K, V]) isDelete() bool {
 	return t.writeReason == deleteReason
 }
 
+// isExpire returns true if this is an expire task.
+func (t *task[K, V]) isExpire() bool {
+	return t.writeReason == expireReason
+}
+
 // isUpdate returns true if this is an update task.
 func (t *task[K, V]) isUpdate() bool {
 	return t.writeReason == updateReason
@@ -110,3 +142,8 @@ func (t *task[K, V]) isClear() bool {
 func (t *task[K, V]) isClose() bool {
 	return t.writeReason == closeReason
 }
+
+// isFlush returns true if this is a flush task.
+func (t *task[K, V]) isFlush() bool {
+	return t.writeReason == flushReason
+}
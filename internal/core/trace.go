@@ -0,0 +1,26 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// startRegion starts a runtime/trace region, which is visible in the go tool trace output.
+// The region is a no-op if the tracing is not active.
+func startRegion(name string) *trace.Region {
+	return trace.StartRegion(context.Background(), name)
+}
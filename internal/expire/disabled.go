@@ -28,9 +28,23 @@ func (d *Disabled[K, V]) Add(n node.Node[K, V]) {
 func (d *Disabled[K, V]) Delete(n node.Node[K, V]) {
 }
 
-func (d *Disabled[K, V]) RemoveExpired(expired []node.Node[K, V]) []node.Node[K, V] {
+func (d *Disabled[K, V]) RemoveExpired(expired []node.Node[K, V], limit int) []node.Node[K, V] {
 	return expired
 }
 
+func (d *Disabled[K, V]) RemoveExpiredPartition(expired []node.Node[K, V], limit, partition, total int) []node.Node[K, V] {
+	return expired
+}
+
+func (d *Disabled[K, V]) ExpiringBefore(deadline int64) int {
+	return 0
+}
+
+func (d *Disabled[K, V]) ForEachExpiringBefore(deadline int64, f func(n node.Node[K, V])) {
+}
+
+func (d *Disabled[K, V]) ForEach(f func(n node.Node[K, V])) {
+}
+
 func (d *Disabled[K, V]) Clear() {
 }
@@ -34,13 +34,44 @@ func (f *Fixed[K, V]) Delete(n node.Node[K, V]) {
 	f.q.remove(n)
 }
 
-func (f *Fixed[K, V]) RemoveExpired(expired []node.Node[K, V]) []node.Node[K, V] {
-	for !f.q.isEmpty() && f.q.head.IsExpired() {
+// RemoveExpired removes at most limit expired nodes and appends them to expired.
+func (f *Fixed[K, V]) RemoveExpired(expired []node.Node[K, V], limit int) []node.Node[K, V] {
+	for ; limit > 0 && !f.q.isEmpty() && f.q.head.IsExpired(); limit-- {
 		expired = append(expired, f.q.pop())
 	}
 	return expired
 }
 
+// RemoveExpiredPartition is like RemoveExpired. The nodes are kept in one queue ordered by the expiration time,
+// so there are no partitions to walk and each call removes the next expired nodes from the head.
+func (f *Fixed[K, V]) RemoveExpiredPartition(expired []node.Node[K, V], limit, partition, total int) []node.Node[K, V] {
+	return f.RemoveExpired(expired, limit)
+}
+
+func (f *Fixed[K, V]) ExpiringBefore(deadline int64) int {
+	count := 0
+	f.ForEachExpiringBefore(deadline, func(n node.Node[K, V]) {
+		if n.IsAlive() && !n.IsExpired() {
+			count++
+		}
+	})
+	return count
+}
+
+// ForEachExpiringBefore calls fn for each node in the queue that expires no later than the deadline.
+func (f *Fixed[K, V]) ForEachExpiringBefore(deadline int64, fn func(n node.Node[K, V])) {
+	for n := f.q.head; !node.Equals(n, nil) && n.Expiration() <= deadline; n = n.NextExp() {
+		fn(n)
+	}
+}
+
+// ForEach calls f for each node in the queue.
+func (f *Fixed[K, V]) ForEach(fn func(n node.Node[K, V])) {
+	for n := f.q.head; !node.Equals(n, nil); n = n.NextExp() {
+		fn(n)
+	}
+}
+
 func (f *Fixed[K, V]) Clear() {
 	f.q.clear()
 }
@@ -21,53 +21,66 @@ import (
 
 	"github.com/maypok86/otter/internal/generated/node"
 	"github.com/maypok86/otter/internal/unixtime"
-	"github.com/maypok86/otter/internal/xmath"
 )
 
 var (
-	buckets = []uint32{64, 64, 32, 4, 1}
-	spans   = []uint32{
-		xmath.RoundUpPowerOf2(uint32((1 * time.Second).Seconds())),             // 1s
-		xmath.RoundUpPowerOf2(uint32((1 * time.Minute).Seconds())),             // 1.07m
-		xmath.RoundUpPowerOf2(uint32((1 * time.Hour).Seconds())),               // 1.13h
-		xmath.RoundUpPowerOf2(uint32((24 * time.Hour).Seconds())),              // 1.52d
-		buckets[3] * xmath.RoundUpPowerOf2(uint32((24 * time.Hour).Seconds())), // 6.07d
-		buckets[3] * xmath.RoundUpPowerOf2(uint32((24 * time.Hour).Seconds())), // 6.07d
-	}
-	shift = []uint32{
-		uint32(bits.TrailingZeros32(spans[0])),
-		uint32(bits.TrailingZeros32(spans[1])),
-		uint32(bits.TrailingZeros32(spans[2])),
-		uint32(bits.TrailingZeros32(spans[3])),
-		uint32(bits.TrailingZeros32(spans[4])),
+	buckets = []int64{64, 64, 32, 4, 1}
+	spans   = []int64{
+		roundUpPowerOf2(int64(time.Second)),               // 1.07s
+		roundUpPowerOf2(int64(time.Minute)),               // 1.14m
+		roundUpPowerOf2(int64(time.Hour)),                 // 1.22h
+		roundUpPowerOf2(int64(24 * time.Hour)),            // 1.63d
+		buckets[3] * roundUpPowerOf2(int64(24*time.Hour)), // 6.5d
+		buckets[3] * roundUpPowerOf2(int64(24*time.Hour)), // 6.5d
+	}
+	shift = []int64{
+		int64(bits.TrailingZeros64(uint64(spans[0]))),
+		int64(bits.TrailingZeros64(uint64(spans[1]))),
+		int64(bits.TrailingZeros64(uint64(spans[2]))),
+		int64(bits.TrailingZeros64(uint64(spans[3]))),
+		int64(bits.TrailingZeros64(uint64(spans[4]))),
 	}
 )
 
+// roundUpPowerOf2 returns the smallest power of two greater than or equal to v.
+func roundUpPowerOf2(v int64) int64 {
+	return 1 << bits.Len64(uint64(v-1))
+}
+
 type Variable[K comparable, V any] struct {
 	wheel [][]node.Node[K, V]
-	time  uint32
+	// overdue is the list of the expired nodes that have not been removed because of the limit.
+	overdue node.Node[K, V]
+	time    int64
+	// partitionTimes is the time up to which each partition of the wheel has been advanced.
+	partitionTimes []int64
 }
 
 func NewVariable[K comparable, V any](nodeManager *node.Manager[K, V]) *Variable[K, V] {
+	newSentinel := func() node.Node[K, V] {
+		var k K
+		var v V
+		fn := nodeManager.Create(k, v, math.MaxInt64, 1)
+		fn.SetPrevExp(fn)
+		fn.SetNextExp(fn)
+		return fn
+	}
+
 	wheel := make([][]node.Node[K, V], len(buckets))
 	for i := 0; i < len(wheel); i++ {
 		wheel[i] = make([]node.Node[K, V], buckets[i])
 		for j := 0; j < len(wheel[i]); j++ {
-			var k K
-			var v V
-			fn := nodeManager.Create(k, v, math.MaxUint32, 1)
-			fn.SetPrevExp(fn)
-			fn.SetNextExp(fn)
-			wheel[i][j] = fn
+			wheel[i][j] = newSentinel()
 		}
 	}
 	return &Variable[K, V]{
-		wheel: wheel,
+		wheel:   wheel,
+		overdue: newSentinel(),
 	}
 }
 
 // findBucket determines the bucket that the timer event should be added to.
-func (v *Variable[K, V]) findBucket(expiration uint32) node.Node[K, V] {
+func (v *Variable[K, V]) findBucket(expiration int64) node.Node[K, V] {
 	duration := expiration - v.time
 	length := len(v.wheel) - 1
 	for i := 0; i < length; i++ {
@@ -81,7 +94,13 @@ func (v *Variable[K, V]) findBucket(expiration uint32) node.Node[K, V] {
 }
 
 // Add schedules a timer event for the node.
+//
+// The nodes with the zero expiration time never expire, so they are not scheduled.
 func (v *Variable[K, V]) Add(n node.Node[K, V]) {
+	if n.Expiration() == 0 {
+		return
+	}
+
 	root := v.findBucket(n.Expiration())
 	link(root, n)
 }
@@ -93,10 +112,39 @@ func (v *Variable[K, V]) Delete(n node.Node[K, V]) {
 	n.SetPrevExp(nil)
 }
 
-func (v *Variable[K, V]) RemoveExpired(expired []node.Node[K, V]) []node.Node[K, V] {
+// RemoveExpired removes at most limit expired nodes and appends them to expired.
+//
+// The expired nodes above the limit are kept in the overdue list and removed first by the next calls.
+func (v *Variable[K, V]) RemoveExpired(expired []node.Node[K, V], limit int) []node.Node[K, V] {
+	return v.RemoveExpiredPartition(expired, limit, 0, 1)
+}
+
+// RemoveExpiredPartition is like RemoveExpired, but walks only the buckets of the wheel whose index
+// modulo total is equal to partition. Each partition is advanced by its own calls, so the partitions
+// can be walked one after another with the lock released in between. The overdue list is drained by the partition 0.
+//
+// The caller must use the same total for all calls, otherwise the partitions are walked again from the earliest time.
+func (v *Variable[K, V]) RemoveExpiredPartition(expired []node.Node[K, V], limit, partition, total int) []node.Node[K, V] {
+	if len(v.partitionTimes) != total {
+		v.resetPartitions(total)
+	}
+
+	if partition == 0 {
+		for n := v.overdue.NextExp(); limit > 0 && !node.Equals(n, v.overdue); n = v.overdue.NextExp() {
+			v.Delete(n)
+			expired = append(expired, n)
+			limit--
+		}
+	}
+	if limit == 0 {
+		// the partition is advanced by the next call, which handles any number of the passed ticks.
+		return expired
+	}
+
 	currentTime := unixtime.Now()
-	prevTime := v.time
+	prevTime := v.partitionTimes[partition]
 	v.time = currentTime
+	v.partitionTimes[partition] = currentTime
 
 	for i := 0; i < len(shift); i++ {
 		previousTicks := prevTime >> shift[i]
@@ -106,13 +154,34 @@ func (v *Variable[K, V]) RemoveExpired(expired []node.Node[K, V]) []node.Node[K,
 			break
 		}
 
-		expired = v.removeExpiredFromBucket(expired, i, previousTicks, delta)
+		expired, limit = v.removeExpiredFromBucket(expired, limit, i, previousTicks, delta, partition, total)
 	}
 
 	return expired
 }
 
-func (v *Variable[K, V]) removeExpiredFromBucket(expired []node.Node[K, V], index int, prevTicks, delta uint32) []node.Node[K, V] {
+// resetPartitions splits the wheel into total partitions, which are advanced from the earliest time
+// of the previous partitions, so that no passed bucket is skipped.
+func (v *Variable[K, V]) resetPartitions(total int) {
+	earliest := v.time
+	for _, t := range v.partitionTimes {
+		if t < earliest {
+			earliest = t
+		}
+	}
+	v.partitionTimes = make([]int64, total)
+	for i := range v.partitionTimes {
+		v.partitionTimes[i] = earliest
+	}
+}
+
+func (v *Variable[K, V]) removeExpiredFromBucket(
+	expired []node.Node[K, V],
+	limit int,
+	index int,
+	prevTicks, delta int64,
+	partition, total int,
+) ([]node.Node[K, V], int) {
 	mask := buckets[index] - 1
 	steps := buckets[index]
 	if delta < steps {
@@ -122,6 +191,10 @@ func (v *Variable[K, V]) removeExpiredFromBucket(expired []node.Node[K, V], inde
 	end := start + steps
 	timerWheel := v.wheel[index]
 	for i := start; i < end; i++ {
+		if int((i&mask)%int64(total)) != partition {
+			continue
+		}
+
 		root := timerWheel[i&mask]
 		n := root.NextExp()
 		root.SetPrevExp(root)
@@ -132,20 +205,88 @@ func (v *Variable[K, V]) removeExpiredFromBucket(expired []node.Node[K, V], inde
 			n.SetPrevExp(nil)
 			n.SetNextExp(nil)
 
-			if n.Expiration() <= v.time {
-				expired = append(expired, n)
-			} else {
+			switch {
+			case n.Expiration() > v.time:
 				v.Add(n)
+			case limit > 0:
+				expired = append(expired, n)
+				limit--
+			default:
+				link(v.overdue, n)
 			}
 
 			n = next
 		}
 	}
 
-	return expired
+	return expired, limit
+}
+
+// ExpiringBefore returns the approximate number of live entries that expire no later than the deadline.
+//
+// Only the buckets that may contain such entries are visited, so the cost depends on the deadline
+// and not on the total number of entries in the timer wheel.
+func (v *Variable[K, V]) ExpiringBefore(deadline int64) int {
+	count := 0
+	v.ForEachExpiringBefore(deadline, func(n node.Node[K, V]) {
+		if n.IsAlive() && !n.IsExpired() {
+			count++
+		}
+	})
+	return count
+}
+
+// ForEachExpiringBefore calls f for each node in the timer wheel that expires no later than the deadline.
+// Like ExpiringBefore, it visits only the buckets that may contain such nodes.
+func (v *Variable[K, V]) ForEachExpiringBefore(deadline int64, f func(n node.Node[K, V])) {
+	if deadline < v.time {
+		return
+	}
+
+	length := len(v.wheel) - 1
+	for i := 0; i < length; i++ {
+		startTicks := v.time >> shift[i]
+		endTicks := deadline >> shift[i]
+		if endTicks-startTicks >= buckets[i] {
+			endTicks = startTicks + buckets[i] - 1
+		}
+		mask := buckets[i] - 1
+		for ticks := startTicks; ticks <= endTicks; ticks++ {
+			forEachBefore(v.wheel[i][ticks&mask], deadline, f)
+		}
+	}
+	if deadline-v.time >= spans[length] {
+		forEachBefore(v.wheel[length][0], deadline, f)
+	}
+}
+
+func forEachBefore[K comparable, V any](root node.Node[K, V], deadline int64, f func(n node.Node[K, V])) {
+	for n := root.NextExp(); !node.Equals(n, root); n = n.NextExp() {
+		if n.Expiration() <= deadline {
+			f(n)
+		}
+	}
+}
+
+// ForEach calls f for each node in the timer wheel.
+func (v *Variable[K, V]) ForEach(f func(n node.Node[K, V])) {
+	for n := v.overdue.NextExp(); !node.Equals(n, v.overdue); n = n.NextExp() {
+		f(n)
+	}
+	for i := 0; i < len(v.wheel); i++ {
+		for j := 0; j < len(v.wheel[i]); j++ {
+			root := v.wheel[i][j]
+			for n := root.NextExp(); !node.Equals(n, root); n = n.NextExp() {
+				f(n)
+			}
+		}
+	}
 }
 
 func (v *Variable[K, V]) Clear() {
+	for n := v.overdue.NextExp(); !node.Equals(n, v.overdue); n = v.overdue.NextExp() {
+		v.Delete(n)
+	}
 	for i := 0; i < len(v.wheel); i++ {
 		for j := 0; j < len(v.wheel[i]); j++ {
 			root := v.wheel[i][j]
@@ -161,6 +302,9 @@ func (v *Variable[K, V]) Clear() {
 		}
 	}
 	v.time = unixtime.Now()
+	for i := range v.partitionTimes {
+		v.partitionTimes[i] = v.time
+	}
 }
 
 // link adds the entry at the tail of the bucket's list.
@@ -15,12 +15,18 @@
 package expire
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/maypok86/otter/internal/generated/node"
 	"github.com/maypok86/otter/internal/unixtime"
 )
 
+func seconds(s int64) int64 {
+	return s * int64(time.Second)
+}
+
 func contains[K comparable, V any](root, f node.Node[K, V]) bool {
 	n := root.NextExp()
 	for !node.Equals(n, root) {
@@ -52,9 +58,9 @@ func TestVariable_Add(t *testing.T) {
 		WithExpiration: true,
 	})
 	nodes := []node.Node[string, string]{
-		nm.Create("k1", "", 1, 1),
-		nm.Create("k2", "", 69, 1),
-		nm.Create("k3", "", 4399, 1),
+		nm.Create("k1", "", seconds(1), 1),
+		nm.Create("k2", "", seconds(69), 1),
+		nm.Create("k3", "", seconds(4399), 1),
 	}
 	v := NewVariable[string, string](nm)
 
@@ -98,13 +104,13 @@ func TestVariable_RemoveExpired(t *testing.T) {
 		WithExpiration: true,
 	})
 	nodes := []node.Node[string, string]{
-		nm.Create("k1", "", 1, 1),
-		nm.Create("k2", "", 10, 1),
-		nm.Create("k3", "", 30, 1),
-		nm.Create("k4", "", 120, 1),
-		nm.Create("k5", "", 6500, 1),
-		nm.Create("k6", "", 142000, 1),
-		nm.Create("k7", "", 1420000, 1),
+		nm.Create("k1", "", seconds(1), 1),
+		nm.Create("k2", "", seconds(10), 1),
+		nm.Create("k3", "", seconds(30), 1),
+		nm.Create("k4", "", seconds(120), 1),
+		nm.Create("k5", "", seconds(6500), 1),
+		nm.Create("k6", "", seconds(142000), 1),
+		nm.Create("k7", "", seconds(1420000), 1),
 	}
 	v := NewVariable[string, string](nm)
 
@@ -114,28 +120,118 @@ func TestVariable_RemoveExpired(t *testing.T) {
 
 	var expired []node.Node[string, string]
 	var keys []string
-	unixtime.SetNow(64)
-	expired = v.RemoveExpired(expired)
+	unixtime.SetNow(seconds(64))
+	expired = v.RemoveExpired(expired, math.MaxInt)
 	keys = append(keys, "k1", "k2", "k3")
 	match(t, expired, keys)
 
-	unixtime.SetNow(200)
-	expired = v.RemoveExpired(expired)
+	unixtime.SetNow(seconds(200))
+	expired = v.RemoveExpired(expired, math.MaxInt)
 	keys = append(keys, "k4")
 	match(t, expired, keys)
 
-	unixtime.SetNow(12000)
-	expired = v.RemoveExpired(expired)
+	unixtime.SetNow(seconds(12000))
+	expired = v.RemoveExpired(expired, math.MaxInt)
 	keys = append(keys, "k5")
 	match(t, expired, keys)
 
-	unixtime.SetNow(350000)
-	expired = v.RemoveExpired(expired)
+	unixtime.SetNow(seconds(350000))
+	expired = v.RemoveExpired(expired, math.MaxInt)
 	keys = append(keys, "k6")
 	match(t, expired, keys)
 
-	unixtime.SetNow(1520000)
-	expired = v.RemoveExpired(expired)
+	unixtime.SetNow(seconds(1520000))
+	expired = v.RemoveExpired(expired, math.MaxInt)
 	keys = append(keys, "k7")
 	match(t, expired, keys)
 }
+
+func TestVariable_ExpiringBefore(t *testing.T) {
+	unixtime.SetNow(0)
+	nm := node.NewManager[string, string](node.Config{
+		WithExpiration: true,
+	})
+	nodes := []node.Node[string, string]{
+		nm.Create("k1", "", seconds(1), 1),
+		nm.Create("k2", "", seconds(10), 1),
+		nm.Create("k3", "", seconds(30), 1),
+		nm.Create("k4", "", seconds(120), 1),
+		nm.Create("k5", "", seconds(6500), 1),
+		nm.Create("k6", "", seconds(1420000), 1),
+	}
+	v := NewVariable[string, string](nm)
+
+	for _, n := range nodes {
+		v.Add(n)
+	}
+
+	tests := []struct {
+		deadline int64
+		want     int
+	}{
+		{deadline: 0, want: 0},
+		{deadline: seconds(10), want: 2},
+		{deadline: seconds(64), want: 3},
+		{deadline: seconds(7000), want: 5},
+		{deadline: seconds(2000000), want: 6},
+	}
+	for _, tt := range tests {
+		if got := v.ExpiringBefore(tt.deadline); got != tt.want {
+			t.Fatalf("ExpiringBefore(%d) = %d, want %d", tt.deadline, got, tt.want)
+		}
+	}
+}
+
+func TestVariable_RemoveExpiredLimit(t *testing.T) {
+	unixtime.SetNow(0)
+	nm := node.NewManager[string, string](node.Config{
+		WithExpiration: true,
+	})
+	v := NewVariable[string, string](nm)
+	for _, k := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		v.Add(nm.Create(k, "", seconds(10), 1))
+	}
+	v.Add(nm.Create("k6", "", seconds(1000), 1))
+
+	unixtime.SetNow(seconds(64))
+	expired := v.RemoveExpired(nil, 2)
+	if len(expired) != 2 {
+		t.Fatalf("RemoveExpired should remove 2 nodes, but removed %d", len(expired))
+	}
+	count := 0
+	v.ForEach(func(n node.Node[string, string]) {
+		count++
+	})
+	if count != 4 {
+		t.Fatalf("the overdue nodes should be kept, but got %d nodes", count)
+	}
+
+	expired = v.RemoveExpired(expired, 2)
+	expired = v.RemoveExpired(expired, 2)
+	match(t, expired, []string{"k1", "k2", "k3", "k4", "k5"})
+}
+
+func TestVariable_RemoveExpiredPartition(t *testing.T) {
+	unixtime.SetNow(0)
+	nm := node.NewManager[string, string](node.Config{
+		WithExpiration: true,
+	})
+	v := NewVariable[string, string](nm)
+	// the nodes are placed into the consecutive buckets of the first level.
+	for i, k := range []string{"k1", "k2", "k3", "k4"} {
+		v.Add(nm.Create(k, "", seconds(int64(i+1)), 1))
+	}
+	v.Add(nm.Create("k5", "", seconds(100), 1))
+
+	unixtime.SetNow(seconds(10))
+	match(t, v.RemoveExpiredPartition(nil, math.MaxInt, 1, 2), []string{"k2", "k4"})
+	match(t, v.RemoveExpiredPartition(nil, math.MaxInt, 0, 2), []string{"k1", "k3"})
+	match(t, v.RemoveExpiredPartition(nil, math.MaxInt, 0, 2), nil)
+
+	unixtime.SetNow(seconds(200))
+	var expired []node.Node[string, string]
+	for partition := 0; partition < 2; partition++ {
+		expired = v.RemoveExpiredPartition(expired, math.MaxInt, partition, 2)
+	}
+	match(t, expired, []string{"k5"})
+}
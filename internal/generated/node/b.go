@@ -22,7 +22,7 @@ type B[K comparable, V any] struct {
 }
 
 // NewB creates a new B.
-func NewB[K comparable, V any](key K, value V, expiration, cost uint32) Node[K, V] {
+func NewB[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return &B[K, V]{
 		key:   key,
 		value: value,
@@ -43,6 +43,10 @@ func (n *B[K, V]) Value() V {
 	return n.value
 }
 
+func (n *B[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
 func (n *B[K, V]) AsPointer() unsafe.Pointer {
 	return unsafe.Pointer(n)
 }
@@ -91,7 +95,7 @@ func (n *B[K, V]) IsExpired() bool {
 	return false
 }
 
-func (n *B[K, V]) Expiration() uint32 {
+func (n *B[K, V]) Expiration() int64 {
 	panic("not implemented")
 }
 
@@ -99,14 +103,30 @@ func (n *B[K, V]) Cost() uint32 {
 	return 1
 }
 
+func (n *B[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *B[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *B[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
 func (n *B[K, V]) IsAlive() bool {
-	return atomic.LoadUint32(&n.state) == aliveState
+	return atomic.LoadUint32(&n.state) != deadState
 }
 
 func (n *B[K, V]) Die() {
 	atomic.StoreUint32(&n.state, deadState)
 }
 
+func (n *B[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
 func (n *B[K, V]) Frequency() uint8 {
 	return n.frequency
 }
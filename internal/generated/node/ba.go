@@ -0,0 +1,170 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BA is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Access
+type BA[K comparable, V any] struct {
+	lastAccess int64
+	key        K
+	value      V
+	prev       *BA[K, V]
+	next       *BA[K, V]
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBA creates a new BA.
+func NewBA[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BA[K, V]{
+		key:        key,
+		value:      value,
+		lastAccess: unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBA casts a pointer to BA.
+func CastPointerToBA[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BA[K, V])(ptr)
+}
+
+func (n *BA[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BA[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BA[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BA[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BA[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BA[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BA[K, V])(v.AsPointer())
+}
+
+func (n *BA[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BA[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BA[K, V])(v.AsPointer())
+}
+
+func (n *BA[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BA[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BA[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BA[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BA[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
+func (n *BA[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BA[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BA[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BA[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BA[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BA[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BA[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BA[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BA[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BA[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BA[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BA[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,174 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BAI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Access
+//
+// 3. Insertion
+type BAI[K comparable, V any] struct {
+	lastAccess int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BAI[K, V]
+	next       *BAI[K, V]
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBAI creates a new BAI.
+func NewBAI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BAI[K, V]{
+		key:        key,
+		value:      value,
+		lastAccess: unixtime.Now(),
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBAI casts a pointer to BAI.
+func CastPointerToBAI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BAI[K, V])(ptr)
+}
+
+func (n *BAI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BAI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BAI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BAI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BAI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BAI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BAI[K, V])(v.AsPointer())
+}
+
+func (n *BAI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BAI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BAI[K, V])(v.AsPointer())
+}
+
+func (n *BAI[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BAI[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BAI[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BAI[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BAI[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BAI[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BAI[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BAI[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BAI[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BAI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BAI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BAI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BAI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BAI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BAI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BAI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BAI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BAI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BAI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BAI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BAI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BAI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -25,7 +25,7 @@ type BC[K comparable, V any] struct {
 }
 
 // NewBC creates a new BC.
-func NewBC[K comparable, V any](key K, value V, expiration, cost uint32) Node[K, V] {
+func NewBC[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return &BC[K, V]{
 		key:   key,
 		value: value,
@@ -47,6 +47,10 @@ func (n *BC[K, V]) Value() V {
 	return n.value
 }
 
+func (n *BC[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
 func (n *BC[K, V]) AsPointer() unsafe.Pointer {
 	return unsafe.Pointer(n)
 }
@@ -95,7 +99,7 @@ func (n *BC[K, V]) IsExpired() bool {
 	return false
 }
 
-func (n *BC[K, V]) Expiration() uint32 {
+func (n *BC[K, V]) Expiration() int64 {
 	panic("not implemented")
 }
 
@@ -103,14 +107,30 @@ func (n *BC[K, V]) Cost() uint32 {
 	return n.cost
 }
 
+func (n *BC[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BC[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BC[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
 func (n *BC[K, V]) IsAlive() bool {
-	return atomic.LoadUint32(&n.state) == aliveState
+	return atomic.LoadUint32(&n.state) != deadState
 }
 
 func (n *BC[K, V]) Die() {
 	atomic.StoreUint32(&n.state, deadState)
 }
 
+func (n *BC[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
 func (n *BC[K, V]) Frequency() uint8 {
 	return n.frequency
 }
@@ -0,0 +1,174 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BCA is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Cost
+//
+// 3. Access
+type BCA[K comparable, V any] struct {
+	lastAccess int64
+	key        K
+	value      V
+	prev       *BCA[K, V]
+	next       *BCA[K, V]
+	cost       uint32
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBCA creates a new BCA.
+func NewBCA[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BCA[K, V]{
+		key:        key,
+		value:      value,
+		cost:       cost,
+		lastAccess: unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBCA casts a pointer to BCA.
+func CastPointerToBCA[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BCA[K, V])(ptr)
+}
+
+func (n *BCA[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BCA[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BCA[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BCA[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BCA[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BCA[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BCA[K, V])(v.AsPointer())
+}
+
+func (n *BCA[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BCA[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BCA[K, V])(v.AsPointer())
+}
+
+func (n *BCA[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BCA[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BCA[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BCA[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BCA[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
+func (n *BCA[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BCA[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BCA[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BCA[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BCA[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BCA[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BCA[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BCA[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BCA[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BCA[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BCA[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BCA[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,178 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BCAI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Cost
+//
+// 3. Access
+//
+// 4. Insertion
+type BCAI[K comparable, V any] struct {
+	lastAccess int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BCAI[K, V]
+	next       *BCAI[K, V]
+	cost       uint32
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBCAI creates a new BCAI.
+func NewBCAI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BCAI[K, V]{
+		key:        key,
+		value:      value,
+		cost:       cost,
+		lastAccess: unixtime.Now(),
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBCAI casts a pointer to BCAI.
+func CastPointerToBCAI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BCAI[K, V])(ptr)
+}
+
+func (n *BCAI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BCAI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BCAI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BCAI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BCAI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BCAI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BCAI[K, V])(v.AsPointer())
+}
+
+func (n *BCAI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BCAI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BCAI[K, V])(v.AsPointer())
+}
+
+func (n *BCAI[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCAI[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCAI[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCAI[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCAI[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BCAI[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BCAI[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BCAI[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BCAI[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BCAI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BCAI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BCAI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BCAI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BCAI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BCAI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BCAI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BCAI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BCAI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BCAI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BCAI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BCAI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BCAI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,174 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BCI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Cost
+//
+// 3. Insertion
+type BCI[K comparable, V any] struct {
+	createdAt int64
+	key       K
+	value     V
+	prev      *BCI[K, V]
+	next      *BCI[K, V]
+	cost      uint32
+	state     uint32
+	frequency uint8
+	queueType uint8
+}
+
+// NewBCI creates a new BCI.
+func NewBCI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BCI[K, V]{
+		key:       key,
+		value:     value,
+		cost:      cost,
+		createdAt: unixtime.Now(),
+		state:     aliveState,
+	}
+}
+
+// CastPointerToBCI casts a pointer to BCI.
+func CastPointerToBCI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BCI[K, V])(ptr)
+}
+
+func (n *BCI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BCI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BCI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BCI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BCI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BCI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BCI[K, V])(v.AsPointer())
+}
+
+func (n *BCI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BCI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BCI[K, V])(v.AsPointer())
+}
+
+func (n *BCI[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BCI[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BCI[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BCI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BCI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BCI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BCI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BCI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BCI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BCI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BCI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BCI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BCI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BCI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BCI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BCI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -16,20 +16,20 @@ import (
 //
 // 2. Expiration
 type BE[K comparable, V any] struct {
+	expiration int64
 	key        K
 	value      V
 	prev       *BE[K, V]
 	next       *BE[K, V]
 	prevExp    *BE[K, V]
 	nextExp    *BE[K, V]
-	expiration uint32
 	state      uint32
 	frequency  uint8
 	queueType  uint8
 }
 
 // NewBE creates a new BE.
-func NewBE[K comparable, V any](key K, value V, expiration, cost uint32) Node[K, V] {
+func NewBE[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return &BE[K, V]{
 		key:        key,
 		value:      value,
@@ -51,6 +51,10 @@ func (n *BE[K, V]) Value() V {
 	return n.value
 }
 
+func (n *BE[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
 func (n *BE[K, V]) AsPointer() unsafe.Pointer {
 	return unsafe.Pointer(n)
 }
@@ -107,7 +111,7 @@ func (n *BE[K, V]) IsExpired() bool {
 	return n.expiration > 0 && n.expiration < unixtime.Now()
 }
 
-func (n *BE[K, V]) Expiration() uint32 {
+func (n *BE[K, V]) Expiration() int64 {
 	return n.expiration
 }
 
@@ -115,14 +119,30 @@ func (n *BE[K, V]) Cost() uint32 {
 	return 1
 }
 
+func (n *BE[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BE[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BE[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
 func (n *BE[K, V]) IsAlive() bool {
-	return atomic.LoadUint32(&n.state) == aliveState
+	return atomic.LoadUint32(&n.state) != deadState
 }
 
 func (n *BE[K, V]) Die() {
 	atomic.StoreUint32(&n.state, deadState)
 }
 
+func (n *BE[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
 func (n *BE[K, V]) Frequency() uint8 {
 	return n.frequency
 }
@@ -0,0 +1,184 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BEA is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Access
+type BEA[K comparable, V any] struct {
+	expiration int64
+	lastAccess int64
+	key        K
+	value      V
+	prev       *BEA[K, V]
+	next       *BEA[K, V]
+	prevExp    *BEA[K, V]
+	nextExp    *BEA[K, V]
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBEA creates a new BEA.
+func NewBEA[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BEA[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		lastAccess: unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBEA casts a pointer to BEA.
+func CastPointerToBEA[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BEA[K, V])(ptr)
+}
+
+func (n *BEA[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BEA[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BEA[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BEA[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BEA[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BEA[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BEA[K, V])(v.AsPointer())
+}
+
+func (n *BEA[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BEA[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BEA[K, V])(v.AsPointer())
+}
+
+func (n *BEA[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BEA[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BEA[K, V])(v.AsPointer())
+}
+
+func (n *BEA[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BEA[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BEA[K, V])(v.AsPointer())
+}
+
+func (n *BEA[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BEA[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BEA[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BEA[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BEA[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BEA[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
+func (n *BEA[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BEA[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BEA[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BEA[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BEA[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BEA[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BEA[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BEA[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BEA[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BEA[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BEA[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BEA[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,188 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BEAI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Access
+//
+// 4. Insertion
+type BEAI[K comparable, V any] struct {
+	expiration int64
+	lastAccess int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BEAI[K, V]
+	next       *BEAI[K, V]
+	prevExp    *BEAI[K, V]
+	nextExp    *BEAI[K, V]
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBEAI creates a new BEAI.
+func NewBEAI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BEAI[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		lastAccess: unixtime.Now(),
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBEAI casts a pointer to BEAI.
+func CastPointerToBEAI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BEAI[K, V])(ptr)
+}
+
+func (n *BEAI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BEAI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BEAI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BEAI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BEAI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BEAI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BEAI[K, V])(v.AsPointer())
+}
+
+func (n *BEAI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BEAI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BEAI[K, V])(v.AsPointer())
+}
+
+func (n *BEAI[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BEAI[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BEAI[K, V])(v.AsPointer())
+}
+
+func (n *BEAI[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BEAI[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BEAI[K, V])(v.AsPointer())
+}
+
+func (n *BEAI[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BEAI[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BEAI[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BEAI[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BEAI[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BEAI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BEAI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BEAI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BEAI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BEAI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BEAI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BEAI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BEAI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BEAI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BEAI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BEAI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BEAI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BEAI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -18,13 +18,13 @@ import (
 //
 // 3. Cost
 type BEC[K comparable, V any] struct {
+	expiration int64
 	key        K
 	value      V
 	prev       *BEC[K, V]
 	next       *BEC[K, V]
 	prevExp    *BEC[K, V]
 	nextExp    *BEC[K, V]
-	expiration uint32
 	cost       uint32
 	state      uint32
 	frequency  uint8
@@ -32,7 +32,7 @@ type BEC[K comparable, V any] struct {
 }
 
 // NewBEC creates a new BEC.
-func NewBEC[K comparable, V any](key K, value V, expiration, cost uint32) Node[K, V] {
+func NewBEC[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return &BEC[K, V]{
 		key:        key,
 		value:      value,
@@ -55,6 +55,10 @@ func (n *BEC[K, V]) Value() V {
 	return n.value
 }
 
+func (n *BEC[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
 func (n *BEC[K, V]) AsPointer() unsafe.Pointer {
 	return unsafe.Pointer(n)
 }
@@ -111,7 +115,7 @@ func (n *BEC[K, V]) IsExpired() bool {
 	return n.expiration > 0 && n.expiration < unixtime.Now()
 }
 
-func (n *BEC[K, V]) Expiration() uint32 {
+func (n *BEC[K, V]) Expiration() int64 {
 	return n.expiration
 }
 
@@ -119,14 +123,30 @@ func (n *BEC[K, V]) Cost() uint32 {
 	return n.cost
 }
 
+func (n *BEC[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BEC[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BEC[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
 func (n *BEC[K, V]) IsAlive() bool {
-	return atomic.LoadUint32(&n.state) == aliveState
+	return atomic.LoadUint32(&n.state) != deadState
 }
 
 func (n *BEC[K, V]) Die() {
 	atomic.StoreUint32(&n.state, deadState)
 }
 
+func (n *BEC[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
 func (n *BEC[K, V]) Frequency() uint8 {
 	return n.frequency
 }
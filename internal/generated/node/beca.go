@@ -0,0 +1,188 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BECA is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Cost
+//
+// 4. Access
+type BECA[K comparable, V any] struct {
+	expiration int64
+	lastAccess int64
+	key        K
+	value      V
+	prev       *BECA[K, V]
+	next       *BECA[K, V]
+	prevExp    *BECA[K, V]
+	nextExp    *BECA[K, V]
+	cost       uint32
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBECA creates a new BECA.
+func NewBECA[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BECA[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		cost:       cost,
+		lastAccess: unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBECA casts a pointer to BECA.
+func CastPointerToBECA[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BECA[K, V])(ptr)
+}
+
+func (n *BECA[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BECA[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BECA[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BECA[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BECA[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BECA[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BECA[K, V])(v.AsPointer())
+}
+
+func (n *BECA[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BECA[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BECA[K, V])(v.AsPointer())
+}
+
+func (n *BECA[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BECA[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BECA[K, V])(v.AsPointer())
+}
+
+func (n *BECA[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BECA[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BECA[K, V])(v.AsPointer())
+}
+
+func (n *BECA[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BECA[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BECA[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BECA[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BECA[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BECA[K, V]) CreatedAt() int64 {
+	panic("not implemented")
+}
+
+func (n *BECA[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BECA[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BECA[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BECA[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BECA[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BECA[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BECA[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BECA[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BECA[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BECA[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BECA[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BECA[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
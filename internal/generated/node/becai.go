@@ -0,0 +1,192 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BECAI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Cost
+//
+// 4. Access
+//
+// 5. Insertion
+type BECAI[K comparable, V any] struct {
+	expiration int64
+	lastAccess int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BECAI[K, V]
+	next       *BECAI[K, V]
+	prevExp    *BECAI[K, V]
+	nextExp    *BECAI[K, V]
+	cost       uint32
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBECAI creates a new BECAI.
+func NewBECAI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BECAI[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		cost:       cost,
+		lastAccess: unixtime.Now(),
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBECAI casts a pointer to BECAI.
+func CastPointerToBECAI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BECAI[K, V])(ptr)
+}
+
+func (n *BECAI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BECAI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BECAI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BECAI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BECAI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BECAI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BECAI[K, V])(v.AsPointer())
+}
+
+func (n *BECAI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BECAI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BECAI[K, V])(v.AsPointer())
+}
+
+func (n *BECAI[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BECAI[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BECAI[K, V])(v.AsPointer())
+}
+
+func (n *BECAI[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BECAI[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BECAI[K, V])(v.AsPointer())
+}
+
+func (n *BECAI[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BECAI[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BECAI[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BECAI[K, V]) LastAccess() int64 {
+	return atomic.LoadInt64(&n.lastAccess)
+}
+
+func (n *BECAI[K, V]) SetLastAccess(t int64) {
+	atomic.StoreInt64(&n.lastAccess, t)
+}
+
+func (n *BECAI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BECAI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BECAI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BECAI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BECAI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BECAI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BECAI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BECAI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BECAI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BECAI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BECAI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BECAI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BECAI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
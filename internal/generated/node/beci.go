@@ -0,0 +1,188 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BECI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Cost
+//
+// 4. Insertion
+type BECI[K comparable, V any] struct {
+	expiration int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BECI[K, V]
+	next       *BECI[K, V]
+	prevExp    *BECI[K, V]
+	nextExp    *BECI[K, V]
+	cost       uint32
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBECI creates a new BECI.
+func NewBECI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BECI[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		cost:       cost,
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBECI casts a pointer to BECI.
+func CastPointerToBECI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BECI[K, V])(ptr)
+}
+
+func (n *BECI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BECI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BECI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BECI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BECI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BECI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BECI[K, V])(v.AsPointer())
+}
+
+func (n *BECI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BECI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BECI[K, V])(v.AsPointer())
+}
+
+func (n *BECI[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BECI[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BECI[K, V])(v.AsPointer())
+}
+
+func (n *BECI[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BECI[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BECI[K, V])(v.AsPointer())
+}
+
+func (n *BECI[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BECI[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BECI[K, V]) Cost() uint32 {
+	return n.cost
+}
+
+func (n *BECI[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BECI[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BECI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BECI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BECI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BECI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BECI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BECI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BECI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BECI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BECI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BECI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BECI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BECI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BECI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,184 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BEI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Expiration
+//
+// 3. Insertion
+type BEI[K comparable, V any] struct {
+	expiration int64
+	createdAt  int64
+	key        K
+	value      V
+	prev       *BEI[K, V]
+	next       *BEI[K, V]
+	prevExp    *BEI[K, V]
+	nextExp    *BEI[K, V]
+	state      uint32
+	frequency  uint8
+	queueType  uint8
+}
+
+// NewBEI creates a new BEI.
+func NewBEI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BEI[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		createdAt:  unixtime.Now(),
+		state:      aliveState,
+	}
+}
+
+// CastPointerToBEI casts a pointer to BEI.
+func CastPointerToBEI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BEI[K, V])(ptr)
+}
+
+func (n *BEI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BEI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BEI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BEI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BEI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BEI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BEI[K, V])(v.AsPointer())
+}
+
+func (n *BEI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BEI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BEI[K, V])(v.AsPointer())
+}
+
+func (n *BEI[K, V]) PrevExp() Node[K, V] {
+	return n.prevExp
+}
+
+func (n *BEI[K, V]) SetPrevExp(v Node[K, V]) {
+	if v == nil {
+		n.prevExp = nil
+		return
+	}
+	n.prevExp = (*BEI[K, V])(v.AsPointer())
+}
+
+func (n *BEI[K, V]) NextExp() Node[K, V] {
+	return n.nextExp
+}
+
+func (n *BEI[K, V]) SetNextExp(v Node[K, V]) {
+	if v == nil {
+		n.nextExp = nil
+		return
+	}
+	n.nextExp = (*BEI[K, V])(v.AsPointer())
+}
+
+func (n *BEI[K, V]) IsExpired() bool {
+	return n.expiration > 0 && n.expiration < unixtime.Now()
+}
+
+func (n *BEI[K, V]) Expiration() int64 {
+	return n.expiration
+}
+
+func (n *BEI[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BEI[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BEI[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BEI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BEI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BEI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BEI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BEI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BEI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BEI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BEI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BEI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BEI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BEI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BEI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BEI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
@@ -0,0 +1,170 @@
+// Code generated by NodeGenerator. DO NOT EDIT.
+
+// Package node is a generated generator package.
+package node
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/unixtime"
+)
+
+// BI is a cache entry that provide the following features:
+//
+// 1. Base
+//
+// 2. Insertion
+type BI[K comparable, V any] struct {
+	createdAt int64
+	key       K
+	value     V
+	prev      *BI[K, V]
+	next      *BI[K, V]
+	state     uint32
+	frequency uint8
+	queueType uint8
+}
+
+// NewBI creates a new BI.
+func NewBI[K comparable, V any](key K, value V, expiration int64, cost uint32) Node[K, V] {
+	return &BI[K, V]{
+		key:       key,
+		value:     value,
+		createdAt: unixtime.Now(),
+		state:     aliveState,
+	}
+}
+
+// CastPointerToBI casts a pointer to BI.
+func CastPointerToBI[K comparable, V any](ptr unsafe.Pointer) Node[K, V] {
+	return (*BI[K, V])(ptr)
+}
+
+func (n *BI[K, V]) Key() K {
+	return n.key
+}
+
+func (n *BI[K, V]) Value() V {
+	return n.value
+}
+
+func (n *BI[K, V]) ValuePtr() *V {
+	return &n.value
+}
+
+func (n *BI[K, V]) AsPointer() unsafe.Pointer {
+	return unsafe.Pointer(n)
+}
+
+func (n *BI[K, V]) Prev() Node[K, V] {
+	return n.prev
+}
+
+func (n *BI[K, V]) SetPrev(v Node[K, V]) {
+	if v == nil {
+		n.prev = nil
+		return
+	}
+	n.prev = (*BI[K, V])(v.AsPointer())
+}
+
+func (n *BI[K, V]) Next() Node[K, V] {
+	return n.next
+}
+
+func (n *BI[K, V]) SetNext(v Node[K, V]) {
+	if v == nil {
+		n.next = nil
+		return
+	}
+	n.next = (*BI[K, V])(v.AsPointer())
+}
+
+func (n *BI[K, V]) PrevExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) SetPrevExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) NextExp() Node[K, V] {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) SetNextExp(v Node[K, V]) {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) IsExpired() bool {
+	return false
+}
+
+func (n *BI[K, V]) Expiration() int64 {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) Cost() uint32 {
+	return 1
+}
+
+func (n *BI[K, V]) LastAccess() int64 {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) SetLastAccess(t int64) {
+	panic("not implemented")
+}
+
+func (n *BI[K, V]) CreatedAt() int64 {
+	return n.createdAt
+}
+
+func (n *BI[K, V]) IsAlive() bool {
+	return atomic.LoadUint32(&n.state) != deadState
+}
+
+func (n *BI[K, V]) Die() {
+	atomic.StoreUint32(&n.state, deadState)
+}
+
+func (n *BI[K, V]) MarkExpired() bool {
+	return atomic.CompareAndSwapUint32(&n.state, aliveState, expiredState)
+}
+
+func (n *BI[K, V]) Frequency() uint8 {
+	return n.frequency
+}
+
+func (n *BI[K, V]) IncrementFrequency() {
+	n.frequency = minUint8(n.frequency+1, maxFrequency)
+}
+
+func (n *BI[K, V]) DecrementFrequency() {
+	n.frequency--
+}
+
+func (n *BI[K, V]) ResetFrequency() {
+	n.frequency = 0
+}
+
+func (n *BI[K, V]) MarkSmall() {
+	n.queueType = smallQueueType
+}
+
+func (n *BI[K, V]) IsSmall() bool {
+	return n.queueType == smallQueueType
+}
+
+func (n *BI[K, V]) MarkMain() {
+	n.queueType = mainQueueType
+}
+
+func (n *BI[K, V]) IsMain() bool {
+	return n.queueType == mainQueueType
+}
+
+func (n *BI[K, V]) Unmark() {
+	n.queueType = unknownQueueType
+}
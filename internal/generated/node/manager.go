@@ -19,6 +19,8 @@ const (
 const (
 	aliveState uint32 = iota
 	deadState
+	// expiredState means that the node is still in the hash-table, but its removal has already been requested.
+	expiredState
 )
 
 // Node is a cache entry.
@@ -27,6 +29,8 @@ type Node[K comparable, V any] interface {
 	Key() K
 	// Value returns the value.
 	Value() V
+	// ValuePtr returns the pointer to the value stored in the node.
+	ValuePtr() *V
 	// AsPointer returns the node as a pointer.
 	AsPointer() unsafe.Pointer
 	// Prev returns the previous node in the eviction policy.
@@ -47,14 +51,23 @@ type Node[K comparable, V any] interface {
 	SetNextExp(v Node[K, V])
 	// IsExpired returns true if node is expired.
 	IsExpired() bool
-	// Expiration returns the expiration time.
-	Expiration() uint32
+	// Expiration returns the expiration time in nanoseconds since the start of the clock.
+	Expiration() int64
 	// Cost returns the cost of the node.
 	Cost() uint32
+	// LastAccess returns the time of the last access to the node.
+	LastAccess() int64
+	// SetLastAccess sets the time of the last access to the node.
+	SetLastAccess(t int64)
+	// CreatedAt returns the time of the node creation.
+	CreatedAt() int64
 	// IsAlive returns true if the entry is available in the hash-table.
 	IsAlive() bool
 	// Die sets the node to the dead state.
 	Die()
+	// MarkExpired marks the alive node whose removal has been requested after the expiration.
+	// It returns true only for the first call, so the removal is requested once.
+	MarkExpired() bool
 	// Frequency returns the frequency of the node.
 	Frequency() uint8
 	// IncrementFrequency increments the frequency of the node.
@@ -88,10 +101,12 @@ func Equals[K comparable, V any](a, b Node[K, V]) bool {
 type Config struct {
 	WithExpiration bool
 	WithCost       bool
+	WithLastAccess bool
+	WithCreatedAt  bool
 }
 
 type Manager[K comparable, V any] struct {
-	create      func(key K, value V, expiration, cost uint32) Node[K, V]
+	create      func(key K, value V, expiration int64, cost uint32) Node[K, V]
 	fromPointer func(ptr unsafe.Pointer) Node[K, V]
 }
 
@@ -104,10 +119,52 @@ func NewManager[K comparable, V any](c Config) *Manager[K, V] {
 	if c.WithCost {
 		sb.WriteString("c")
 	}
+	if c.WithLastAccess {
+		sb.WriteString("a")
+	}
+	if c.WithCreatedAt {
+		sb.WriteString("i")
+	}
 	nodeType := sb.String()
 	m := &Manager[K, V]{}
 
 	switch nodeType {
+	case "becai":
+		m.create = NewBECAI[K, V]
+		m.fromPointer = CastPointerToBECAI[K, V]
+	case "bcai":
+		m.create = NewBCAI[K, V]
+		m.fromPointer = CastPointerToBCAI[K, V]
+	case "beai":
+		m.create = NewBEAI[K, V]
+		m.fromPointer = CastPointerToBEAI[K, V]
+	case "bai":
+		m.create = NewBAI[K, V]
+		m.fromPointer = CastPointerToBAI[K, V]
+	case "beci":
+		m.create = NewBECI[K, V]
+		m.fromPointer = CastPointerToBECI[K, V]
+	case "bci":
+		m.create = NewBCI[K, V]
+		m.fromPointer = CastPointerToBCI[K, V]
+	case "bei":
+		m.create = NewBEI[K, V]
+		m.fromPointer = CastPointerToBEI[K, V]
+	case "bi":
+		m.create = NewBI[K, V]
+		m.fromPointer = CastPointerToBI[K, V]
+	case "beca":
+		m.create = NewBECA[K, V]
+		m.fromPointer = CastPointerToBECA[K, V]
+	case "bca":
+		m.create = NewBCA[K, V]
+		m.fromPointer = CastPointerToBCA[K, V]
+	case "bea":
+		m.create = NewBEA[K, V]
+		m.fromPointer = CastPointerToBEA[K, V]
+	case "ba":
+		m.create = NewBA[K, V]
+		m.fromPointer = CastPointerToBA[K, V]
 	case "bec":
 		m.create = NewBEC[K, V]
 		m.fromPointer = CastPointerToBEC[K, V]
@@ -126,7 +183,7 @@ func NewManager[K comparable, V any](c Config) *Manager[K, V] {
 	return m
 }
 
-func (m *Manager[K, V]) Create(key K, value V, expiration, cost uint32) Node[K, V] {
+func (m *Manager[K, V]) Create(key K, value V, expiration int64, cost uint32) Node[K, V] {
 	return m.create(key, value, expiration, cost)
 }
 
@@ -27,15 +27,14 @@ type resizeHint int
 const (
 	growHint   resizeHint = 0
 	shrinkHint resizeHint = 1
-	clearHint  resizeHint = 2
 )
 
 const (
 	// number of entries per bucket
 	// 3 because we need to fit them into 1 cache line (64 bytes).
 	bucketSize = 3
-	// percentage at which the map will be expanded.
-	loadFactor = 0.75
+	// DefaultLoadFactor is the default percentage at which the map will be expanded.
+	DefaultLoadFactor = 0.75
 	// threshold fraction of table occupation to start a table shrinking
 	// when deleting the last entry in a bucket chain.
 	shrinkFraction   = 128
@@ -43,6 +42,9 @@ const (
 	minNodeCount     = bucketSize * minBucketCount
 	minCounterLength = 8
 	maxCounterLength = 32
+	// the scan cursor keeps the bucket index in the high 32 bits and the offset within the bucket in the low ones.
+	scanBucketShift = 32
+	scanOffsetMask  = 1<<scanBucketShift - 1
 )
 
 // Map is like a Go map[K]V but is safe for concurrent
@@ -70,6 +72,11 @@ type Map[K comparable, V any] struct {
 	resizeCond sync.Cond
 	// resize in progress flag; updated atomically
 	resizing atomic.Int64
+	// percentage at which the map will be expanded.
+	loadFactor float64
+	// number of completed grows and shrinks of the table
+	grows   atomic.Int64
+	shrinks atomic.Int64
 }
 
 type table[K comparable] struct {
@@ -80,6 +87,8 @@ type table[K comparable] struct {
 	size   []paddedCounter
 	mask   uint64
 	hasher maphash.Hasher[K]
+	// hash is the user-defined hash function used instead of the hasher if it is set.
+	hash func(key K) uint64
 }
 
 func (t *table[K]) addSize(bucketIdx uint64, delta int) {
@@ -101,8 +110,13 @@ func (t *table[K]) sumSize() int64 {
 }
 
 func (t *table[K]) calcShiftHash(key K) uint64 {
+	var h uint64
+	if t.hash != nil {
+		h = t.hash(key)
+	} else {
+		h = t.hasher.Hash(key)
+	}
 	// uint64(0) is a reserved value which stands for an empty slot.
-	h := t.hasher.Hash(key)
 	if h == uint64(0) {
 		return 1
 	}
@@ -122,34 +136,55 @@ type paddedCounter struct {
 }
 
 // NewWithSize creates a new Map instance with capacity enough
-// to hold size nodes. If size is zero or negative, the value
+// to hold size nodes without exceeding the load factor. If size is zero or negative, the value
 // is ignored.
-func NewWithSize[K comparable, V any](nodeManager *node.Manager[K, V], size int) *Map[K, V] {
-	return newMap[K, V](nodeManager, size)
+//
+// The load factor is the occupancy of the table at which the map will be expanded. The lower load factor
+// means fewer collisions and shorter bucket chains at the cost of the memory, and the higher one
+// packs the nodes tighter.
+func NewWithSize[K comparable, V any](nodeManager *node.Manager[K, V], size int, loadFactor float64) *Map[K, V] {
+	return newMap[K, V](nodeManager, size, loadFactor, nil)
 }
 
-// New creates a new Map instance.
-func New[K comparable, V any](nodeManager *node.Manager[K, V]) *Map[K, V] {
-	return newMap[K, V](nodeManager, minNodeCount)
+// New creates a new Map instance with the given load factor.
+func New[K comparable, V any](nodeManager *node.Manager[K, V], loadFactor float64) *Map[K, V] {
+	return newMap[K, V](nodeManager, minNodeCount, loadFactor, nil)
 }
 
-func newMap[K comparable, V any](nodeManager *node.Manager[K, V], size int) *Map[K, V] {
+// NewWithHasher creates a new Map instance like NewWithSize that hashes the keys with the given function
+// instead of the default seeded hasher. If hash is nil, the default hasher is used.
+func NewWithHasher[K comparable, V any](
+	nodeManager *node.Manager[K, V],
+	size int,
+	loadFactor float64,
+	hash func(key K) uint64,
+) *Map[K, V] {
+	return newMap[K, V](nodeManager, size, loadFactor, hash)
+}
+
+func newMap[K comparable, V any](
+	nodeManager *node.Manager[K, V],
+	size int,
+	loadFactor float64,
+	hash func(key K) uint64,
+) *Map[K, V] {
 	m := &Map[K, V]{
 		nodeManager: nodeManager,
+		loadFactor:  loadFactor,
 	}
 	m.resizeCond = *sync.NewCond(&m.resizeMutex)
 	var t *table[K]
 	if size <= minNodeCount {
-		t = newTable(minBucketCount, maphash.NewHasher[K]())
+		t = newTable(minBucketCount, maphash.NewHasher[K](), hash)
 	} else {
-		bucketCount := xmath.RoundUpPowerOf2(uint32(size / bucketSize))
-		t = newTable(int(bucketCount), maphash.NewHasher[K]())
+		bucketCount := xmath.RoundUpPowerOf2(uint32(float64(size) / (bucketSize * loadFactor)))
+		t = newTable(int(bucketCount), maphash.NewHasher[K](), hash)
 	}
 	atomic.StorePointer(&m.table, unsafe.Pointer(t))
 	return m
 }
 
-func newTable[K comparable](bucketCount int, prevHasher maphash.Hasher[K]) *table[K] {
+func newTable[K comparable](bucketCount int, prevHasher maphash.Hasher[K], hash func(key K) uint64) *table[K] {
 	buckets := make([]paddedBucket, bucketCount)
 	counterLength := bucketCount >> 10
 	if counterLength < minCounterLength {
@@ -164,6 +199,7 @@ func newTable[K comparable](bucketCount int, prevHasher maphash.Hasher[K]) *tabl
 		size:    counter,
 		mask:    mask,
 		hasher:  maphash.NewSeed[K](prevHasher),
+		hash:    hash,
 	}
 	return t
 }
@@ -265,7 +301,7 @@ func (m *Map[K, V]) set(n node.Node[K, V], onlyIfAbsent bool) node.Node[K, V] {
 				if onlyIfAbsent {
 					// found node, drop set
 					rootBucket.mutex.Unlock()
-					return n
+					return prev
 				}
 				// in-place update.
 				// We get a copy of the value via an interface{} on each call,
@@ -286,7 +322,7 @@ func (m *Map[K, V]) set(n node.Node[K, V], onlyIfAbsent bool) node.Node[K, V] {
 					t.addSize(bucketIdx, 1)
 					return nil
 				}
-				growThreshold := float64(tableLen) * bucketSize * loadFactor
+				growThreshold := float64(tableLen) * bucketSize * m.loadFactor
 				if t.sumSize() > int64(growThreshold) {
 					// need to grow the table then go for another attempt.
 					rootBucket.mutex.Unlock()
@@ -411,12 +447,12 @@ func (m *Map[K, V]) resize(known *table[K], hint resizeHint) {
 	switch hint {
 	case growHint:
 		// grow the table with factor of 2.
-		nt = newTable(tableLen<<1, t.hasher)
+		nt = newTable(tableLen<<1, t.hasher, t.hash)
 	case shrinkHint:
 		shrinkThreshold := int64((tableLen * bucketSize) / shrinkFraction)
 		if tableLen > minBucketCount && t.sumSize() <= shrinkThreshold {
 			// shrink the table with factor of 2.
-			nt = newTable(tableLen>>1, t.hasher)
+			nt = newTable(tableLen>>1, t.hasher, t.hash)
 		} else {
 			// no need to shrink, wake up all waiters and give up.
 			m.resizeMutex.Lock()
@@ -425,26 +461,33 @@ func (m *Map[K, V]) resize(known *table[K], hint resizeHint) {
 			m.resizeMutex.Unlock()
 			return
 		}
-	case clearHint:
-		nt = newTable(minBucketCount, t.hasher)
 	default:
 		panic(fmt.Sprintf("unexpected resize hint: %d", hint))
 	}
-	// copy the data only if we're not clearing the hashtable.
-	if hint != clearHint {
-		for i := 0; i < tableLen; i++ {
-			copied := m.copyBuckets(&t.buckets[i], nt)
-			nt.addSizePlain(uint64(i), copied)
-		}
+	for i := 0; i < tableLen; i++ {
+		copied := m.copyBuckets(&t.buckets[i], nt)
+		nt.addSizePlain(uint64(i), copied)
 	}
 	// publish the new table and wake up all waiters.
 	atomic.StorePointer(&m.table, unsafe.Pointer(nt))
+	if hint == growHint {
+		m.grows.Add(1)
+	} else {
+		m.shrinks.Add(1)
+	}
 	m.resizeMutex.Lock()
 	m.resizing.Store(0)
 	m.resizeCond.Broadcast()
 	m.resizeMutex.Unlock()
 }
 
+// Resizes returns the number of times the table has been grown and shrunk.
+//
+// Each resize rehashes all the nodes of the table, so the concurrent modifications wait for it to finish.
+func (m *Map[K, V]) Resizes() (grows, shrinks int64) {
+	return m.grows.Load(), m.shrinks.Load()
+}
+
 func (m *Map[K, V]) copyBuckets(b *paddedBucket, dest *table[K]) (copied int) {
 	rootBucket := b
 	rootBucket.mutex.Lock()
@@ -497,11 +540,14 @@ func (m *Map[K, V]) waitForResize() {
 // concurrent modification rule apply, i.e. the changes may be not
 // reflected in the subsequently iterated nodes.
 func (m *Map[K, V]) Range(f func(node.Node[K, V]) bool) {
+	t := (*table[K])(atomic.LoadPointer(&m.table))
+	m.rangeTable(t, f)
+}
+
+func (m *Map[K, V]) rangeTable(t *table[K], f func(node.Node[K, V]) bool) {
 	var zeroPtr unsafe.Pointer
 	// Pre-allocate array big enough to fit nodes for most hash tables.
 	buffer := make([]unsafe.Pointer, 0, 16*bucketSize)
-	tp := atomic.LoadPointer(&m.table)
-	t := *(*table[K])(tp)
 	for i := range t.buckets {
 		rootBucket := &t.buckets[i]
 		b := rootBucket
@@ -533,10 +579,131 @@ func (m *Map[K, V]) Range(f func(node.Node[K, V]) bool) {
 	}
 }
 
+// Scan calls f sequentially for the nodes starting from the position encoded by cursor until f returns false,
+// and returns the cursor of the first node f hasn't been called for. The zero cursor is the start
+// of the traversal, and Scan returns the zero cursor when the traversal is complete.
+//
+// The cursor encodes the bucket index and the offset of the node within the bucket, so the traversal
+// is best-effort if the map is modified or resized between the calls: the nodes may be skipped or
+// visited more than once.
+func (m *Map[K, V]) Scan(cursor uint64, f func(node.Node[K, V]) bool) uint64 {
+	t := (*table[K])(atomic.LoadPointer(&m.table))
+	offset := int(cursor & scanOffsetMask)
+	buffer := make([]unsafe.Pointer, 0, 16*bucketSize)
+	for i := int(cursor >> scanBucketShift); i < len(t.buckets); i++ {
+		rootBucket := &t.buckets[i]
+		b := rootBucket
+		rootBucket.mutex.Lock()
+		for {
+			for j := 0; j < bucketSize; j++ {
+				if b.nodes[j] != nil {
+					buffer = append(buffer, b.nodes[j])
+				}
+			}
+			if b.next == nil {
+				rootBucket.mutex.Unlock()
+				break
+			}
+			b = (*paddedBucket)(b.next)
+		}
+
+		for j := offset; j < len(buffer); j++ {
+			if f(m.nodeManager.FromPointer(buffer[j])) {
+				continue
+			}
+			if j+1 < len(buffer) {
+				return uint64(i)<<scanBucketShift | uint64(j+1)
+			}
+			if i+1 < len(t.buckets) {
+				return uint64(i+1) << scanBucketShift
+			}
+			return 0
+		}
+		offset = 0
+		buffer = buffer[:0]
+	}
+	return 0
+}
+
+// RandomNode returns a pseudo-random node from the map using r as a source of randomness
+// or nil if the map is empty.
+//
+// The bucket chain is selected by r, skipping the empty ones, and the node is selected by r
+// among all the nodes of the chain, including the overflow buckets.
+func (m *Map[K, V]) RandomNode(r uint32) node.Node[K, V] {
+	t := (*table[K])(atomic.LoadPointer(&m.table))
+	bucketCount := len(t.buckets)
+	start := int(uint64(r) & t.mask)
+	// the slot is selected by the mixed bits of r, so that it doesn't depend on the bucket index.
+	pick := uint32((uint64(r) * 0x9e3779b97f4a7c15) >> 32)
+	for i := 0; i < bucketCount; i++ {
+		rootBucket := &t.buckets[(start+i)&(bucketCount-1)]
+		var found unsafe.Pointer
+		rootBucket.mutex.Lock()
+		count := uint32(0)
+		for b := rootBucket; b != nil; b = (*paddedBucket)(b.next) {
+			for j := 0; j < bucketSize; j++ {
+				if b.nodes[j] != nil {
+					count++
+				}
+			}
+		}
+		if count > 0 {
+			k := pick % count
+			for b := rootBucket; found == nil; b = (*paddedBucket)(b.next) {
+				for j := 0; j < bucketSize; j++ {
+					if b.nodes[j] == nil {
+						continue
+					}
+					if k == 0 {
+						found = b.nodes[j]
+						break
+					}
+					k--
+				}
+			}
+		}
+		rootBucket.mutex.Unlock()
+
+		if found != nil {
+			return m.nodeManager.FromPointer(found)
+		}
+	}
+	return nil
+}
+
 // Clear deletes all keys and values currently stored in the map.
 func (m *Map[K, V]) Clear() {
-	table := (*table[K])(atomic.LoadPointer(&m.table))
-	m.resize(table, clearHint)
+	m.ClearAndRange(nil)
+}
+
+// ClearAndRange deletes all keys and values currently stored in the map
+// and then calls f sequentially for each deleted node.
+//
+// The map is cleared by atomically replacing the table with an empty one, so it is safe
+// to call ClearAndRange concurrently with other operations. Concurrent writes either
+// get to the new table or are completed in the old table before f is called for its nodes.
+func (m *Map[K, V]) ClearAndRange(f func(node.Node[K, V])) {
+	// wait for all in-progress resizes, because they can copy the old nodes into the new table.
+	for !m.resizing.CompareAndSwap(0, 1) {
+		m.waitForResize()
+	}
+	t := (*table[K])(atomic.LoadPointer(&m.table))
+	nt := newTable(minBucketCount, t.hasher, t.hash)
+	// publish the new table and wake up all waiters.
+	atomic.StorePointer(&m.table, unsafe.Pointer(nt))
+	m.resizeMutex.Lock()
+	m.resizing.Store(0)
+	m.resizeCond.Broadcast()
+	m.resizeMutex.Unlock()
+
+	if f == nil {
+		return
+	}
+	m.rangeTable(t, func(n node.Node[K, V]) bool {
+		f(n)
+		return true
+	})
 }
 
 // Size returns current size of the map.
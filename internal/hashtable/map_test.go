@@ -43,7 +43,7 @@ func TestMap_PaddedBucketSize(t *testing.T) {
 
 func TestMap_EmptyStringKey(t *testing.T) {
 	nm := node.NewManager[string, string](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	m.Set(nm.Create("", "foobar", 0, 1))
 	n, ok := m.Get("")
 	if !ok {
@@ -56,7 +56,7 @@ func TestMap_EmptyStringKey(t *testing.T) {
 
 func TestMap_SetNilValue(t *testing.T) {
 	nm := node.NewManager[string, *struct{}](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	m.Set(nm.Create("foo", nil, 0, 1))
 	n, ok := m.Get("foo")
 	if !ok {
@@ -70,7 +70,7 @@ func TestMap_SetNilValue(t *testing.T) {
 func TestMap_Set(t *testing.T) {
 	const numberOfNodes = 128
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numberOfNodes; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -88,7 +88,7 @@ func TestMap_Set(t *testing.T) {
 func TestMap_SetIfAbsent(t *testing.T) {
 	const numberOfNodes = 128
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numberOfNodes; i++ {
 		res := m.SetIfAbsent(nm.Create(strconv.Itoa(i), i, 0, 1))
 		if res != nil {
@@ -96,11 +96,14 @@ func TestMap_SetIfAbsent(t *testing.T) {
 		}
 	}
 	for i := 0; i < numberOfNodes; i++ {
-		n := nm.Create(strconv.Itoa(i), i, 0, 1)
+		n := nm.Create(strconv.Itoa(i), i+1, 0, 1)
 		res := m.SetIfAbsent(n)
 		if res == nil {
 			t.Fatalf("set was not dropped. node that was set: %+v", res)
 		}
+		if res.Value() != i {
+			t.Fatalf("the resident node should be returned for %d, but got %v", i, res.Value())
+		}
 	}
 
 	for i := 0; i < numberOfNodes; i++ {
@@ -123,7 +126,7 @@ type hasher struct {
 func TestMap_SetWithCollisions(t *testing.T) {
 	const numNodes = 1000
 	nm := node.NewManager[int, int](node.Config{})
-	m := NewWithSize(nm, numNodes)
+	m := NewWithSize(nm, numNodes, DefaultLoadFactor)
 	table := (*table[int])(atomic.LoadPointer(&m.table))
 	hasher := (*hasher)((unsafe.Pointer)(&table.hasher))
 	hasher.hash = func(ptr unsafe.Pointer, seed uintptr) uintptr {
@@ -145,10 +148,41 @@ func TestMap_SetWithCollisions(t *testing.T) {
 	}
 }
 
+func TestMap_Hasher(t *testing.T) {
+	const numNodes = 1000
+	nm := node.NewManager[int, int](node.Config{})
+	calls := 0
+	m := NewWithHasher(nm, 0, DefaultLoadFactor, func(key int) uint64 {
+		calls++
+		return uint64(key % 10)
+	})
+	for i := 0; i < numNodes; i++ {
+		m.Set(nm.Create(i, i, 0, 1))
+	}
+	if calls == 0 {
+		t.Fatal("the custom hash func should be used")
+	}
+	for i := 0; i < numNodes; i++ {
+		n, ok := m.Get(i)
+		if !ok {
+			t.Fatalf("value not found for %d", i)
+		}
+		if n.Value() != i {
+			t.Fatalf("values do not match for %d: %v", i, n.Value())
+		}
+	}
+	for i := 0; i < numNodes; i++ {
+		m.Delete(i)
+	}
+	if size := m.Size(); size != 0 {
+		t.Fatalf("map should be empty after the deletions, size: %d", size)
+	}
+}
+
 func TestMap_SetThenDelete(t *testing.T) {
 	const numberOfNodes = 1000
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numberOfNodes; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -160,10 +194,30 @@ func TestMap_SetThenDelete(t *testing.T) {
 	}
 }
 
+func TestMap_Resizes(t *testing.T) {
+	const numberOfNodes = 1000
+	nm := node.NewManager[int, int](node.Config{})
+	m := New(nm, DefaultLoadFactor)
+	for i := 0; i < numberOfNodes; i++ {
+		m.Set(nm.Create(i, i, 0, 1))
+	}
+	grows, shrinks := m.Resizes()
+	if grows == 0 || shrinks != 0 {
+		t.Fatalf("only grows were expected, got %d grows and %d shrinks", grows, shrinks)
+	}
+
+	for i := 0; i < numberOfNodes; i++ {
+		m.Delete(i)
+	}
+	if _, shrinks := m.Resizes(); shrinks == 0 {
+		t.Fatal("shrinks were expected after the deletion of all nodes")
+	}
+}
+
 func TestMap_Range(t *testing.T) {
 	const numNodes = 1000
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numNodes; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -190,7 +244,7 @@ func TestMap_Range(t *testing.T) {
 
 func TestMap_RangeFalseReturned(t *testing.T) {
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < 100; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -204,10 +258,48 @@ func TestMap_RangeFalseReturned(t *testing.T) {
 	}
 }
 
+func TestMap_Scan(t *testing.T) {
+	const numNodes = 1000
+	nm := node.NewManager[string, int](node.Config{})
+	m := New(nm, DefaultLoadFactor)
+	for i := 0; i < numNodes; i++ {
+		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
+	}
+
+	for _, pageSize := range []int{1, 7, numNodes} {
+		met := make(map[string]int)
+		pages := 0
+		var cursor uint64
+		for {
+			count := 0
+			cursor = m.Scan(cursor, func(n node.Node[string, int]) bool {
+				met[n.Key()]++
+				count++
+				return count < pageSize
+			})
+			if count > pageSize {
+				t.Fatalf("page %d has %d nodes, but the page size is %d", pages, count, pageSize)
+			}
+			pages++
+			if cursor == 0 {
+				break
+			}
+		}
+		if want := (numNodes + pageSize - 1) / pageSize; pages < want {
+			t.Fatalf("got %d pages, want at least %d", pages, want)
+		}
+		for i := 0; i < numNodes; i++ {
+			if c := met[strconv.Itoa(i)]; c != 1 {
+				t.Fatalf("scan with the page size %d did not iterate correctly over %d: %d", pageSize, i, c)
+			}
+		}
+	}
+}
+
 func TestMap_RangeNestedDelete(t *testing.T) {
 	const numNodes = 256
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numNodes; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -225,7 +317,7 @@ func TestMap_RangeNestedDelete(t *testing.T) {
 func TestMap_Size(t *testing.T) {
 	const numberOfNodes = 1000
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	size := m.Size()
 	if size != 0 {
 		t.Fatalf("zero size expected: %d", size)
@@ -249,10 +341,37 @@ func TestMap_Size(t *testing.T) {
 	}
 }
 
+func TestMap_LoadFactor(t *testing.T) {
+	const numberOfNodes = 10000
+	tableLen := func(loadFactor float64) int {
+		nm := node.NewManager[int, int](node.Config{})
+		m := New(nm, loadFactor)
+		for i := 0; i < numberOfNodes; i++ {
+			m.Set(nm.Create(i, i, 0, 1))
+		}
+		if size := m.Size(); size != numberOfNodes {
+			t.Fatalf("size of %d was expected, got: %d", numberOfNodes, size)
+		}
+		return len((*table[int])(atomic.LoadPointer(&m.table)).buckets)
+	}
+
+	sparse := tableLen(0.25)
+	dense := tableLen(1)
+	if sparse <= dense {
+		t.Fatalf("table with lower load factor should be larger, but got %d <= %d buckets", sparse, dense)
+	}
+
+	nm := node.NewManager[int, int](node.Config{})
+	m := NewWithSize(nm, numberOfNodes, 0.5)
+	if got := len((*table[int])(atomic.LoadPointer(&m.table)).buckets); float64(got*bucketSize)*0.5 < numberOfNodes {
+		t.Fatalf("table should fit %d nodes without exceeding the load factor, but got %d buckets", numberOfNodes, got)
+	}
+}
+
 func TestMap_Clear(t *testing.T) {
 	const numberOfNodes = 1000
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numberOfNodes; i++ {
 		m.Set(nm.Create(strconv.Itoa(i), i, 0, 1))
 	}
@@ -294,7 +413,7 @@ func TestMap_ParallelSets(t *testing.T) {
 	const iterations = 10_000
 	const nodes = 100
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(storers)
@@ -355,7 +474,7 @@ func TestMap_ParallelGet(t *testing.T) {
 	const iterations = 100_000
 	const nodes = 100
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(3)
@@ -371,7 +490,7 @@ func TestMap_ParallelSetsAndDeletes(t *testing.T) {
 	const iterations = 100_000
 	const nodes = 1000
 	nm := node.NewManager[string, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	wg := &sync.WaitGroup{}
 	wg.Add(2 * workers)
 	for i := 0; i < workers; i++ {
@@ -413,7 +532,7 @@ func parallelTypedRangeDeleter(t *testing.T, m *Map[int, int], numNodes int, sto
 func TestMap_ParallelRange(t *testing.T) {
 	const numNodes = 10_000
 	nm := node.NewManager[int, int](node.Config{})
-	m := New(nm)
+	m := New(nm, DefaultLoadFactor)
 	for i := 0; i < numNodes; i++ {
 		m.Set(nm.Create(i, i, 0, 1))
 	}
@@ -445,3 +564,32 @@ func TestMap_ParallelRange(t *testing.T) {
 	<-cdone
 	<-cdone
 }
+
+func TestMap_RandomNode(t *testing.T) {
+	nm := node.NewManager[int, int](node.Config{})
+	m := New(nm, DefaultLoadFactor)
+	if n := m.RandomNode(rand.Uint32()); n != nil {
+		t.Fatalf("empty map should not return nodes, but got %v", n)
+	}
+
+	const numEntries = 100
+	for i := 0; i < numEntries; i++ {
+		m.Set(nm.Create(i, i, 0, 1))
+	}
+
+	seen := make(map[int]bool)
+	for i := 0; i < 100*numEntries; i++ {
+		n := m.RandomNode(rand.Uint32())
+		if n == nil {
+			t.Fatal("node was expected")
+		}
+		if got, ok := m.Get(n.Key()); !ok || got.Value() != n.Value() {
+			t.Fatalf("random node should be in the map: %d", n.Key())
+		}
+		seen[n.Key()] = true
+	}
+	// the nodes in any slot of a bucket chain can be selected.
+	if len(seen) != numEntries {
+		t.Fatalf("random nodes should cover the map, but only %d of %d were seen", len(seen), numEntries)
+	}
+}
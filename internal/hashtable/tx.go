@@ -0,0 +1,171 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashtable
+
+import (
+	"sort"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+// Tx gives access to the keys locked by Map.Transact.
+//
+// Its methods must be called only for the locked keys and only inside the transaction function.
+type Tx[K comparable, V any] struct {
+	m       *Map[K, V]
+	t       *table[K]
+	isGrown bool
+}
+
+// Transact locks the buckets of all the given keys in the order of their indices, so that the concurrent
+// modifications of these keys wait for the transaction, calls f and releases the locks.
+//
+// f must not call other methods of the map, otherwise it can deadlock.
+func (m *Map[K, V]) Transact(keys []K, f func(tx *Tx[K, V])) {
+	for {
+		t := (*table[K])(atomic.LoadPointer(&m.table))
+		indices := make([]uint64, 0, len(keys))
+		for _, key := range keys {
+			indices = append(indices, t.calcShiftHash(key)&t.mask)
+		}
+		sort.Slice(indices, func(i, j int) bool {
+			return indices[i] < indices[j]
+		})
+		indices = unique(indices)
+
+		for _, idx := range indices {
+			t.buckets[idx].mutex.Lock()
+		}
+		// the following two checks must go in reverse to what's
+		// in the resize method.
+		if m.resizeInProgress() {
+			unlockBuckets(t, indices)
+			m.waitForResize()
+			continue
+		}
+		if m.newerTableExists(t) {
+			unlockBuckets(t, indices)
+			continue
+		}
+
+		tx := &Tx[K, V]{
+			m: m,
+			t: t,
+		}
+		func() {
+			defer unlockBuckets(t, indices)
+			f(tx)
+		}()
+
+		if tx.isGrown {
+			growThreshold := float64(len(t.buckets)) * bucketSize * m.loadFactor
+			if t.sumSize() > int64(growThreshold) {
+				m.resize(t, growHint)
+			}
+		}
+		return
+	}
+}
+
+func unique(sorted []uint64) []uint64 {
+	result := sorted[:0]
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func unlockBuckets[K comparable](t *table[K], indices []uint64) {
+	for i := len(indices) - 1; i >= 0; i-- {
+		t.buckets[indices[i]].mutex.Unlock()
+	}
+}
+
+// Get returns the node stored in the map for a key, or nil if no node is present.
+func (tx *Tx[K, V]) Get(key K) (node.Node[K, V], bool) {
+	hash := tx.t.calcShiftHash(key)
+	b := &tx.t.buckets[hash&tx.t.mask]
+	for {
+		for i := 0; i < bucketSize; i++ {
+			if b.hashes[i] != hash {
+				continue
+			}
+			n := tx.m.nodeManager.FromPointer(b.nodes[i])
+			if key == n.Key() {
+				return n, true
+			}
+		}
+		if b.next == nil {
+			return nil, false
+		}
+		b = (*paddedBucket)(b.next)
+	}
+}
+
+// Set sets the node for the key.
+//
+// Returns the replaced node or nil if the node was inserted.
+func (tx *Tx[K, V]) Set(n node.Node[K, V]) node.Node[K, V] {
+	var (
+		emptyBucket *paddedBucket
+		emptyIdx    int
+	)
+	hash := tx.t.calcShiftHash(n.Key())
+	bucketIdx := hash & tx.t.mask
+	b := &tx.t.buckets[bucketIdx]
+	for {
+		for i := 0; i < bucketSize; i++ {
+			h := b.hashes[i]
+			if h == uint64(0) {
+				if emptyBucket == nil {
+					emptyBucket = b
+					emptyIdx = i
+				}
+				continue
+			}
+			if h != hash {
+				continue
+			}
+			prev := tx.m.nodeManager.FromPointer(b.nodes[i])
+			if n.Key() != prev.Key() {
+				continue
+			}
+			atomic.StorePointer(&b.nodes[i], n.AsPointer())
+			return prev
+		}
+		if b.next == nil {
+			if emptyBucket != nil {
+				atomic.StoreUint64(&emptyBucket.hashes[emptyIdx], hash)
+				atomic.StorePointer(&emptyBucket.nodes[emptyIdx], n.AsPointer())
+				tx.t.addSize(bucketIdx, 1)
+				return nil
+			}
+			// the table can't be resized while the buckets are locked,
+			// so the growth is checked after the transaction.
+			newBucket := &paddedBucket{}
+			newBucket.hashes[0] = hash
+			newBucket.nodes[0] = n.AsPointer()
+			atomic.StorePointer(&b.next, unsafe.Pointer(newBucket))
+			tx.t.addSize(bucketIdx, 1)
+			tx.isGrown = true
+			return nil
+		}
+		b = (*paddedBucket)(b.next)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashtable
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+func TestMap_Transact(t *testing.T) {
+	nm := node.NewManager[int, int](node.Config{})
+	m := New(nm, DefaultLoadFactor)
+
+	const (
+		numKeys       = 4
+		numGoroutines = 8
+		numIncrements = 1000
+	)
+	keys := make([]int, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		keys = append(keys, i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numIncrements; i++ {
+				m.Transact(keys, func(tx *Tx[int, int]) {
+					for _, key := range keys {
+						value := 0
+						if n, ok := tx.Get(key); ok {
+							value = n.Value()
+						}
+						tx.Set(nm.Create(key, value+1, 0, 1))
+					}
+				})
+			}
+		}()
+	}
+	// concurrent inserts of other keys cause resizes.
+	for i := numKeys; i < 10_000; i++ {
+		m.Set(nm.Create(i, i, 0, 1))
+	}
+	wg.Wait()
+
+	for _, key := range keys {
+		n, ok := m.Get(key)
+		if !ok || n.Value() != numGoroutines*numIncrements {
+			t.Fatalf("lost updates for key %d: got %v", key, n)
+		}
+	}
+	if size := m.Size(); size != 10_000 {
+		t.Fatalf("size should be %d, but got %d", 10_000, size)
+	}
+}
@@ -58,6 +58,9 @@ type Buffer[K comparable, V any] struct {
 	policyBuffers        unsafe.Pointer
 	returnedSlicePadding [xruntime.CacheLineSize - 8]byte
 	buffer               [capacity]unsafe.Pointer
+	dropped              atomic.Int64
+	// pending is set when the returned buffer is left to be processed by another goroutine.
+	pending atomic.Bool
 }
 
 // New creates a new lossy Buffer.
@@ -82,41 +85,85 @@ func (b *Buffer[K, V]) Add(n node.Node[K, V]) *PolicyBuffers[K, V] {
 	size := tail - head
 	if size >= capacity {
 		// full buffer
-		return nil
+		b.dropped.Add(1)
+		// the producer that filled the buffer could not drain it if the returned buffer was taken
+		// at that moment, e.g. deferred, so the buffer is drained by the next producer instead.
+		return b.drain()
 	}
 	if b.tail.CompareAndSwap(tail, tail+1) {
 		// success
 		index := int(tail & mask)
 		atomic.StorePointer(&b.buffer[index], n.AsPointer())
 		if size == capacity-1 {
-			// try return new buffer
-			if !atomic.CompareAndSwapPointer(&b.returned, b.policyBuffers, nil) {
-				// somebody already get buffer
-				return nil
-			}
-
-			pb := (*PolicyBuffers[K, V])(b.policyBuffers)
-			for i := 0; i < capacity; i++ {
-				index := int(head & mask)
-				v := atomic.LoadPointer(&b.buffer[index])
-				if v != nil {
-					// published
-					pb.Returned = append(pb.Returned, b.nodeManager.FromPointer(v))
-					// release
-					atomic.StorePointer(&b.buffer[index], nil)
-				}
-				head++
-			}
-
-			b.head.Store(head)
-			return pb
+			return b.drain()
 		}
+		return nil
 	}
 
 	// failed
+	b.dropped.Add(1)
 	return nil
 }
 
+// drain takes the elements of the full buffer and returns them or nil if the returned buffer is taken
+// or the elements have already been drained by another producer.
+func (b *Buffer[K, V]) drain() *PolicyBuffers[K, V] {
+	// try return new buffer
+	if !atomic.CompareAndSwapPointer(&b.returned, b.policyBuffers, nil) {
+		// somebody already get buffer
+		return nil
+	}
+
+	// the head is changed only by the owner of the returned buffer, so it can't change until it is freed.
+	head := b.head.Load()
+	if b.tail.Load()-head < capacity {
+		atomic.StorePointer(&b.returned, b.policyBuffers)
+		return nil
+	}
+
+	pb := (*PolicyBuffers[K, V])(b.policyBuffers)
+	for i := 0; i < capacity; i++ {
+		index := int(head & mask)
+		v := atomic.LoadPointer(&b.buffer[index])
+		if v != nil {
+			// published
+			pb.Returned = append(pb.Returned, b.nodeManager.FromPointer(v))
+			// release
+			atomic.StorePointer(&b.buffer[index], nil)
+		}
+		head++
+	}
+
+	b.head.Store(head)
+	return pb
+}
+
+// DroppedCount returns the number of items lost due to contention or the full buffer.
+func (b *Buffer[K, V]) DroppedCount() int64 {
+	return b.dropped.Load()
+}
+
+// Defer leaves the returned buffer to be processed later by the goroutine that calls TakePending.
+// The caller must not use the returned buffer after Defer.
+func (b *Buffer[K, V]) Defer() {
+	b.pending.Store(true)
+}
+
+// HasPending reports whether there is a deferred buffer.
+func (b *Buffer[K, V]) HasPending() bool {
+	return b.pending.Load()
+}
+
+// TakePending returns the deferred buffer or nil if there is no such buffer.
+// The caller must process the buffer and call Free.
+func (b *Buffer[K, V]) TakePending() *PolicyBuffers[K, V] {
+	if !b.pending.CompareAndSwap(true, false) {
+		return nil
+	}
+
+	return (*PolicyBuffers[K, V])(b.policyBuffers)
+}
+
 // Free returns the processed buffer back and also clears it.
 func (b *Buffer[K, V]) Free() {
 	pb := (*PolicyBuffers[K, V])(b.policyBuffers)
@@ -130,6 +177,11 @@ func (b *Buffer[K, V]) Free() {
 // Clear clears the lossy Buffer and returns it to the default state.
 func (b *Buffer[K, V]) Clear() {
 	for !atomic.CompareAndSwapPointer(&b.returned, b.policyBuffers, nil) {
+		// the deferred buffer is never returned back by its producer.
+		if b.pending.CompareAndSwap(true, false) {
+			b.Free()
+			continue
+		}
 		runtime.Gosched()
 	}
 	for i := 0; i < capacity; i++ {
@@ -0,0 +1,99 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lossy
+
+import (
+	"testing"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+func TestBuffer_DroppedCount(t *testing.T) {
+	nm := node.NewManager[int, int](node.Config{})
+	b := New[int, int](nm)
+
+	if pb := fill(b, nm, capacity); pb == nil {
+		t.Fatal("the full buffer should be returned")
+	}
+	// the returned buffer is not freed, so the next full buffer can't be returned and the items are dropped.
+	fill(b, nm, capacity+3)
+
+	if dropped := b.DroppedCount(); dropped != 3 {
+		t.Fatalf("b.DroppedCount() = %d, want = 3", dropped)
+	}
+}
+
+func fill(b *Buffer[int, int], nm *node.Manager[int, int], count int) *PolicyBuffers[int, int] {
+	var pb *PolicyBuffers[int, int]
+	for i := 0; i < count; i++ {
+		if returned := b.Add(nm.Create(i, i, 0, 1)); returned != nil {
+			pb = returned
+		}
+	}
+	return pb
+}
+
+func TestBuffer_Defer(t *testing.T) {
+	nm := node.NewManager[int, int](node.Config{})
+	b := New[int, int](nm)
+
+	if pb := b.TakePending(); pb != nil {
+		t.Fatal("nothing should be pending")
+	}
+
+	pb := fill(b, nm, capacity)
+	if pb == nil {
+		t.Fatal("the full buffer should be returned")
+	}
+	b.Defer()
+
+	pending := b.TakePending()
+	if pending != pb || len(pending.Returned) != capacity {
+		t.Fatalf("the deferred buffer should be taken, got %v", pending)
+	}
+	if b.TakePending() != nil {
+		t.Fatal("the deferred buffer should be taken only once")
+	}
+	b.Free()
+
+	if pb := fill(b, nm, capacity); pb == nil {
+		t.Fatal("the freed buffer should be returned again")
+	}
+	b.Defer()
+	// the buffer fills up again while the batch is deferred, so its producer can't drain it.
+	if pb := fill(b, nm, 10*capacity); pb != nil {
+		t.Fatal("the buffer should not be returned while the batch is deferred")
+	}
+	b.TakePending()
+	b.Free()
+	// the next producer should drain the full buffer, otherwise the buffer is stuck and drops everything.
+	for i := 0; i < 10; i++ {
+		pb := fill(b, nm, capacity)
+		if pb == nil || len(pb.Returned) != capacity {
+			t.Fatalf("the buffer should be drained after the deferred batch is freed, got %v", pb)
+		}
+		b.Free()
+	}
+
+	if pb := fill(b, nm, capacity); pb == nil {
+		t.Fatal("the freed buffer should be returned again")
+	}
+	b.Defer()
+	// Clear should not wait for the deferred buffer to be freed by its producer.
+	b.Clear()
+	if b.TakePending() != nil {
+		t.Fatal("the deferred buffer should be dropped by Clear")
+	}
+}
@@ -97,6 +97,19 @@ func (g *Growable[T]) Clear() {
 	g.mutex.Unlock()
 }
 
+// Len returns the number of items in the queue.
+func (g *Growable[T]) Len() int {
+	g.mutex.Lock()
+	count := g.count
+	g.mutex.Unlock()
+	return count
+}
+
+// Cap returns the maximum number of items in the queue.
+func (g *Growable[T]) Cap() int {
+	return g.maxCap
+}
+
 func (g *Growable[T]) grow() {
 	if g.count != len(g.buf) {
 		return
@@ -69,6 +69,10 @@ func (g *ghost[K, V]) insert(deleted []node.Node[K, V], n node.Node[K, V]) []nod
 	return deleted
 }
 
+func (g *ghost[K, V]) length() int {
+	return g.q.Len()
+}
+
 func (g *ghost[K, V]) clear() {
 	g.q.Clear()
 	g.m.Clear()
@@ -64,6 +64,56 @@ func (m *main[K, V]) evict(deleted []node.Node[K, V]) []node.Node[K, V] {
 	return deleted
 }
 
+// evictLRU evicts the head of the queue regardless of its frequency.
+func (m *main[K, V]) evictLRU(deleted []node.Node[K, V]) []node.Node[K, V] {
+	n := m.q.pop()
+	if node.Equals(n, nil) {
+		return deleted
+	}
+
+	n.Unmark()
+	m.cost -= n.Cost()
+	return append(deleted, n)
+}
+
+// moveToTail moves the node to the tail of the queue making it the most recently used one.
+func (m *main[K, V]) moveToTail(n node.Node[K, V]) {
+	m.q.remove(n)
+	m.q.push(n)
+}
+
+// lruCandidates appends to the result the nodes from the head of the queue.
+func (m *main[K, V]) lruCandidates(result []node.Node[K, V], limit int) []node.Node[K, V] {
+	for n := m.q.head; !node.Equals(n, nil) && len(result) < limit; n = n.Next() {
+		result = append(result, n)
+	}
+	return result
+}
+
+// candidates appends to the result the nodes that would be evicted from the queue without reinsertion.
+func (m *main[K, V]) candidates(result []node.Node[K, V], limit int) []node.Node[K, V] {
+	for n := m.q.head; !node.Equals(n, nil) && len(result) < limit; n = n.Next() {
+		if !n.IsAlive() || n.IsExpired() || n.Frequency() == 0 {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// evictLive removes the live nodes from the head of the queue regardless of their frequencies
+// until the deleted contains limit nodes.
+func (m *main[K, V]) evictLive(deleted []node.Node[K, V], limit int) []node.Node[K, V] {
+	for n := m.q.head; !node.Equals(n, nil) && len(deleted) < limit; {
+		next := n.Next()
+		if n.IsAlive() && !n.IsExpired() {
+			m.remove(n)
+			deleted = append(deleted, n)
+		}
+		n = next
+	}
+	return deleted
+}
+
 func (m *main[K, V]) remove(n node.Node[K, V]) {
 	m.cost -= n.Cost()
 	n.Unmark()
@@ -26,6 +26,8 @@ type Policy[K comparable, V any] struct {
 	ghost                *ghost[K, V]
 	maxCost              uint32
 	maxAvailableNodeCost uint32
+	lru                  bool
+	added                uint64
 }
 
 // NewPolicy creates a new Policy.
@@ -47,15 +49,55 @@ func NewPolicy[K comparable, V any](maxCost uint32) *Policy[K, V] {
 	}
 }
 
+// NewLRUPolicy creates a new Policy that keeps all nodes in the main queue in the order of recency
+// and evicts the least recently used node instead of using S3-FIFO. The order is as exact as the reads
+// passed to Read, so it is an approximate LRU when the reads are sampled or dropped by the caller.
+func NewLRUPolicy[K comparable, V any](maxCost uint32) *Policy[K, V] {
+	p := NewPolicy[K, V](maxCost)
+	p.main.maxCost = maxCost
+	p.maxAvailableNodeCost = maxCost
+	p.lru = true
+	return p
+}
+
+// NewLFUPolicy creates a new Policy that leans towards the frequency of the accesses rather than
+// the scan resistance. The small queue takes 1% of the capacity instead of 10%, a repeated access
+// is enough to move a node from the small queue to the main one and the node keeps its frequency there,
+// so most of the capacity is given to the frequently used nodes.
+//
+// It suits the stable working sets with long-lived hot entries.
+func NewLFUPolicy[K comparable, V any](maxCost uint32) *Policy[K, V] {
+	p := NewPolicy[K, V](maxCost)
+	p.small.lfu = true
+	p.small.maxCost = maxCost / 100
+	if p.small.maxCost == 0 {
+		p.small.maxCost = 1
+	}
+	p.main.maxCost = maxCost - p.small.maxCost
+	return p
+}
+
 // Read updates the eviction policy based on node accesses.
 func (p *Policy[K, V]) Read(nodes []node.Node[K, V]) {
 	for _, n := range nodes {
 		n.IncrementFrequency()
+		if p.lru && n.IsMain() {
+			p.main.moveToTail(n)
+		}
 	}
 }
 
 // Add adds node to the eviction policy.
 func (p *Policy[K, V]) Add(deleted []node.Node[K, V], n node.Node[K, V]) []node.Node[K, V] {
+	if p.lru {
+		p.main.insert(n)
+		for p.isFull() {
+			deleted = p.main.evictLRU(deleted)
+		}
+		return deleted
+	}
+
+	p.added++
 	if p.ghost.isGhost(n) {
 		p.main.insert(n)
 		n.ResetFrequency()
@@ -71,7 +113,7 @@ func (p *Policy[K, V]) Add(deleted []node.Node[K, V], n node.Node[K, V]) []node.
 }
 
 func (p *Policy[K, V]) evict(deleted []node.Node[K, V]) []node.Node[K, V] {
-	if p.small.cost >= p.maxCost/10 {
+	if p.small.cost >= p.small.maxCost {
 		return p.small.evict(deleted)
 	}
 
@@ -94,11 +136,78 @@ func (p *Policy[K, V]) Delete(n node.Node[K, V]) {
 	}
 }
 
+// NextEvictions returns at most n nodes that are most likely to be evicted next in the order of eviction.
+//
+// It doesn't modify the policy, so the result is an estimation: the frequencies of the nodes
+// can change before the next eviction.
+func (p *Policy[K, V]) NextEvictions(n int) []node.Node[K, V] {
+	if n <= 0 {
+		return nil
+	}
+	if size := p.small.length() + p.main.length(); n > size {
+		n = size
+	}
+
+	result := make([]node.Node[K, V], 0, n)
+	if p.lru {
+		return p.main.lruCandidates(result, n)
+	}
+	if p.small.cost >= p.small.maxCost {
+		result = p.small.candidates(result, n)
+		return p.main.candidates(result, n)
+	}
+
+	result = p.main.candidates(result, n)
+	return p.small.candidates(result, n)
+}
+
+// Evict removes at most n live nodes from the policy in the order of eviction regardless of their frequencies
+// and appends them to deleted. The dead and expired nodes are left to their delete tasks and the cleanup.
+func (p *Policy[K, V]) Evict(deleted []node.Node[K, V], n int) []node.Node[K, V] {
+	if n <= 0 {
+		return deleted
+	}
+	if size := p.small.length() + p.main.length(); n > size {
+		n = size
+	}
+
+	limit := len(deleted) + n
+	if p.lru {
+		return p.main.evictLive(deleted, limit)
+	}
+	if p.small.cost >= p.small.maxCost {
+		deleted = p.small.evictLive(deleted, limit)
+		return p.main.evictLive(deleted, limit)
+	}
+
+	deleted = p.main.evictLive(deleted, limit)
+	return p.small.evictLive(deleted, limit)
+}
+
+// Cost returns the total cost of the nodes in the policy.
+func (p *Policy[K, V]) Cost() uint32 {
+	return p.small.cost + p.main.cost
+}
+
 // MaxAvailableCost returns the maximum available cost of the node.
 func (p *Policy[K, V]) MaxAvailableCost() uint32 {
 	return p.maxAvailableNodeCost
 }
 
+// AdmissionStats returns the number of nodes added to the policy and the number of live nodes rejected
+// by the admission filter, i.e. evicted from the small queue without being promoted to the main queue.
+//
+// The counters are cumulative and are not reset by Clear.
+func (p *Policy[K, V]) AdmissionStats() (added, rejected uint64) {
+	return p.added, p.small.rejected
+}
+
+// QueueSizes returns the current number of nodes in the small and main queues and the number of keys
+// remembered by the ghost queue.
+func (p *Policy[K, V]) QueueSizes() (small, main, ghost int) {
+	return p.small.length(), p.main.length(), p.ghost.length()
+}
+
 // Clear clears the eviction policy and returns it to the default state.
 func (p *Policy[K, V]) Clear() {
 	p.ghost.clear()
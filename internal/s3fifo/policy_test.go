@@ -15,6 +15,8 @@
 package s3fifo
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/maypok86/otter/internal/generated/node"
@@ -128,3 +130,192 @@ func TestPolicy_Update(t *testing.T) {
 		t.Fatalf("updated node should be evicted: %+v", n3)
 	}
 }
+
+func TestPolicy_NextEvictions(t *testing.T) {
+	p := NewPolicy[int, int](10)
+
+	nodes := make([]node.Node[int, int], 0, 5)
+	for i := 0; i < cap(nodes); i++ {
+		n := newNode(i)
+		nodes = append(nodes, n)
+		p.Add(nil, n)
+	}
+	p.Read(nodes[:2])
+	p.Read(nodes[:2])
+
+	candidates := p.NextEvictions(10)
+	if len(candidates) != 3 {
+		t.Fatalf("got unexpected number of candidates: %d", len(candidates))
+	}
+	for i, n := range candidates {
+		if n.Key() != i+2 {
+			t.Fatalf("got unexpected candidate: %+v", n)
+		}
+	}
+	if candidates := p.NextEvictions(1); len(candidates) != 1 || candidates[0].Key() != 2 {
+		t.Fatalf("got unexpected candidates: %+v", candidates)
+	}
+	if candidates := p.NextEvictions(math.MaxInt); len(candidates) != 3 {
+		t.Fatalf("the candidates should be bounded by the policy size, but got %d", len(candidates))
+	}
+	if !nodes[0].IsSmall() || nodes[0].Frequency() != 2 {
+		t.Fatalf("policy should not be modified: %+v", nodes[0])
+	}
+}
+
+func TestPolicy_Evict(t *testing.T) {
+	p := NewPolicy[int, int](10)
+
+	nodes := make([]node.Node[int, int], 0, 5)
+	for i := 0; i < cap(nodes); i++ {
+		n := newNode(i)
+		nodes = append(nodes, n)
+		p.Add(nil, n)
+	}
+	p.Read(nodes)
+	p.Read(nodes)
+	nodes[0].Die()
+
+	deleted := p.Evict(nil, 3)
+	if len(deleted) != 3 {
+		t.Fatalf("the hot nodes should be evicted, but got %d nodes", len(deleted))
+	}
+	for i, n := range deleted {
+		if n.Key() != i+1 || n.IsSmall() || n.IsMain() {
+			t.Fatalf("got unexpected evicted node: %+v", n)
+		}
+	}
+	if p.Cost() != 2 || !nodes[0].IsSmall() {
+		t.Fatalf("the dead node should be left in the policy, cost: %d", p.Cost())
+	}
+}
+
+func TestPolicy_LRU(t *testing.T) {
+	p := NewLRUPolicy[int, int](3)
+
+	nodes := make([]node.Node[int, int], 0, 4)
+	for i := 0; i < cap(nodes); i++ {
+		nodes = append(nodes, newNode(i))
+	}
+
+	for _, n := range nodes[:3] {
+		if deleted := p.Add(nil, n); len(deleted) != 0 {
+			t.Fatalf("nothing should be evicted, but got %d nodes", len(deleted))
+		}
+	}
+
+	// 0 becomes the most recently used node, so 1 is the next victim.
+	p.Read(nodes[:1])
+	if next := p.NextEvictions(1); len(next) != 1 || next[0].Key() != 1 {
+		t.Fatalf("the least recently used node should be the next victim, but got %v", next)
+	}
+
+	deleted := p.Add(nil, nodes[3])
+	if len(deleted) != 1 || deleted[0].Key() != 1 {
+		t.Fatalf("the least recently used node should be evicted, but got %v", deleted)
+	}
+	if !nodes[0].IsMain() || !nodes[3].IsMain() {
+		t.Fatal("all nodes should be in the main queue")
+	}
+}
+
+func TestPolicy_AdmissionStats(t *testing.T) {
+	p := NewPolicy[int, int](10)
+
+	for i := 0; i < 20; i++ {
+		p.Add(nil, newNode(i))
+	}
+
+	added, rejected := p.AdmissionStats()
+	if added != 20 {
+		t.Fatalf("added = %d, want = 20", added)
+	}
+	if rejected != 10 {
+		t.Fatalf("the nodes that were never read should be rejected, rejected = %d, want = 10", rejected)
+	}
+}
+
+func TestPolicy_QueueSizes(t *testing.T) {
+	p := NewPolicy[int, int](10)
+
+	nodes := make([]node.Node[int, int], 0, 20)
+	for i := 0; i < 20; i++ {
+		n := newNode(i)
+		nodes = append(nodes, n)
+		p.Add(nil, n)
+	}
+	if small, main, ghost := p.QueueSizes(); small != 10 || main != 0 || ghost != 10 {
+		t.Fatalf("the nodes that were never read should be in small and ghost queues, got %d, %d, %d", small, main, ghost)
+	}
+
+	p.Read(nodes[10:12])
+	p.Read(nodes[10:12])
+	p.Add(nil, newNode(20))
+	if small, main, _ := p.QueueSizes(); small+main != 10 || main == 0 {
+		t.Fatalf("the read nodes should be promoted to the main queue, got %d small and %d main", small, main)
+	}
+
+	p.Clear()
+	if small, main, ghost := p.QueueSizes(); small != 0 || main != 0 || ghost != 0 {
+		t.Fatalf("queues should be empty after clear, got %d, %d, %d", small, main, ghost)
+	}
+}
+
+func zipfTrace(length int) []int {
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, 1_000_000)
+	trace := make([]int, length)
+	for i := range trace {
+		trace[i] = int(z.Uint64())
+	}
+	return trace
+}
+
+func hitRatio(p *Policy[int, int], trace []int) float64 {
+	m := node.NewManager[int, int](node.Config{})
+	nodes := make(map[int]node.Node[int, int])
+	hits := 0
+	for _, k := range trace {
+		if n, ok := nodes[k]; ok {
+			hits++
+			p.Read([]node.Node[int, int]{n})
+			continue
+		}
+
+		n := m.Create(k, k, 0, 1)
+		nodes[k] = n
+		for _, d := range p.Add(nil, n) {
+			delete(nodes, d.Key())
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func TestPolicy_LFU(t *testing.T) {
+	trace := zipfTrace(500_000)
+
+	s3fifo := hitRatio(NewPolicy[int, int](1000), trace)
+	lfu := hitRatio(NewLFUPolicy[int, int](1000), trace)
+	if lfu <= s3fifo {
+		t.Fatalf("lfu hit ratio should be higher on zipf trace, but lfu = %.4f, s3fifo = %.4f", lfu, s3fifo)
+	}
+}
+
+func BenchmarkPolicy_ZipfHitRatio(b *testing.B) {
+	trace := zipfTrace(1_000_000)
+	for _, bench := range []struct {
+		name   string
+		policy func() *Policy[int, int]
+	}{
+		{name: "s3fifo", policy: func() *Policy[int, int] { return NewPolicy[int, int](1000) }},
+		{name: "lfu", policy: func() *Policy[int, int] { return NewLFUPolicy[int, int](1000) }},
+		{name: "lru", policy: func() *Policy[int, int] { return NewLRUPolicy[int, int](1000) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var ratio float64
+			for i := 0; i < b.N; i++ {
+				ratio = hitRatio(bench.policy(), trace)
+			}
+			b.ReportMetric(ratio*100, "hit%")
+		})
+	}
+}
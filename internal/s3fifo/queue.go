@@ -70,6 +70,7 @@ func (q *queue[K, V]) remove(n node.Node[K, V]) {
 
 func (q *queue[K, V]) clear() {
 	for !q.isEmpty() {
-		q.pop()
+		// the nodes are unmarked, so that the stale tasks and reads of them are ignored by the policy.
+		q.pop().Unmark()
 	}
 }
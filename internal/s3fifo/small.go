@@ -24,6 +24,11 @@ type small[K comparable, V any] struct {
 	ghost   *ghost[K, V]
 	cost    uint32
 	maxCost uint32
+	// rejected is the number of live nodes evicted without being promoted to the main queue.
+	rejected uint64
+	// lfu makes a single repeated access enough for the promotion and keeps the frequency
+	// of the promoted node, so the frequently used nodes settle in the main queue.
+	lfu bool
 }
 
 func newSmall[K comparable, V any](
@@ -57,18 +62,53 @@ func (s *small[K, V]) evict(deleted []node.Node[K, V]) []node.Node[K, V] {
 		return append(deleted, n)
 	}
 
-	if n.Frequency() > 1 {
+	if n.Frequency() > s.promotionThreshold() {
 		s.main.insert(n)
 		for s.main.isFull() {
 			deleted = s.main.evict(deleted)
 		}
-		n.ResetFrequency()
+		if !s.lfu {
+			n.ResetFrequency()
+		}
 		return deleted
 	}
 
+	s.rejected++
 	return s.ghost.insert(deleted, n)
 }
 
+// candidates appends to the result the nodes that would be evicted from the queue instead of being moved to main.
+func (s *small[K, V]) candidates(result []node.Node[K, V], limit int) []node.Node[K, V] {
+	for n := s.q.head; !node.Equals(n, nil) && len(result) < limit; n = n.Next() {
+		if !n.IsAlive() || n.IsExpired() || n.Frequency() <= s.promotionThreshold() {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// evictLive removes the live nodes from the head of the queue regardless of their frequencies
+// until the deleted contains limit nodes. The removed nodes are neither promoted nor remembered by the ghost queue.
+func (s *small[K, V]) evictLive(deleted []node.Node[K, V], limit int) []node.Node[K, V] {
+	for n := s.q.head; !node.Equals(n, nil) && len(deleted) < limit; {
+		next := n.Next()
+		if n.IsAlive() && !n.IsExpired() {
+			s.remove(n)
+			deleted = append(deleted, n)
+		}
+		n = next
+	}
+	return deleted
+}
+
+// promotionThreshold returns the frequency that the node has to exceed to be moved to main.
+func (s *small[K, V]) promotionThreshold() uint8 {
+	if s.lfu {
+		return 0
+	}
+	return 1
+}
+
 func (s *small[K, V]) remove(n node.Node[K, V]) {
 	s.cost -= n.Cost()
 	n.Unmark()
@@ -0,0 +1,192 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sampled
+
+import (
+	"sort"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+// DefaultSampleSize is the default number of nodes sampled to select a victim.
+const DefaultSampleSize = 5
+
+// maxAttemptsFactor limits the number of attempts to find a node in the policy to sampleSize*maxAttemptsFactor.
+const maxAttemptsFactor = 4
+
+// Policy is an approximate LRU eviction policy: to select a victim it samples several random nodes
+// and evicts the least recently accessed one, like Redis does.
+//
+// It doesn't maintain any queues, so it requires the nodes to track the last access time.
+type Policy[K comparable, V any] struct {
+	randomNode func() node.Node[K, V]
+	sampleSize int
+	len        int
+	cost       uint32
+	maxCost    uint32
+}
+
+// NewPolicy creates a new Policy. randomNode should return a random node of the cache or nil if the cache is empty.
+func NewPolicy[K comparable, V any](maxCost uint32, sampleSize int, randomNode func() node.Node[K, V]) *Policy[K, V] {
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	return &Policy[K, V]{
+		randomNode: randomNode,
+		sampleSize: sampleSize,
+		maxCost:    maxCost,
+	}
+}
+
+// Read updates the eviction policy based on node accesses.
+func (p *Policy[K, V]) Read(nodes []node.Node[K, V]) {
+	for _, n := range nodes {
+		n.IncrementFrequency()
+	}
+}
+
+// Add adds node to the eviction policy.
+func (p *Policy[K, V]) Add(deleted []node.Node[K, V], n node.Node[K, V]) []node.Node[K, V] {
+	n.MarkMain()
+	p.len++
+	p.cost += n.Cost()
+
+	for p.cost > p.maxCost {
+		victim := p.victim()
+		if node.Equals(victim, nil) {
+			// the sampling has failed, so we evict the new node to keep the cost bounded.
+			victim = n
+		}
+		p.remove(victim)
+		deleted = append(deleted, victim)
+	}
+
+	return deleted
+}
+
+// victim returns the least recently accessed node among the sampled ones.
+func (p *Policy[K, V]) victim() node.Node[K, V] {
+	var victim node.Node[K, V]
+	sampled := 0
+	for i := 0; i < p.sampleSize*maxAttemptsFactor && sampled < p.sampleSize; i++ {
+		n := p.randomNode()
+		if node.Equals(n, nil) {
+			return victim
+		}
+		if !n.IsMain() {
+			// the node has not been added to the policy yet or has already been deleted.
+			continue
+		}
+		if !n.IsAlive() || n.IsExpired() {
+			return n
+		}
+
+		sampled++
+		if node.Equals(victim, nil) || isOlder(n, victim) {
+			victim = n
+		}
+	}
+	return victim
+}
+
+// isOlder reports whether a should be evicted before b.
+func isOlder[K comparable, V any](a, b node.Node[K, V]) bool {
+	if a.LastAccess() != b.LastAccess() {
+		return a.LastAccess() < b.LastAccess()
+	}
+	return a.Frequency() < b.Frequency()
+}
+
+func (p *Policy[K, V]) remove(n node.Node[K, V]) {
+	n.Unmark()
+	p.len--
+	p.cost -= n.Cost()
+}
+
+// Delete deletes node from the eviction policy.
+func (p *Policy[K, V]) Delete(n node.Node[K, V]) {
+	if n.IsMain() {
+		p.remove(n)
+	}
+}
+
+// NextEvictions returns at most n sampled nodes that are most likely to be evicted next in the order of eviction.
+//
+// The nodes are sampled randomly, so the result is only an estimation.
+func (p *Policy[K, V]) NextEvictions(n int) []node.Node[K, V] {
+	return p.sample(n, false)
+}
+
+// Evict removes at most n sampled live nodes from the policy in the order of eviction and appends them to deleted.
+// The dead and expired nodes are left to their delete tasks and the cleanup.
+func (p *Policy[K, V]) Evict(deleted []node.Node[K, V], n int) []node.Node[K, V] {
+	for _, victim := range p.sample(n, true) {
+		p.remove(victim)
+		deleted = append(deleted, victim)
+	}
+	return deleted
+}
+
+// sample returns at most n sampled nodes in the order of eviction. If liveOnly is set, the dead and expired
+// nodes are not sampled.
+func (p *Policy[K, V]) sample(n int, liveOnly bool) []node.Node[K, V] {
+	if n > p.len {
+		n = p.len
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	seen := make(map[K]struct{}, n*p.sampleSize)
+	sample := make([]node.Node[K, V], 0, n*p.sampleSize)
+	for i := 0; i < n*p.sampleSize*maxAttemptsFactor && len(sample) < n*p.sampleSize; i++ {
+		got := p.randomNode()
+		if node.Equals(got, nil) {
+			break
+		}
+		if _, ok := seen[got.Key()]; ok || !got.IsMain() {
+			continue
+		}
+		if liveOnly && (!got.IsAlive() || got.IsExpired()) {
+			continue
+		}
+		seen[got.Key()] = struct{}{}
+		sample = append(sample, got)
+	}
+
+	sort.Slice(sample, func(i, j int) bool {
+		return isOlder(sample[i], sample[j])
+	})
+	if len(sample) > n {
+		sample = sample[:n]
+	}
+	return sample
+}
+
+// Cost returns the total cost of the nodes in the policy.
+func (p *Policy[K, V]) Cost() uint32 {
+	return p.cost
+}
+
+// MaxAvailableCost returns the maximum available cost of the node.
+func (p *Policy[K, V]) MaxAvailableCost() uint32 {
+	return p.maxCost
+}
+
+// Clear clears the eviction policy and returns it to the default state.
+func (p *Policy[K, V]) Clear() {
+	p.len = 0
+	p.cost = 0
+}
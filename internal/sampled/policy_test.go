@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sampled
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/maypok86/otter/internal/generated/node"
+)
+
+func TestPolicy_Add(t *testing.T) {
+	m := node.NewManager[int, int](node.Config{WithLastAccess: true})
+	nodes := make([]node.Node[int, int], 0, 10)
+	p := NewPolicy[int, int](5, 0, func() node.Node[int, int] {
+		if len(nodes) == 0 {
+			return nil
+		}
+		return nodes[rand.Intn(len(nodes))]
+	})
+
+	var deleted []node.Node[int, int]
+	for i := 0; i < cap(nodes); i++ {
+		n := m.Create(i, i, 0, 1)
+		n.SetLastAccess(int64(i))
+		nodes = append(nodes, n)
+		deleted = p.Add(deleted, n)
+	}
+
+	if len(deleted) != 5 {
+		t.Fatalf("5 nodes should be evicted, but got %d", len(deleted))
+	}
+	if p.cost != 5 {
+		t.Fatalf("cost should be bounded by the max cost, but got %d", p.cost)
+	}
+	for _, n := range deleted {
+		if n.IsMain() {
+			t.Fatalf("evicted node should be removed from the policy: %d", n.Key())
+		}
+	}
+
+	p.Delete(nodes[9])
+	if nodes[9].IsMain() || p.cost != 4 {
+		t.Fatalf("node should be deleted from the policy, cost: %d", p.cost)
+	}
+
+	next := p.NextEvictions(2)
+	for i := 1; i < len(next); i++ {
+		if next[i].LastAccess() < next[i-1].LastAccess() {
+			t.Fatal("next evictions should be sorted by the last access time")
+		}
+	}
+	if next := p.NextEvictions(math.MaxInt); len(next) > 4 {
+		t.Fatalf("next evictions should be bounded by the policy size, but got %d", len(next))
+	}
+}
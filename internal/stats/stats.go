@@ -15,31 +15,80 @@
 package stats
 
 import (
+	"math/bits"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/maypok86/otter/internal/xruntime"
 )
 
 // Stats is a thread-safe statistics collector.
+//
+// A nil *Stats is a valid disabled collector: all its methods do nothing and return zeros.
+// The cache keeps a nil pointer when the statistics are disabled, so that the hot paths pay only
+// for a predictable nil check instead of an interface call, and every new method must keep this contract.
 type Stats struct {
 	hits                   *counter
 	misses                 *counter
+	expiredMisses          *counter
 	rejectedSets           *counter
-	evictedCountersPadding [xruntime.CacheLineSize - 2*unsafe.Sizeof(atomic.Int64{})]byte
+	evictedCountersPadding [xruntime.CacheLineSize - 3*unsafe.Sizeof(atomic.Int64{})]byte
 	evictedCount           atomic.Int64
 	evictedCost            atomic.Int64
+	insertedCount          atomic.Int64
+	insertedCost           atomic.Int64
+	lockContention         atomic.Int64
+	expiredCount           atomic.Int64
+	writeBufferOverflows   atomic.Int64
+	cleanupCycles          atomic.Int64
+	cleanupDuration        atomic.Int64
+	maxCleanupDuration     atomic.Int64
+	writeLatency           [writeLatencyBuckets]atomic.Int64
+	maxWriteLatency        atomic.Int64
+	// recorder receives the events in addition to the counters if it is set.
+	recorder Recorder
 }
 
+// Recorder receives the statistics events as they happen, so that they can be forwarded
+// to an external metrics system without polling the snapshots.
+//
+// The methods are called synchronously on the hot paths of the cache, including Get,
+// so they must be thread-safe and fast.
+type Recorder interface {
+	// RecordHit is called for each cache hit.
+	RecordHit()
+	// RecordMiss is called for each cache miss.
+	RecordMiss()
+	// RecordRejectedSet is called for each write rejected because of its cost.
+	RecordRejectedSet()
+	// RecordEviction is called for each entry evicted because of the capacity with the cost of the entry.
+	RecordEviction(cost uint32)
+	// RecordExpiration is called for each expired entry removed by the cleanup.
+	RecordExpiration()
+}
+
+// writeLatencyBuckets is the number of buckets in the write latency histogram.
+// The i-th bucket contains the latencies in [2^(i-1), 2^i) nanoseconds.
+const writeLatencyBuckets = 65
+
 // New creates a new Stats collector.
 func New() *Stats {
 	return &Stats{
-		hits:         newCounter(),
-		misses:       newCounter(),
-		rejectedSets: newCounter(),
+		hits:          newCounter(),
+		misses:        newCounter(),
+		expiredMisses: newCounter(),
+		rejectedSets:  newCounter(),
 	}
 }
 
+// NewWithRecorder creates a new Stats collector that also forwards the events to recorder.
+func NewWithRecorder(recorder Recorder) *Stats {
+	s := New()
+	s.recorder = recorder
+	return s
+}
+
 // IncHits increments the hits counter.
 func (s *Stats) IncHits() {
 	if s == nil {
@@ -47,6 +96,9 @@ func (s *Stats) IncHits() {
 	}
 
 	s.hits.increment()
+	if s.recorder != nil {
+		s.recorder.RecordHit()
+	}
 }
 
 // Hits returns the number of cache hits.
@@ -65,6 +117,9 @@ func (s *Stats) IncMisses() {
 	}
 
 	s.misses.increment()
+	if s.recorder != nil {
+		s.recorder.RecordMiss()
+	}
 }
 
 // Misses returns the number of cache misses.
@@ -76,6 +131,24 @@ func (s *Stats) Misses() int64 {
 	return s.misses.value()
 }
 
+// IncExpiredMisses increments the expiredMisses counter. It should be called in addition to IncMisses.
+func (s *Stats) IncExpiredMisses() {
+	if s == nil {
+		return
+	}
+
+	s.expiredMisses.increment()
+}
+
+// ExpiredMisses returns the number of cache misses caused by the expired entries.
+func (s *Stats) ExpiredMisses() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.expiredMisses.value()
+}
+
 // IncRejectedSets increments the rejectedSets counter.
 func (s *Stats) IncRejectedSets() {
 	if s == nil {
@@ -83,6 +156,9 @@ func (s *Stats) IncRejectedSets() {
 	}
 
 	s.rejectedSets.increment()
+	if s.recorder != nil {
+		s.recorder.RecordRejectedSet()
+	}
 }
 
 // RejectedSets returns the number of rejected sets.
@@ -112,13 +188,17 @@ func (s *Stats) EvictedCount() int64 {
 	return s.evictedCount.Load()
 }
 
-// AddEvictedCost adds cost to the evictedCost counter.
+// AddEvictedCost adds cost to the evictedCost counter. It is called once per evicted entry,
+// so the recorder is notified about the eviction here.
 func (s *Stats) AddEvictedCost(cost uint32) {
 	if s == nil {
 		return
 	}
 
 	s.evictedCost.Add(int64(cost))
+	if s.recorder != nil {
+		s.recorder.RecordEviction(cost)
+	}
 }
 
 // EvictedCost returns the sum of costs of evicted entries.
@@ -130,6 +210,200 @@ func (s *Stats) EvictedCost() int64 {
 	return s.evictedCost.Load()
 }
 
+// RecordInsertion increments the insertedCount counter and adds cost to the insertedCost counter.
+func (s *Stats) RecordInsertion(cost uint32) {
+	if s == nil {
+		return
+	}
+
+	s.insertedCount.Add(1)
+	s.insertedCost.Add(int64(cost))
+}
+
+// InsertedCount returns the number of entries inserted into the eviction policy.
+func (s *Stats) InsertedCount() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.insertedCount.Load()
+}
+
+// InsertedCost returns the sum of costs of entries inserted into the eviction policy.
+func (s *Stats) InsertedCost() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.insertedCost.Load()
+}
+
+// IncLockContention increments the lockContention counter.
+func (s *Stats) IncLockContention() {
+	if s == nil {
+		return
+	}
+
+	s.lockContention.Add(1)
+}
+
+// LockContention returns the number of times the eviction mutex was acquired after waiting for it.
+func (s *Stats) LockContention() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.lockContention.Load()
+}
+
+// IncExpiredCount increments the expiredCount counter.
+func (s *Stats) IncExpiredCount() {
+	if s == nil {
+		return
+	}
+
+	s.expiredCount.Add(1)
+	if s.recorder != nil {
+		s.recorder.RecordExpiration()
+	}
+}
+
+// ExpiredCount returns the number of entries removed by the cleanup because of the expiration.
+func (s *Stats) ExpiredCount() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.expiredCount.Load()
+}
+
+// IncWriteBufferOverflows increments the writeBufferOverflows counter.
+func (s *Stats) IncWriteBufferOverflows() {
+	if s == nil {
+		return
+	}
+
+	s.writeBufferOverflows.Add(1)
+}
+
+// WriteBufferOverflows returns the number of times the write buffer was found almost full.
+func (s *Stats) WriteBufferOverflows() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.writeBufferOverflows.Load()
+}
+
+// RecordCleanup records a cleanup cycle that took d.
+func (s *Stats) RecordCleanup(d time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.cleanupCycles.Add(1)
+	s.cleanupDuration.Add(int64(d))
+	for {
+		maxDuration := s.maxCleanupDuration.Load()
+		if int64(d) <= maxDuration || s.maxCleanupDuration.CompareAndSwap(maxDuration, int64(d)) {
+			return
+		}
+	}
+}
+
+// CleanupCycles returns the number of the recorded cleanup cycles.
+func (s *Stats) CleanupCycles() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.cleanupCycles.Load()
+}
+
+// TotalCleanupDuration returns the total duration of the recorded cleanup cycles.
+func (s *Stats) TotalCleanupDuration() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(s.cleanupDuration.Load())
+}
+
+// MaxCleanupDuration returns the duration of the longest recorded cleanup cycle.
+func (s *Stats) MaxCleanupDuration() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(s.maxCleanupDuration.Load())
+}
+
+// RecordWriteLatency records the time a write task waited in the write buffer.
+func (s *Stats) RecordWriteLatency(d time.Duration) {
+	if s == nil {
+		return
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	s.writeLatency[bits.Len64(uint64(d))].Add(1)
+	for {
+		maxLatency := s.maxWriteLatency.Load()
+		if int64(d) <= maxLatency || s.maxWriteLatency.CompareAndSwap(maxLatency, int64(d)) {
+			return
+		}
+	}
+}
+
+// WriteLatencyQuantile returns the upper bound of the histogram bucket containing the q-quantile
+// of the recorded write latencies, but not greater than the max latency.
+func (s *Stats) WriteLatencyQuantile(q float64) time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	var counts [writeLatencyBuckets]int64
+	total := int64(0)
+	for i := range s.writeLatency {
+		counts[i] = s.writeLatency[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(q * float64(total))
+	if rank < 1 {
+		rank = 1
+	}
+	maxLatency := s.MaxWriteLatency()
+	seen := int64(0)
+	for i, count := range counts {
+		seen += count
+		if seen < rank {
+			continue
+		}
+		if i >= 63 {
+			return maxLatency
+		}
+		if upper := time.Duration(1<<i - 1); upper < maxLatency {
+			return upper
+		}
+		break
+	}
+	return maxLatency
+}
+
+// MaxWriteLatency returns the max recorded write latency.
+func (s *Stats) MaxWriteLatency() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(s.maxWriteLatency.Load())
+}
+
 func (s *Stats) Clear() {
 	if s == nil {
 		return
@@ -137,7 +411,20 @@ func (s *Stats) Clear() {
 
 	s.hits.reset()
 	s.misses.reset()
+	s.expiredMisses.reset()
 	s.rejectedSets.reset()
 	s.evictedCount.Store(0)
 	s.evictedCost.Store(0)
+	s.insertedCount.Store(0)
+	s.insertedCost.Store(0)
+	s.lockContention.Store(0)
+	s.expiredCount.Store(0)
+	s.writeBufferOverflows.Store(0)
+	s.cleanupCycles.Store(0)
+	s.cleanupDuration.Store(0)
+	s.maxCleanupDuration.Store(0)
+	for i := range s.writeLatency {
+		s.writeLatency[i].Store(0)
+	}
+	s.maxWriteLatency.Store(0)
 }
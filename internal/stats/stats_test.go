@@ -47,6 +47,19 @@ func TestStats_Nil(t *testing.T) {
 		func() {
 			s.AddEvictedCost(1)
 		},
+		s.IncLockContention,
+		func() {
+			s.RecordInsertion(1)
+		},
+		s.IncExpiredMisses,
+		s.IncWriteBufferOverflows,
+		func() {
+			s.RecordCleanup(time.Second)
+		},
+		s.IncExpiredCount,
+		func() {
+			s.RecordWriteLatency(time.Second)
+		},
 		s.IncHits,
 		s.IncMisses,
 	} {
@@ -58,6 +71,19 @@ func TestStats_Nil(t *testing.T) {
 		s.RejectedSets,
 		s.EvictedCount,
 		s.EvictedCost,
+		s.LockContention,
+		s.InsertedCount,
+		s.InsertedCost,
+		s.ExpiredMisses,
+		s.WriteBufferOverflows,
+		s.CleanupCycles,
+		func() int64 {
+			return int64(s.TotalCleanupDuration() + s.MaxCleanupDuration())
+		},
+		s.ExpiredCount,
+		func() int64 {
+			return int64(s.WriteLatencyQuantile(0.99) + s.MaxWriteLatency())
+		},
 	} {
 		if expected != f() {
 			t.Fatalf("hits and misses for nil stats should always be %d", expected)
@@ -170,3 +196,74 @@ func TestStats_Clear(t *testing.T) {
 		t.Fatalf("hits and misses after clear should be 0, but got hits: %d and misses: %d", hits, misses)
 	}
 }
+
+func TestStats_LockContention(t *testing.T) {
+	expected := generateCount(t)
+
+	s := New()
+	for i := int64(0); i < expected; i++ {
+		s.IncLockContention()
+	}
+
+	lockContention := s.LockContention()
+	if expected != lockContention {
+		t.Fatalf("lock contention should be %d, but got %d", expected, lockContention)
+	}
+
+	s.Clear()
+	if s.LockContention() != 0 {
+		t.Fatal("lock contention should be reset")
+	}
+}
+
+func TestStats_RecordCleanup(t *testing.T) {
+	s := New()
+	for _, d := range []time.Duration{time.Millisecond, 5 * time.Millisecond, 2 * time.Millisecond} {
+		s.RecordCleanup(d)
+	}
+
+	if s.CleanupCycles() != 3 {
+		t.Fatalf("number of cleanup cycles should be 3, but got %d", s.CleanupCycles())
+	}
+	if s.TotalCleanupDuration() != 8*time.Millisecond {
+		t.Fatalf("total cleanup duration should be 8ms, but got %s", s.TotalCleanupDuration())
+	}
+	if s.MaxCleanupDuration() != 5*time.Millisecond {
+		t.Fatalf("max cleanup duration should be 5ms, but got %s", s.MaxCleanupDuration())
+	}
+
+	s.Clear()
+	if s.CleanupCycles() != 0 || s.TotalCleanupDuration() != 0 || s.MaxCleanupDuration() != 0 {
+		t.Fatal("cleanup stats should be reset")
+	}
+}
+
+func TestStats_WriteLatency(t *testing.T) {
+	s := New()
+	if s.WriteLatencyQuantile(0.99) != 0 {
+		t.Fatal("quantile of the empty histogram should be 0")
+	}
+
+	for i := 0; i < 99; i++ {
+		s.RecordWriteLatency(100 * time.Nanosecond)
+	}
+	s.RecordWriteLatency(time.Millisecond)
+
+	if p50 := s.WriteLatencyQuantile(0.5); p50 < 100*time.Nanosecond || p50 >= 200*time.Nanosecond {
+		t.Fatalf("p50 should be in the bucket of 100ns, but got %s", p50)
+	}
+	if p99 := s.WriteLatencyQuantile(0.99); p99 >= 200*time.Nanosecond {
+		t.Fatalf("p99 should be in the bucket of 100ns, but got %s", p99)
+	}
+	if p100 := s.WriteLatencyQuantile(1); p100 != time.Millisecond {
+		t.Fatalf("p100 should be the max latency, but got %s", p100)
+	}
+	if s.MaxWriteLatency() != time.Millisecond {
+		t.Fatalf("max latency should be 1ms, but got %s", s.MaxWriteLatency())
+	}
+
+	s.Clear()
+	if s.WriteLatencyQuantile(0.5) != 0 || s.MaxWriteLatency() != 0 {
+		t.Fatal("write latency should be reset")
+	}
+}
@@ -20,67 +20,174 @@ import (
 	"time"
 )
 
+const (
+	// DefaultResolution is the default interval between the timer updates.
+	DefaultResolution = time.Second
+	// MinResolution is the finest resolution of the timer. If a finer resolution is requested,
+	// Now reads the current time on each call instead of the timer.
+	MinResolution = time.Millisecond
+)
+
 var (
 	// We need this package because time.Now() is slower, allocates memory,
 	// and we don't need a more precise time for the expiry time (and most other operations).
-	now uint32
+	now           int64
+	unixStartTime int64
+	// precise is set if Now should read the current time instead of the timer.
+	precise atomic.Bool
 
 	mutex         sync.Mutex
 	countInstance int
+	resolutions   = make(map[time.Duration]int)
+	interval      time.Duration
 	done          chan struct{}
 )
 
-func startTimer() {
+func startTimer(resolution time.Duration) {
 	done = make(chan struct{})
-	startTime := time.Now().Unix()
-	atomic.StoreUint32(&now, uint32(0))
+	interval = resolution
+	startTime := atomic.LoadInt64(&unixStartTime)
 
-	go func() {
-		ticker := time.NewTicker(time.Second)
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(resolution)
 		defer ticker.Stop()
 		for {
 			select {
 			case t := <-ticker.C:
-				atomic.StoreUint32(&now, uint32(t.Unix()-startTime))
+				atomic.StoreInt64(&now, t.UnixNano()-startTime)
 			case <-done:
 				return
 			}
 		}
-	}()
+	}(done)
+}
+
+func stopTimer() {
+	done <- struct{}{}
+	close(done)
+}
+
+// finestResolution returns the smallest resolution requested by the running instances.
+func finestResolution() time.Duration {
+	finest := time.Duration(0)
+	for r := range resolutions {
+		if finest == 0 || r < finest {
+			finest = r
+		}
+	}
+	return finest
 }
 
 // Start should be called when the cache instance is created to initialize the timer.
 func Start() {
+	StartWithResolution(DefaultResolution)
+}
+
+// StartWithResolution is like Start, but the timer is updated at least every resolution.
+//
+// The timer is shared by all the instances, so it is updated with the finest resolution of them.
+// A finer resolution reduces the staleness of Now at the cost of the more frequent updates,
+// and a resolution finer than MinResolution makes Now exact at the cost of reading the current time
+// on each call. Each call must be paired with StopWithResolution called with the same resolution.
+func StartWithResolution(resolution time.Duration) {
+	if resolution <= 0 {
+		resolution = DefaultResolution
+	}
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
 	if countInstance == 0 {
-		startTimer()
+		atomic.StoreInt64(&unixStartTime, time.Now().UnixNano())
+		atomic.StoreInt64(&now, 0)
 	}
 
 	countInstance++
+	resolutions[resolution]++
+	updateTimer()
 }
 
 // Stop should be called when closing and stopping the cache instance to stop the timer.
 func Stop() {
+	StopWithResolution(DefaultResolution)
+}
+
+// StopWithResolution should be called when closing the cache instance started with StartWithResolution.
+func StopWithResolution(resolution time.Duration) {
+	if resolution <= 0 {
+		resolution = DefaultResolution
+	}
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
 	countInstance--
+	resolutions[resolution]--
+	if resolutions[resolution] <= 0 {
+		delete(resolutions, resolution)
+	}
+	updateTimer()
+}
+
+// updateTimer starts, restarts or stops the timer according to the running instances.
+//
+// NOTE: the mutex must be held.
+func updateTimer() {
 	if countInstance == 0 {
-		done <- struct{}{}
-		close(done)
+		if done != nil {
+			stopTimer()
+			done = nil
+		}
+		precise.Store(false)
+		return
+	}
+
+	resolution := finestResolution()
+	precise.Store(resolution < MinResolution)
+	if resolution < MinResolution {
+		// Now reads the current time, so the timer is not needed.
+		if done != nil {
+			stopTimer()
+			done = nil
+		}
+		return
 	}
+	if done != nil && interval == resolution {
+		return
+	}
+	if done != nil {
+		stopTimer()
+	}
+	atomic.StoreInt64(&now, time.Now().UnixNano()-atomic.LoadInt64(&unixStartTime))
+	startTimer(resolution)
+}
+
+// Now returns the time in nanoseconds elapsed since the start of the timer.
+func Now() int64 {
+	if precise.Load() {
+		return time.Now().UnixNano() - atomic.LoadInt64(&unixStartTime)
+	}
+	return atomic.LoadInt64(&now)
+}
+
+// ToTime converts the time returned by Now into time.Time.
+func ToTime(t int64) time.Time {
+	return time.Unix(0, atomic.LoadInt64(&unixStartTime)+t)
 }
 
-// Now returns time as a Unix time, the number of seconds elapsed since program start.
-func Now() uint32 {
-	return atomic.LoadUint32(&now)
+// FromTime converts t into the time returned by Now.
+// It returns 0 if t is before the start of the timer.
+func FromTime(t time.Time) int64 {
+	d := t.UnixNano() - atomic.LoadInt64(&unixStartTime)
+	if d < 0 {
+		return 0
+	}
+	return d
 }
 
 // SetNow sets the current time.
 //
 // NOTE: use only for testing and debugging.
-func SetNow(t uint32) {
-	atomic.StoreUint32(&now, t)
+func SetNow(t int64) {
+	atomic.StoreInt64(&now, t)
 }
@@ -25,11 +25,11 @@ func BenchmarkNow(b *testing.B) {
 
 	b.ReportAllocs()
 	b.RunParallel(func(pb *testing.PB) {
-		var ts uint32
+		var ts int64
 		for pb.Next() {
 			ts += Now()
 		}
-		atomic.StoreUint32(&sink, ts)
+		atomic.StoreInt64(&sink, ts)
 	})
 
 	Stop()
@@ -38,13 +38,13 @@ func BenchmarkNow(b *testing.B) {
 func BenchmarkTimeNowUnix(b *testing.B) {
 	b.ReportAllocs()
 	b.RunParallel(func(pb *testing.PB) {
-		var ts uint32
+		var ts int64
 		for pb.Next() {
-			ts += uint32(time.Now().Unix())
+			ts += time.Now().UnixNano()
 		}
-		atomic.StoreUint32(&sink, ts)
+		atomic.StoreInt64(&sink, ts)
 	})
 }
 
 // sink should prevent from code elimination by optimizing compiler.
-var sink uint32
+var sink int64
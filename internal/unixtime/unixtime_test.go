@@ -23,22 +23,92 @@ func TestNow(t *testing.T) {
 	Start()
 
 	got := Now()
-	if got != 0 {
+	if got < 0 || got >= int64(time.Second) {
 		t.Fatalf("unexpected time since program start; got %d; want %d", got, 0)
 	}
 
 	time.Sleep(3 * time.Second)
 
 	got = Now()
-	if got != 2 && got != 3 {
-		t.Fatalf("unexpected time since program start; got %d; want %d", got, 3)
+	if got < int64(2*time.Second) || got >= int64(4*time.Second) {
+		t.Fatalf("unexpected time since program start; got %d; want %d", got, int64(3*time.Second))
 	}
 
 	Stop()
 
+	// Stop waits for the timer goroutine to exit, so the time can't be updated after it returns.
+	got = Now()
+
 	time.Sleep(3 * time.Second)
 
-	if Now()-got > 1 {
+	if Now() != got {
+		t.Fatal("timer should have stopped")
+	}
+}
+
+func TestStartWithResolution(t *testing.T) {
+	Start()
+	StartWithResolution(10 * time.Millisecond)
+
+	mutex.Lock()
+	got := interval
+	mutex.Unlock()
+	if got != 10*time.Millisecond {
+		t.Fatalf("the finest resolution should be used; got %v; want %v", got, 10*time.Millisecond)
+	}
+
+	StopWithResolution(10 * time.Millisecond)
+
+	mutex.Lock()
+	got = interval
+	mutex.Unlock()
+	if got != DefaultResolution {
+		t.Fatalf("the resolution should be restored; got %v; want %v", got, DefaultResolution)
+	}
+
+	Stop()
+
+	mutex.Lock()
+	stopped := done == nil && len(resolutions) == 0
+	mutex.Unlock()
+	if !stopped {
 		t.Fatal("timer should have stopped")
 	}
 }
+
+func TestFromTime(t *testing.T) {
+	start := ToTime(0)
+	for _, tt := range []struct {
+		t    time.Time
+		want int64
+	}{
+		{t: start.Add(-time.Second), want: 0},
+		{t: start, want: 0},
+		{t: start.Add(time.Millisecond), want: int64(time.Millisecond)},
+		{t: start.Add(5 * time.Second), want: int64(5 * time.Second)},
+	} {
+		if got := FromTime(tt.t); got != tt.want {
+			t.Fatalf("FromTime(%v) = %d, want = %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestPrecise(t *testing.T) {
+	Start()
+	StartWithResolution(time.Nanosecond)
+
+	before := FromTime(time.Now())
+	got := Now()
+	after := FromTime(time.Now())
+	if got < before || got > after {
+		t.Fatalf("Now should read the current time; got %d; want in [%d, %d]", got, before, after)
+	}
+
+	StopWithResolution(time.Nanosecond)
+
+	if precise.Load() {
+		t.Fatal("Now should use the timer after the precise instance has stopped")
+	}
+
+	Stop()
+}
@@ -14,6 +14,11 @@
 
 package xmath
 
+// IsPowerOf2 reports whether v is a power of two.
+func IsPowerOf2(v uint32) bool {
+	return v != 0 && v&(v-1) == 0
+}
+
 // RoundUpPowerOf2 is based on https://graphics.stanford.edu/~seander/bithacks.html#RoundUpPowerOf2.
 func RoundUpPowerOf2(v uint32) uint32 {
 	if v == 0 {
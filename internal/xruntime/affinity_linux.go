@@ -0,0 +1,48 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xruntime
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// maxAffinityCPUs is the number of CPUs the affinity mask can describe.
+const maxAffinityCPUs = 1024
+
+// SetThreadAffinity restricts the current OS thread to the given CPUs. The caller should lock
+// the goroutine to the thread with runtime.LockOSThread first, otherwise the setting is lost
+// as soon as the goroutine migrates.
+func SetThreadAffinity(cpus []int) error {
+	var mask [maxAffinityCPUs / 64]uint64
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= maxAffinityCPUs {
+			return fmt.Errorf("cpu %d is out of range [0, %d)", cpu, maxAffinityCPUs)
+		}
+		mask[cpu/64] |= 1 << (cpu % 64)
+	}
+
+	_, _, errno := syscall.RawSyscall(
+		syscall.SYS_SCHED_SETAFFINITY,
+		0,
+		uintptr(unsafe.Sizeof(mask)),
+		uintptr(unsafe.Pointer(&mask)),
+	)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xruntime
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	cgroupV2MemoryLimitPath = "/sys/fs/cgroup/memory.max"
+	cgroupV1MemoryLimitPath = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+	memInfoPath             = "/proc/meminfo"
+	// cgroupV1Unlimited is the min value that cgroup v1 reports for the unlimited memory.
+	cgroupV1Unlimited = 1 << 62
+)
+
+// MemoryLimit returns the memory available to the process in bytes: the memory limit of the cgroup
+// if it is set, otherwise the total system memory. It returns false if the memory can't be detected,
+// e.g. on the systems other than Linux.
+func MemoryLimit() (uint64, bool) {
+	total, ok := readMemTotal(memInfoPath)
+	for _, path := range []string{cgroupV2MemoryLimitPath, cgroupV1MemoryLimitPath} {
+		if limit, found := readCgroupMemoryLimit(path); found && (!ok || limit < total) {
+			return limit, true
+		}
+	}
+	return total, ok
+}
+
+// readCgroupMemoryLimit reads the memory limit from the cgroup file. It returns false if the file
+// doesn't exist or the memory is unlimited.
+func readCgroupMemoryLimit(path string) (uint64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || limit == 0 || limit >= cgroupV1Unlimited {
+		return 0, false
+	}
+	return limit, true
+}
+
+// readMemTotal reads the total system memory from /proc/meminfo.
+func readMemTotal(path string) (uint64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemTotal:       16314660 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			total *= 1024
+		}
+		return total, true
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xruntime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("can not write the file: %v", err)
+	}
+	return path
+}
+
+func TestReadCgroupMemoryLimit(t *testing.T) {
+	for _, tt := range []struct {
+		content string
+		want    uint64
+		ok      bool
+	}{
+		{content: "1073741824\n", want: 1 << 30, ok: true},
+		{content: "max\n", ok: false},
+		{content: "9223372036854771712\n", ok: false},
+		{content: "invalid", ok: false},
+	} {
+		got, ok := readCgroupMemoryLimit(writeFile(t, tt.content))
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("readCgroupMemoryLimit(%q) = %d, %v, want = %d, %v", tt.content, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if _, ok := readCgroupMemoryLimit(filepath.Join(t.TempDir(), "absent")); ok {
+		t.Fatal("the limit should not be detected without the file")
+	}
+}
+
+func TestReadMemTotal(t *testing.T) {
+	path := writeFile(t, "MemFree:         1000 kB\nMemTotal:       16314660 kB\n")
+	got, ok := readMemTotal(path)
+	if !ok || got != 16314660*1024 {
+		t.Fatalf("readMemTotal() = %d, %v, want = %d, true", got, ok, 16314660*1024)
+	}
+
+	if _, ok := readMemTotal(writeFile(t, "MemFree: 1000 kB\n")); ok {
+		t.Fatal("the total memory should not be detected without MemTotal")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+// Interner is a bounded string interner based on Cache.
+//
+// It deduplicates memory for repeated strings (for example, labels or log fields): all equal strings
+// passed to Intern are replaced with a single canonical copy while it stays in the cache.
+type Interner struct {
+	cache Cache[string, string]
+}
+
+// NewInterner creates an Interner that keeps at most capacity canonical strings.
+//
+// Returns an error if capacity <= 0.
+func NewInterner(capacity int) (*Interner, error) {
+	b, err := NewBuilder[string, string](capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Interner{
+		cache: cache,
+	}, nil
+}
+
+// Intern returns the canonical copy of s.
+//
+// If there is no canonical copy of s yet, then s itself becomes the canonical copy.
+func (i *Interner) Intern(s string) string {
+	if v, ok := i.cache.Get(s); ok {
+		return v
+	}
+
+	if i.cache.SetIfAbsent(s, s) {
+		return s
+	}
+
+	// somebody has interned the same string concurrently.
+	if v, ok := i.cache.Get(s); ok {
+		return v
+	}
+	return s
+}
+
+// Size returns the current number of canonical strings in the Interner.
+func (i *Interner) Size() int {
+	return i.cache.Size()
+}
+
+// Close clears the Interner and stops all goroutines of the underlying cache.
+func (i *Interner) Close() {
+	i.cache.Close()
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+func stringData(s string) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&s))
+}
+
+func TestInterner(t *testing.T) {
+	if _, err := NewInterner(0); err == nil {
+		t.Fatal("interner with zero capacity should not be created")
+	}
+
+	i, err := NewInterner(100)
+	if err != nil {
+		t.Fatalf("can not create interner: %v", err)
+	}
+	defer i.Close()
+
+	first := i.Intern(string([]byte("label")))
+	second := i.Intern(string([]byte("label")))
+	if first != second || stringData(first) != stringData(second) {
+		t.Fatal("interned strings should share the same memory")
+	}
+	if i.Size() != 1 {
+		t.Fatalf("i.Size() = %d, want = %d", i.Size(), 1)
+	}
+}
+
+func benchmarkRetained(b *testing.B, intern func(s string) string) {
+	b.Helper()
+
+	const (
+		count    = 100_000
+		distinct = 100
+	)
+
+	var before, after runtime.MemStats
+	for n := 0; n < b.N; n++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		retained := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			s := "some-repetitive-label-" + strconv.Itoa(i%distinct)
+			retained = append(retained, intern(s))
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(count), "retained-B/string")
+		runtime.KeepAlive(retained)
+	}
+}
+
+func BenchmarkInterner(b *testing.B) {
+	i, err := NewInterner(1000)
+	if err != nil {
+		b.Fatalf("can not create interner: %v", err)
+	}
+	defer i.Close()
+
+	benchmarkRetained(b, i.Intern)
+}
+
+func BenchmarkWithoutInterner(b *testing.B) {
+	benchmarkRetained(b, func(s string) string {
+		return s
+	})
+}
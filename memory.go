@@ -0,0 +1,72 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/maypok86/otter/internal/xruntime"
+)
+
+var (
+	// ErrIllegalMemoryFraction means that the fraction of the memory passed to CapacityFromMemory is not in (0, 1].
+	ErrIllegalMemoryFraction = errors.New("memory fraction should be in (0, 1]")
+	// ErrIllegalEntryCost means that a non-positive entry cost has been passed to CapacityFromMemory.
+	ErrIllegalEntryCost = errors.New("entry cost should be positive")
+	// ErrMemoryNotDetected means that the memory available to the process can't be detected.
+	ErrMemoryNotDetected = errors.New("available memory can't be detected")
+)
+
+// CapacityFromMemory returns the capacity of a cache that takes about the given fraction of the memory
+// available to the process, if the average cost of an entry is entryCost bytes. The available memory is
+// the memory limit of the cgroup (e.g. of the container) if it is set, otherwise the total system memory.
+//
+// For a cache whose cost function returns the size of the entries in bytes, pass 1 as entryCost.
+//
+// Returns ErrMemoryNotDetected if the memory can't be detected, which is always the case on the systems
+// other than Linux, so the caller should fall back to a fixed capacity.
+func CapacityFromMemory(fraction float64, entryCost int) (int, error) {
+	if !(fraction > 0 && fraction <= 1) {
+		return 0, ErrIllegalMemoryFraction
+	}
+	if entryCost <= 0 {
+		return 0, ErrIllegalEntryCost
+	}
+
+	limit, ok := xruntime.MemoryLimit()
+	if !ok {
+		return 0, ErrMemoryNotDetected
+	}
+
+	capacity := float64(limit) * fraction / float64(entryCost)
+	if capacity > math.MaxInt32 {
+		// the cost of the entries is uint32.
+		capacity = math.MaxInt32
+	}
+	if capacity < 1 {
+		return 0, ErrIllegalCapacity
+	}
+	return int(capacity), nil
+}
+
+// NewBuilderFromMemory creates a builder with the capacity calculated by CapacityFromMemory.
+func NewBuilderFromMemory[K comparable, V any](fraction float64, entryCost int) (*Builder[K, V], error) {
+	capacity, err := CapacityFromMemory(fraction, entryCost)
+	if err != nil {
+		return nil, err
+	}
+	return NewBuilder[K, V](capacity)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestCapacityFromMemory(t *testing.T) {
+	if _, err := CapacityFromMemory(1.5, 100); !errors.Is(err, ErrIllegalMemoryFraction) {
+		t.Fatalf("CapacityFromMemory() error = %v, want = %v", err, ErrIllegalMemoryFraction)
+	}
+	if _, err := CapacityFromMemory(0.25, 0); !errors.Is(err, ErrIllegalEntryCost) {
+		t.Fatalf("CapacityFromMemory() error = %v, want = %v", err, ErrIllegalEntryCost)
+	}
+
+	small, err := CapacityFromMemory(0.1, 1024)
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, ErrMemoryNotDetected) {
+			t.Fatalf("CapacityFromMemory() error = %v, want = %v", err, ErrMemoryNotDetected)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("can not detect the memory: %v", err)
+	}
+	large, err := CapacityFromMemory(0.2, 1024)
+	if err != nil {
+		t.Fatalf("can not detect the memory: %v", err)
+	}
+	if small <= 0 || large < small {
+		t.Fatalf("the capacity should grow with the fraction: %d, %d", small, large)
+	}
+
+	b, err := NewBuilderFromMemory[int, int](0.1, 1024)
+	if err != nil {
+		t.Fatalf("can not create the builder: %v", err)
+	}
+	if b.capacity != small {
+		t.Fatalf("the builder capacity = %d, want = %d", b.capacity, small)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package http provides an HTTP middleware that caches the responses in otter.
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maypok86/otter"
+)
+
+// Response is a cached HTTP response.
+type Response struct {
+	// Status is the status code of the response.
+	Status int
+	// Header contains the end-to-end headers of the response. The hop-by-hop headers and Set-Cookie are not cached.
+	Header http.Header
+	// Body is the body of the response.
+	Body []byte
+}
+
+// HTTPCacheOpts is a set of settings of the HTTP cache middleware.
+type HTTPCacheOpts struct {
+	// Key returns the cache key of the request. By default, the request URL is used.
+	Key func(r *http.Request) string
+	// Vary returns the part of the cache key that depends on the request headers (like Accept-Encoding).
+	// By default, the responses don't vary.
+	Vary func(r *http.Request) string
+	// Filter reports whether the response with the given status code and headers should be cached.
+	// By default, only the 200 OK responses are cached.
+	Filter func(status int, header http.Header) bool
+	// TTL returns the time to live of the response. The response is not cached if it is not positive.
+	// By default, the ttl is extracted from the Cache-Control header using CacheControlTTL.
+	TTL func(header http.Header) time.Duration
+	// DefaultTTL is the ttl of the responses without the max-age directive. It is used only by the default TTL.
+	DefaultTTL time.Duration
+}
+
+// NewHTTPCacheMiddleware returns a middleware that serves the GET responses from the cache.
+// The other requests bypass the cache.
+//
+// The status, the end-to-end headers and the body of the responses are cached. The cache is shared by all clients,
+// so the responses to the requests with the Authorization header are cached only if the Cache-Control header
+// allows it explicitly with the public or s-maxage directive.
+//
+// The cache must support a variable ttl, because the ttl of each response is extracted from its headers.
+func NewHTTPCacheMiddleware(
+	cache otter.CacheWithVariableTTL[string, *Response],
+	opts HTTPCacheOpts,
+) func(http.Handler) http.Handler {
+	key := opts.Key
+	if key == nil {
+		key = func(r *http.Request) string {
+			return r.URL.String()
+		}
+	}
+	filter := opts.Filter
+	if filter == nil {
+		filter = func(status int, header http.Header) bool {
+			return status == http.StatusOK
+		}
+	}
+	ttl := opts.TTL
+	if ttl == nil {
+		ttl = func(header http.Header) time.Duration {
+			return CacheControlTTL(header, opts.DefaultTTL)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			k := key(r)
+			if opts.Vary != nil {
+				k += "\x00" + opts.Vary(r)
+			}
+
+			if resp, ok := cache.Get(k); ok {
+				header := w.Header()
+				for name, values := range resp.Header {
+					header[name] = append([]string(nil), values...)
+				}
+				header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+				header.Set("X-Cache", "HIT")
+				w.WriteHeader(resp.Status)
+				_, _ = w.Write(resp.Body)
+				return
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			rec := &recorder{
+				ResponseWriter: w,
+				status:         http.StatusOK,
+			}
+			next.ServeHTTP(rec, r)
+
+			header := rec.sentHeader()
+			if !filter(rec.status, header) {
+				return
+			}
+			if r.Header.Get("Authorization") != "" && !isShared(header) {
+				return
+			}
+			if d := ttl(header); d > 0 {
+				cache.Set(k, &Response{
+					Status: rec.status,
+					Header: cacheableHeader(header),
+					Body:   rec.body.Bytes(),
+				}, d)
+			}
+		})
+	}
+}
+
+// CacheControlTTL returns the ttl specified by the max-age or s-maxage directive of the Cache-Control header.
+// It returns 0 if the response must not be cached and defaultTTL if the header doesn't specify the ttl.
+func CacheControlTTL(header http.Header, defaultTTL time.Duration) time.Duration {
+	ttl := defaultTTL
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-store", "no-cache", "private":
+			return 0
+		case "max-age", "s-maxage":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil {
+				return 0
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	return ttl
+}
+
+// isShared reports whether the response to a request with the Authorization header can be cached
+// for all clients according to its Cache-Control header.
+func isShared(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		if name == "public" || name == "s-maxage" {
+			return true
+		}
+	}
+	return false
+}
+
+// uncachedHeaders are the headers that are not stored with the response: the hop-by-hop headers,
+// the headers set per client and the header set by the middleware itself.
+var uncachedHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Set-Cookie",
+	"Content-Length",
+	"X-Cache",
+}
+
+// cacheableHeader returns a copy of the header without the headers that must not be cached.
+func cacheableHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, name := range uncachedHeaders {
+		h.Del(name)
+	}
+	return h
+}
+
+// recorder is a http.ResponseWriter that copies the response status, headers and body.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	header      http.Header
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// sentHeader returns the headers sent with the response. The changes made after the headers are sent are ignored.
+func (r *recorder) sentHeader() http.Header {
+	if r.header == nil {
+		return r.ResponseWriter.Header()
+	}
+	return r.header
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maypok86/otter"
+)
+
+func TestHTTPCacheMiddleware(t *testing.T) {
+	cache, err := otter.MustBuilder[string, *Response](100).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Set-Cookie", "session=1")
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(w, "{}")
+			return
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		}
+		_, _ = io.WriteString(w, "hello")
+	})
+	h := NewHTTPCacheMiddleware(cache, HTTPCacheOpts{DefaultTTL: time.Minute})(handler)
+
+	get := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	getAuthorized := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer token")
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get(http.MethodGet, "/"); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "hello" {
+		t.Fatalf("unexpected first response: %v %q", w.Header(), w.Body.String())
+	}
+	if w := get(http.MethodGet, "/"); w.Header().Get("X-Cache") != "HIT" || w.Body.String() != "hello" {
+		t.Fatalf("unexpected cached response: %v %q", w.Header(), w.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("handler should be called once, but was called %d times", calls)
+	}
+
+	get(http.MethodPost, "/")
+	get(http.MethodGet, "/private")
+	get(http.MethodGet, "/private")
+	if calls != 4 {
+		t.Fatalf("non-GET and non-cacheable requests should bypass the cache, calls: %d", calls)
+	}
+
+	// the headers of the response are cached with its body.
+	get(http.MethodGet, "/json")
+	w := get(http.MethodGet, "/json")
+	if w.Header().Get("X-Cache") != "HIT" ||
+		w.Header().Get("Content-Type") != "application/json" ||
+		w.Header().Get("Content-Encoding") != "gzip" ||
+		w.Header().Get("Set-Cookie") != "" ||
+		w.Body.String() != "{}" {
+		t.Fatalf("unexpected cached response: %v %q", w.Header(), w.Body.String())
+	}
+
+	// the private responses to the authorized requests are not shared with the other clients.
+	calls = 0
+	getAuthorized("/user")
+	if w := get(http.MethodGet, "/user"); w.Header().Get("X-Cache") != "MISS" || calls != 2 {
+		t.Fatalf("the response to an authorized request should not be cached, calls: %d", calls)
+	}
+	getAuthorized("/public")
+	if w := get(http.MethodGet, "/public"); w.Header().Get("X-Cache") != "HIT" || calls != 3 {
+		t.Fatalf("the public response to an authorized request should be cached, calls: %d", calls)
+	}
+}
+
+func TestCacheControlTTL(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		want         time.Duration
+	}{
+		{cacheControl: "", want: time.Minute},
+		{cacheControl: "public, max-age=10", want: 10 * time.Second},
+		{cacheControl: "max-age=10, s-maxage=20", want: 20 * time.Second},
+		{cacheControl: "no-store", want: 0},
+		{cacheControl: "max-age=abc", want: 0},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("Cache-Control", tt.cacheControl)
+		if got := CacheControlTTL(header, time.Minute); got != tt.want {
+			t.Fatalf("CacheControlTTL(%q) = %v, want = %v", tt.cacheControl, got, tt.want)
+		}
+	}
+}
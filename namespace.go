@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import "strings"
+
+// Namespace is a view of a Cache with string keys that transparently prefixes all keys.
+//
+// Namespaces allow multiple logical caches to share one bounded cache instance and its eviction budget.
+// The keys of different namespaces do not collide as long as no prefix is a prefix of another one,
+// so it is recommended to end the prefixes with a separator, for example "users:".
+type Namespace[V any] struct {
+	cache  Cache[string, V]
+	prefix string
+}
+
+// NewNamespace returns a view of the cache in which all keys are prefixed with prefix.
+func NewNamespace[V any](cache Cache[string, V], prefix string) Namespace[V] {
+	return Namespace[V]{
+		cache:  cache,
+		prefix: prefix,
+	}
+}
+
+// Namespace returns a nested view in which all keys are additionally prefixed with prefix.
+func (ns Namespace[V]) Namespace(prefix string) Namespace[V] {
+	return NewNamespace(ns.cache, ns.prefix+prefix)
+}
+
+// Prefix returns the prefix of the namespace keys.
+func (ns Namespace[V]) Prefix() string {
+	return ns.prefix
+}
+
+// Has checks if there is an item with the given key in the namespace.
+func (ns Namespace[V]) Has(key string) bool {
+	return ns.cache.Has(ns.prefix + key)
+}
+
+// Get returns the value associated with the key in the namespace.
+func (ns Namespace[V]) Get(key string) (V, bool) {
+	return ns.cache.Get(ns.prefix + key)
+}
+
+// Set associates the value with the key in the namespace.
+// If the namespace previously contained a value associated with the key, the old value is replaced by the value.
+//
+// If it returns false, then the key-value item had too much cost and the Set was dropped.
+func (ns Namespace[V]) Set(key string, value V) bool {
+	return ns.cache.Set(ns.prefix+key, value)
+}
+
+// SetIfAbsent if the specified key is not already associated with a value associates it with the given value.
+//
+// If the specified key is not present, but the key-value item had too much cost and the SetIfAbsent was dropped,
+// then it returns false.
+func (ns Namespace[V]) SetIfAbsent(key string, value V) bool {
+	return ns.cache.SetIfAbsent(ns.prefix+key, value)
+}
+
+// Delete removes the association for this key from the namespace.
+func (ns Namespace[V]) Delete(key string) {
+	ns.cache.Delete(ns.prefix + key)
+}
+
+// DeletePrefix removes all items of the namespace from the underlying cache.
+func (ns Namespace[V]) DeletePrefix() {
+	ns.cache.DeleteByFunc(func(key string, _ V) bool {
+		return strings.HasPrefix(key, ns.prefix)
+	})
+}
+
+// Range iterates over all items in the namespace. The keys are passed to f without the prefix.
+//
+// Iteration stops early when the given function returns false.
+func (ns Namespace[V]) Range(f func(key string, value V) bool) {
+	ns.cache.Range(func(key string, value V) bool {
+		if !strings.HasPrefix(key, ns.prefix) {
+			return true
+		}
+		return f(key[len(ns.prefix):], value)
+	})
+}
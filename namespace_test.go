@@ -0,0 +1,66 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import "testing"
+
+func TestNamespace(t *testing.T) {
+	c, err := MustBuilder[string, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	users := NewNamespace(c, "users:")
+	orders := NewNamespace(c, "orders:")
+	for i, k := range []string{"a", "b", "c"} {
+		users.Set(k, i)
+		orders.Set(k, i+10)
+	}
+
+	if v, ok := users.Get("a"); !ok || v != 0 {
+		t.Fatalf("users.Get(a) = %d, %v, want = 0, true", v, ok)
+	}
+	if v, ok := c.Get("orders:a"); !ok || v != 10 {
+		t.Fatalf("c.Get(orders:a) = %d, %v, want = 10, true", v, ok)
+	}
+	if users.SetIfAbsent("a", 100) {
+		t.Fatal("key already exists in the namespace")
+	}
+
+	users.Delete("a")
+	if users.Has("a") || !orders.Has("a") {
+		t.Fatal("delete should affect only the namespace")
+	}
+
+	keys := make(map[string]bool)
+	users.Range(func(key string, value int) bool {
+		keys[key] = true
+		return true
+	})
+	if len(keys) != 2 || !keys["b"] || !keys["c"] {
+		t.Fatalf("unexpected keys in the namespace: %v", keys)
+	}
+
+	nested := users.Namespace("admins:")
+	nested.Set("root", 1)
+	if !c.Has("users:admins:root") {
+		t.Fatal("nested namespace should combine the prefixes")
+	}
+
+	users.DeletePrefix()
+	if users.Has("b") || nested.Has("root") || c.Size() != 3 {
+		t.Fatalf("only the namespace items should be deleted, size: %d", c.Size())
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"time"
+
+	"github.com/maypok86/otter/internal/core"
+)
+
+// ReadOnlyCache is an immutable point-in-time snapshot of a cache.
+//
+// It serves the reads from the state captured by ReadOnlySnapshot and is not affected by the subsequent writes,
+// evictions and expirations of the cache. It keeps the references to the captured items, so its memory cost is
+// proportional to the size of the cache at the time of the snapshot.
+type ReadOnlyCache[K comparable, V any] struct {
+	snapshot *core.ReadOnlySnapshot[K, V]
+}
+
+// ReadOnlySnapshot captures the live items of the cache into an immutable snapshot.
+//
+// The items written concurrently with the call may be missed. Unlike Freeze, it doesn't affect the cache itself.
+func (bs baseCache[K, V]) ReadOnlySnapshot() ReadOnlyCache[K, V] {
+	return ReadOnlyCache[K, V]{
+		snapshot: bs.cache.ReadOnlySnapshot(),
+	}
+}
+
+// Has checks if there is an item with the given key in the snapshot.
+func (c ReadOnlyCache[K, V]) Has(key K) bool {
+	_, ok := c.snapshot.Get(key)
+	return ok
+}
+
+// Get returns the value associated with the key in the snapshot.
+func (c ReadOnlyCache[K, V]) Get(key K) (V, bool) {
+	return c.snapshot.Get(key)
+}
+
+// ExpiresAt returns the time when the item with the given key expires in the original cache.
+// The item is still available in the snapshot after this time.
+//
+// It returns false if the item is not found or doesn't expire.
+func (c ReadOnlyCache[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	return c.snapshot.ExpiresAt(key)
+}
+
+// Range iterates over all items in the snapshot.
+//
+// Iteration stops early when the given function returns false.
+func (c ReadOnlyCache[K, V]) Range(f func(key K, value V) bool) {
+	c.snapshot.Range(f)
+}
+
+// Size returns the number of items in the snapshot.
+func (c ReadOnlyCache[K, V]) Size() int {
+	return c.snapshot.Size()
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadOnlyCache(t *testing.T) {
+	c, err := MustBuilder[int, int](100).WithTTL(time.Hour).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		c.Set(i, i)
+	}
+
+	snapshot := c.ReadOnlySnapshot()
+	c.Set(0, 100)
+	c.Delete(1)
+	c.Set(10, 10)
+
+	if snapshot.Size() != 10 {
+		t.Fatalf("snapshot.Size() = %d, want = 10", snapshot.Size())
+	}
+	if v, ok := snapshot.Get(0); !ok || v != 0 {
+		t.Fatalf("snapshot.Get(0) = %d, %v, want = 0, true", v, ok)
+	}
+	if !snapshot.Has(1) || snapshot.Has(10) {
+		t.Fatal("snapshot should not be affected by the writes")
+	}
+	if expiresAt, ok := snapshot.ExpiresAt(2); !ok || time.Until(expiresAt) < 50*time.Minute {
+		t.Fatalf("unexpected expiration time: %v, %v", expiresAt, ok)
+	}
+
+	sum := 0
+	snapshot.Range(func(key int, value int) bool {
+		sum += value
+		return true
+	})
+	if sum != 45 {
+		t.Fatalf("sum of the values = %d, want = 45", sum)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memcached provides a server that exposes an otter cache over the memcached ASCII protocol.
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/maypok86/otter"
+)
+
+const (
+	maxValueSize = 1 << 20
+	// maxLineSize is the maximum length of a command line. It is the same as in memcached.
+	maxLineSize = 2048
+)
+
+var errClientError = errors.New("bad command line format")
+
+// MemcachedServer serves the get, set, delete, flush_all, stats and quit commands
+// of the memcached ASCII protocol on top of a cache.
+//
+// The flags and the expiration time of set are accepted but not stored: the items get
+// the ttl of the cache and the flags are always returned as 0. The command lines longer than
+// 2048 bytes are skipped with CLIENT_ERROR.
+type MemcachedServer[K ~string, V ~[]byte] struct {
+	cache otter.Cache[K, V]
+
+	mutex   sync.Mutex
+	closers map[io.Closer]struct{}
+	closed  bool
+}
+
+// NewMemcachedServer returns a server that exposes the cache over the memcached ASCII protocol.
+func NewMemcachedServer[K ~string, V ~[]byte](cache otter.Cache[K, V]) *MemcachedServer[K, V] {
+	return &MemcachedServer[K, V]{
+		cache:   cache,
+		closers: make(map[io.Closer]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP network address addr and then calls Serve.
+func (s *MemcachedServer[K, V]) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts the connections on the listener and serves each of them in a separate goroutine.
+// It returns net.ErrClosed after Close.
+func (s *MemcachedServer[K, V]) Serve(l net.Listener) error {
+	if !s.track(l) {
+		l.Close()
+		return net.ErrClosed
+	}
+	defer s.untrack(l)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return net.ErrClosed
+		}
+
+		go func() {
+			defer s.untrack(conn)
+			defer conn.Close()
+
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close closes all listeners and connections of the server.
+func (s *MemcachedServer[K, V]) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	for c := range s.closers {
+		c.Close()
+	}
+	return nil
+}
+
+func (s *MemcachedServer[K, V]) serveConn(conn net.Conn) {
+	r := bufio.NewReaderSize(conn, maxLineSize)
+	w := bufio.NewWriter(conn)
+	for {
+		// the line is read into the buffer of the reader, so a client can't make it grow without limit.
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// the rest of the line is skipped in the chunks of the buffer size.
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = r.ReadSlice('\n')
+			}
+			if err != nil {
+				return
+			}
+			fmt.Fprint(w, "CLIENT_ERROR line too long\r\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			fmt.Fprint(w, "ERROR\r\n")
+		} else if fields[0] == "quit" {
+			w.Flush()
+			return
+		} else if err := s.handle(r, w, fields); err != nil {
+			if !errors.Is(err, errClientError) {
+				return
+			}
+			fmt.Fprintf(w, "CLIENT_ERROR %s\r\n", err)
+		}
+
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *MemcachedServer[K, V]) handle(r *bufio.Reader, w *bufio.Writer, fields []string) error {
+	switch fields[0] {
+	case "get", "gets":
+		for _, key := range fields[1:] {
+			if value, ok := s.cache.Get(K(key)); ok {
+				fmt.Fprintf(w, "VALUE %s 0 %d\r\n", key, len(value))
+				w.Write(value)
+				w.WriteString("\r\n")
+			}
+		}
+		w.WriteString("END\r\n")
+	case "set":
+		// set <key> <flags> <exptime> <bytes> [noreply]
+		if len(fields) < 5 {
+			return errClientError
+		}
+		size, err := strconv.Atoi(fields[4])
+		if err != nil || size < 0 || size > maxValueSize {
+			return errClientError
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return err
+		}
+		if string(value[size:]) != "\r\n" {
+			return errClientError
+		}
+
+		reply := "STORED\r\n"
+		if !s.cache.Set(K(fields[1]), V(value[:size])) {
+			reply = "NOT_STORED\r\n"
+		}
+		writeReply(w, fields, 5, reply)
+	case "delete":
+		// delete <key> [noreply]
+		if len(fields) < 2 {
+			return errClientError
+		}
+		reply := "NOT_FOUND\r\n"
+		if s.cache.DeleteWithResult(K(fields[1])) {
+			reply = "DELETED\r\n"
+		}
+		writeReply(w, fields, 2, reply)
+	case "flush_all":
+		s.cache.Clear()
+		writeReply(w, fields, len(fields)-1, "OK\r\n")
+	case "stats":
+		stats := s.cache.Stats()
+		fmt.Fprintf(w, "STAT curr_items %d\r\n", s.cache.Size())
+		fmt.Fprintf(w, "STAT capacity %d\r\n", s.cache.Capacity())
+		fmt.Fprintf(w, "STAT get_hits %d\r\n", stats.Hits())
+		fmt.Fprintf(w, "STAT get_misses %d\r\n", stats.Misses())
+		fmt.Fprintf(w, "STAT evictions %d\r\n", stats.EvictedCount())
+		w.WriteString("END\r\n")
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return nil
+}
+
+// writeReply writes the reply unless the noreply option is passed at the given position.
+func writeReply(w *bufio.Writer, fields []string, noreplyIdx int, reply string) {
+	if noreplyIdx > 0 && noreplyIdx < len(fields) && fields[noreplyIdx] == "noreply" {
+		return
+	}
+	w.WriteString(reply)
+}
+
+// track registers the listener or the connection to close it on Close. It returns false if the server is closed.
+func (s *MemcachedServer[K, V]) track(c io.Closer) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.closers[c] = struct{}{}
+	return true
+}
+
+func (s *MemcachedServer[K, V]) untrack(c io.Closer) {
+	s.mutex.Lock()
+	delete(s.closers, c)
+	s.mutex.Unlock()
+}
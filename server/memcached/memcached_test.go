@@ -0,0 +1,96 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memcached
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/maypok86/otter"
+)
+
+func TestMemcachedServer(t *testing.T) {
+	cache, err := otter.MustBuilder[string, []byte](100).CollectStats().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("can not listen: %v", err)
+	}
+	s := NewMemcachedServer(cache)
+	go s.Serve(l)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("can not connect: %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	roundTrip := func(request string, lines int) string {
+		if _, err := io.WriteString(conn, request); err != nil {
+			t.Fatalf("can not write request %q: %v", request, err)
+		}
+		var sb strings.Builder
+		for i := 0; i < lines; i++ {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("can not read response to %q: %v", request, err)
+			}
+			sb.WriteString(line)
+		}
+		return sb.String()
+	}
+
+	tests := []struct {
+		request string
+		lines   int
+		want    string
+	}{
+		{request: "set a 0 0 5\r\nhello\r\n", lines: 1, want: "STORED\r\n"},
+		{request: "set b 0 0 1 noreply\r\nb\r\n", lines: 0, want: ""},
+		{request: "get a b c\r\n", lines: 5, want: "VALUE a 0 5\r\nhello\r\nVALUE b 0 1\r\nb\r\nEND\r\n"},
+		{request: "delete a\r\n", lines: 1, want: "DELETED\r\n"},
+		{request: "delete a\r\n", lines: 1, want: "NOT_FOUND\r\n"},
+		{request: "set c 0 0 x\r\n", lines: 1, want: "CLIENT_ERROR bad command line format\r\n"},
+		{request: "unknown\r\n", lines: 1, want: "ERROR\r\n"},
+		{request: "flush_all\r\n", lines: 1, want: "OK\r\n"},
+		{request: "get b\r\n", lines: 1, want: "END\r\n"},
+	}
+	for _, tt := range tests {
+		if got := roundTrip(tt.request, tt.lines); got != tt.want {
+			t.Fatalf("response to %q = %q, want = %q", tt.request, got, tt.want)
+		}
+	}
+
+	if got := roundTrip("stats\r\n", 6); !strings.HasPrefix(got, "STAT curr_items 0\r\n") || !strings.HasSuffix(got, "END\r\n") {
+		t.Fatalf("unexpected stats: %q", got)
+	}
+
+	// the command line is bounded, and the too long lines are skipped.
+	want := "CLIENT_ERROR line too long\r\n"
+	if got := roundTrip("get "+strings.Repeat("a", 2*maxLineSize)+"\r\n", 1); got != want {
+		t.Fatalf("response to a too long line = %q, want = %q", got, want)
+	}
+	if got := roundTrip("get b\r\n", 1); got != "END\r\n" {
+		t.Fatalf("the connection should be usable after a too long line, but got %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SnapshotEntry is an item of a cache snapshot.
+type SnapshotEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// ExpiresAt is the expiration time of the item or the zero time if the item doesn't expire.
+	ExpiresAt time.Time
+}
+
+// SnapshotCodec encodes and decodes the cache snapshots.
+type SnapshotCodec[K comparable, V any] interface {
+	Encode(w io.Writer, entries []SnapshotEntry[K, V]) error
+	Decode(r io.Reader) ([]SnapshotEntry[K, V], error)
+}
+
+type gobCodec[K comparable, V any] struct{}
+
+// GobCodec returns a binary SnapshotCodec based on encoding/gob.
+//
+// If K or V contains interfaces, then the concrete types stored in them must be passed as extraTypes,
+// so that they are registered in gob.
+func GobCodec[K comparable, V any](extraTypes ...any) SnapshotCodec[K, V] {
+	for _, t := range extraTypes {
+		gob.Register(t)
+	}
+	return gobCodec[K, V]{}
+}
+
+func (gobCodec[K, V]) Encode(w io.Writer, entries []SnapshotEntry[K, V]) error {
+	bw := bufio.NewWriter(w)
+	if err := gob.NewEncoder(bw).Encode(entries); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (gobCodec[K, V]) Decode(r io.Reader) ([]SnapshotEntry[K, V], error) {
+	var entries []SnapshotEntry[K, V]
+	if err := gob.NewDecoder(bufio.NewReader(r)).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+type jsonCodec[K comparable, V any] struct{}
+
+// JSONCodec returns a human-readable SnapshotCodec based on encoding/json.
+func JSONCodec[K comparable, V any]() SnapshotCodec[K, V] {
+	return jsonCodec[K, V]{}
+}
+
+func (jsonCodec[K, V]) Encode(w io.Writer, entries []SnapshotEntry[K, V]) error {
+	bw := bufio.NewWriter(w)
+	if err := json.NewEncoder(bw).Encode(entries); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (jsonCodec[K, V]) Decode(r io.Reader) ([]SnapshotEntry[K, V], error) {
+	var entries []SnapshotEntry[K, V]
+	if err := json.NewDecoder(bufio.NewReader(r)).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SaveSnapshot encodes all live items of the cache with their expiration times into w.
+//
+// The items written concurrently may be missed.
+func (bs baseCache[K, V]) SaveSnapshot(w io.Writer, codec SnapshotCodec[K, V]) error {
+	entries := make([]SnapshotEntry[K, V], 0, bs.Size())
+	bs.cache.RangeWithExpiration(func(key K, value V, expiresAt time.Time) bool {
+		entries = append(entries, SnapshotEntry[K, V]{
+			Key:       key,
+			Value:     value,
+			ExpiresAt: expiresAt,
+		})
+		return true
+	})
+	return codec.Encode(w, entries)
+}
+
+// LoadSnapshot decodes the snapshot from r and stores its items in the cache. The items get the ttl of the cache,
+// and the items that have already expired are skipped.
+//
+// It returns the number of stored items.
+func (c Cache[K, V]) LoadSnapshot(r io.Reader, codec SnapshotCodec[K, V]) (int, error) {
+	entries, err := codec.Decode(r)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	loaded := 0
+	for _, e := range entries {
+		if !e.ExpiresAt.IsZero() && !e.ExpiresAt.After(now) {
+			continue
+		}
+		if c.Set(e.Key, e.Value) {
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// LoadSnapshot decodes the snapshot from r and stores its items in the cache. The items keep their remaining ttl,
+// the items without an expiration time get defaultTTL, and the items that have already expired are skipped.
+//
+// It returns the number of stored items.
+func (c CacheWithVariableTTL[K, V]) LoadSnapshot(
+	r io.Reader,
+	codec SnapshotCodec[K, V],
+	defaultTTL time.Duration,
+) (int, error) {
+	entries, err := codec.Decode(r)
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	for _, e := range entries {
+		ttl := defaultTTL
+		if !e.ExpiresAt.IsZero() {
+			ttl = time.Until(e.ExpiresAt)
+		}
+		if ttl <= 0 {
+			continue
+		}
+		if c.Set(e.Key, e.Value, ttl) {
+			loaded++
+		}
+	}
+	return loaded, nil
+}
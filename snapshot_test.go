@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type snapshotValue struct {
+	Name string
+}
+
+func TestSnapshot_Gob(t *testing.T) {
+	src, err := MustBuilder[int, any](100).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		src.Set(i, snapshotValue{Name: "v"}, time.Hour)
+	}
+
+	var buf bytes.Buffer
+	codec := GobCodec[int, any](snapshotValue{})
+	if err := src.SaveSnapshot(&buf, codec); err != nil {
+		t.Fatalf("can not save snapshot: %v", err)
+	}
+
+	dst, err := MustBuilder[int, any](100).WithVariableTTL().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	loaded, err := dst.LoadSnapshot(&buf, codec, time.Minute)
+	if err != nil || loaded != 10 {
+		t.Fatalf("LoadSnapshot() = %d, %v, want = 10, nil", loaded, err)
+	}
+	if v, ok := dst.Get(1); !ok || v.(snapshotValue).Name != "v" {
+		t.Fatalf("unexpected value: %v, %v", v, ok)
+	}
+	if within := dst.ExpiringWithin(30 * time.Minute); within != 0 {
+		t.Fatalf("the items should keep their ttl, but %d expire within 30 minutes", within)
+	}
+}
+
+func TestSnapshot_JSON(t *testing.T) {
+	src, err := MustBuilder[string, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	src.Set("a", 1)
+	src.Set("b", 2)
+
+	var buf bytes.Buffer
+	codec := JSONCodec[string, int]()
+	if err := src.SaveSnapshot(&buf, codec); err != nil {
+		t.Fatalf("can not save snapshot: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"Key":"a"`)) {
+		t.Fatalf("snapshot should be human-readable: %s", buf.String())
+	}
+
+	dst, err := MustBuilder[string, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	if loaded, err := dst.LoadSnapshot(&buf, codec); err != nil || loaded != 2 {
+		t.Fatalf("LoadSnapshot() = %d, %v, want = 2, nil", loaded, err)
+	}
+	if v, ok := dst.Get("b"); !ok || v != 2 {
+		t.Fatalf("dst.Get(b) = %d, %v, want = 2, true", v, ok)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlcache provides a cache of the database/sql query results.
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/maypok86/otter"
+)
+
+// SQLCache caches the decoded results of the SQL queries keyed by the query and its arguments.
+type SQLCache[V any] struct {
+	cache   otter.Cache[string, V]
+	decoder func(rows *sql.Rows) (V, error)
+}
+
+// NewSQLCache returns a cache of the query results decoded by decoder.
+//
+// The decoder is given the rows right after the query execution, so it should iterate over them by itself.
+func NewSQLCache[V any](cache otter.Cache[string, V], decoder func(rows *sql.Rows) (V, error)) *SQLCache[V] {
+	return &SQLCache[V]{
+		cache:   cache,
+		decoder: decoder,
+	}
+}
+
+// QueryRow returns the cached result of the query with the given arguments. On a miss it executes the query,
+// decodes the result and caches it.
+//
+// The errors of the query and the decoder are returned as is and the result is not cached.
+// The queries with the arguments that can't be converted to the driver values are not cached either.
+func (sc *SQLCache[V]) QueryRow(ctx context.Context, db *sql.DB, query string, args ...any) (V, error) {
+	key, ok := cacheKey(query, args)
+	if !ok {
+		return sc.query(ctx, db, query, args)
+	}
+	if v, ok := sc.cache.Get(key); ok {
+		return v, nil
+	}
+
+	v, err := sc.query(ctx, db, query, args)
+	if err != nil {
+		return v, err
+	}
+
+	sc.cache.Set(key, v)
+	return v, nil
+}
+
+func (sc *SQLCache[V]) query(ctx context.Context, db *sql.DB, query string, args []any) (V, error) {
+	var zero V
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return zero, err
+	}
+	defer rows.Close()
+
+	v, err := sc.decoder(rows)
+	if err != nil {
+		return zero, err
+	}
+	if err := rows.Err(); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// Invalidate deletes the cached result of the query with the given arguments.
+//
+// It returns otter.ErrFrozen if the cache is in the read-only mode, so the stale result is still cached.
+func (sc *SQLCache[V]) Invalidate(query string, args ...any) error {
+	key, ok := cacheKey(query, args)
+	if !ok {
+		return nil
+	}
+	return sc.cache.TryDelete(key)
+}
+
+// cacheKey returns the cache key of the query or false if the arguments can't be keyed.
+//
+// The arguments are converted to the driver values first, so that the pointers and the driver.Valuer
+// implementations are keyed by the values they hold and not by their addresses. The values are formatted
+// with their types, so that, for example, 1 and "1" produce different keys.
+func cacheKey(query string, args []any) (string, bool) {
+	values := make([]any, 0, len(args))
+	for _, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			v, err := driver.DefaultParameterConverter.ConvertValue(named.Value)
+			if err != nil {
+				return "", false
+			}
+			values = append(values, sql.NamedArg{Name: named.Name, Value: v})
+			continue
+		}
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return "", false
+		}
+		values = append(values, v)
+	}
+	return query + "\x00" + fmt.Sprintf("%#v", values), true
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maypok86/otter"
+)
+
+var queries atomic.Int64
+
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) {
+	return testConn{}, nil
+}
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) {
+	return testStmt{}, nil
+}
+
+func (testConn) Close() error {
+	return nil
+}
+
+func (testConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type testStmt struct{}
+
+func (testStmt) Close() error {
+	return nil
+}
+
+func (testStmt) NumInput() int {
+	return -1
+}
+
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+// Query returns a single row with the first argument.
+func (testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	queries.Add(1)
+	return &testRows{value: args[0]}, nil
+}
+
+type testRows struct {
+	value driver.Value
+	done  bool
+}
+
+func (r *testRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestSQLCache(t *testing.T) {
+	sql.Register("sqlcache_test", testDriver{})
+	db, err := sql.Open("sqlcache_test", "")
+	if err != nil {
+		t.Fatalf("can not open db: %v", err)
+	}
+	defer db.Close()
+
+	cache, err := otter.MustBuilder[string, int64](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	sc := NewSQLCache(cache, func(rows *sql.Rows) (int64, error) {
+		var v int64
+		if rows.Next() {
+			if err := rows.Scan(&v); err != nil {
+				return 0, err
+			}
+		}
+		return v, nil
+	})
+
+	ctx := context.Background()
+	const query = "SELECT value FROM t WHERE id = ?"
+	for i := 0; i < 3; i++ {
+		v, err := sc.QueryRow(ctx, db, query, int64(1))
+		if err != nil || v != 1 {
+			t.Fatalf("QueryRow(1) = %d, %v, want = 1, nil", v, err)
+		}
+	}
+	if v, err := sc.QueryRow(ctx, db, query, int64(2)); err != nil || v != 2 {
+		t.Fatalf("QueryRow(2) = %d, %v, want = 2, nil", v, err)
+	}
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("the query should be executed once per arguments, but was executed %d times", n)
+	}
+
+	if err := sc.Invalidate(query, int64(1)); err != nil {
+		t.Fatalf("can not invalidate the query: %v", err)
+	}
+	if _, err := sc.QueryRow(ctx, db, query, int64(1)); err != nil {
+		t.Fatalf("QueryRow(1) failed: %v", err)
+	}
+	if n := queries.Load(); n != 3 {
+		t.Fatalf("the query should be executed again after the invalidation, but was executed %d times", n)
+	}
+
+	// the pointers are keyed by the values they point to.
+	arg := int64(3)
+	if v, err := sc.QueryRow(ctx, db, query, &arg); err != nil || v != 3 {
+		t.Fatalf("QueryRow(&3) = %d, %v, want = 3, nil", v, err)
+	}
+	arg = 4
+	if v, err := sc.QueryRow(ctx, db, query, &arg); err != nil || v != 4 {
+		t.Fatalf("QueryRow(&4) = %d, %v, want = 4, nil", v, err)
+	}
+	if v, err := sc.QueryRow(ctx, db, query, int64(4)); err != nil || v != 4 || queries.Load() != 5 {
+		t.Fatalf("QueryRow(4) = %d, %v, the cached result of the pointer should be used", v, err)
+	}
+
+	// the driver.Valuer implementations are keyed by their values.
+	if v, err := sc.QueryRow(ctx, db, query, sql.NullInt64{Int64: 5, Valid: true}); err != nil || v != 5 {
+		t.Fatalf("QueryRow(NullInt64(5)) = %d, %v, want = 5, nil", v, err)
+	}
+	if v, err := sc.QueryRow(ctx, db, query, sql.NullInt64{Int64: 6, Valid: true}); err != nil || v != 6 {
+		t.Fatalf("QueryRow(NullInt64(6)) = %d, %v, want = 6, nil", v, err)
+	}
+
+	if err := cache.Freeze(); err != nil {
+		t.Fatalf("can not freeze cache: %v", err)
+	}
+	if err := sc.Invalidate(query, int64(2)); !errors.Is(err, otter.ErrFrozen) {
+		t.Fatalf("Invalidate() = %v, want = %v", err, otter.ErrFrozen)
+	}
+}
@@ -16,26 +16,67 @@ package otter
 
 import (
 	"math"
+	"time"
 
+	"github.com/maypok86/otter/internal/core"
 	"github.com/maypok86/otter/internal/stats"
 )
 
+// StatsRecorder receives the statistics events as they happen. It can forward them to a metrics system
+// or count only the events of interest. See Builder.RecordStats.
+type StatsRecorder = stats.Recorder
+
 // Stats is a statistics snapshot.
 type Stats struct {
-	hits         int64
-	misses       int64
-	rejectedSets int64
-	evictedCount int64
-	evictedCost  int64
+	hits           int64
+	misses         int64
+	expiredMisses  int64
+	rejectedSets   int64
+	evictedCount   int64
+	evictedCost    int64
+	insertedCount  int64
+	insertedCost   int64
+	lockContention int64
+	expiredCount   int64
+
+	writeBufferUtilization float64
+	writeBufferOverflows   int64
+	inserted               int64
+	admissionRejections    int64
+	grows                  int64
+	shrinks                int64
+
+	cleanupCycles      int64
+	cleanupDuration    time.Duration
+	maxCleanupDuration time.Duration
+
+	p50WriteLatency time.Duration
+	p99WriteLatency time.Duration
+	maxWriteLatency time.Duration
 }
 
 func newStats(s *stats.Stats) Stats {
 	return Stats{
-		hits:         negativeToMax(s.Hits()),
-		misses:       negativeToMax(s.Misses()),
-		rejectedSets: negativeToMax(s.RejectedSets()),
-		evictedCount: negativeToMax(s.EvictedCount()),
-		evictedCost:  negativeToMax(s.EvictedCost()),
+		hits:           negativeToMax(s.Hits()),
+		misses:         negativeToMax(s.Misses()),
+		expiredMisses:  negativeToMax(s.ExpiredMisses()),
+		rejectedSets:   negativeToMax(s.RejectedSets()),
+		evictedCount:   negativeToMax(s.EvictedCount()),
+		evictedCost:    negativeToMax(s.EvictedCost()),
+		insertedCount:  negativeToMax(s.InsertedCount()),
+		insertedCost:   negativeToMax(s.InsertedCost()),
+		lockContention: negativeToMax(s.LockContention()),
+		expiredCount:   negativeToMax(s.ExpiredCount()),
+
+		writeBufferOverflows: negativeToMax(s.WriteBufferOverflows()),
+
+		cleanupCycles:      negativeToMax(s.CleanupCycles()),
+		cleanupDuration:    time.Duration(negativeToMax(int64(s.TotalCleanupDuration()))),
+		maxCleanupDuration: s.MaxCleanupDuration(),
+
+		p50WriteLatency: s.WriteLatencyQuantile(0.5),
+		p99WriteLatency: s.WriteLatencyQuantile(0.99),
+		maxWriteLatency: s.MaxWriteLatency(),
 	}
 }
 
@@ -49,6 +90,24 @@ func (s Stats) Misses() int64 {
 	return s.misses
 }
 
+// ExpiredMisses returns the number of cache misses caused by the expired items.
+//
+// A large share of such misses means that the ttl may be too short.
+func (s Stats) ExpiredMisses() int64 {
+	return s.expiredMisses
+}
+
+// AbsentMisses returns the number of cache misses caused by the absent items (never written, deleted or evicted).
+//
+// A large share of such misses means that the capacity may be too small.
+func (s Stats) AbsentMisses() int64 {
+	// the counters are read separately, so the snapshot may be slightly inconsistent.
+	if s.expiredMisses > s.misses {
+		return 0
+	}
+	return s.misses - s.expiredMisses
+}
+
 // Ratio returns the cache hit ratio.
 func (s Stats) Ratio() float64 {
 	requests := checkedAdd(s.hits, s.misses)
@@ -73,6 +132,196 @@ func (s Stats) EvictedCost() int64 {
 	return s.evictedCost
 }
 
+// InsertedCount returns the number of entries inserted into the cache, including the updates.
+// The entries rejected because of their cost are not counted.
+func (s Stats) InsertedCount() int64 {
+	return s.insertedCount
+}
+
+// InsertedCost returns the sum of costs of entries inserted into the cache, including the updates.
+//
+// For a cache weighted by the size of the values in bytes, it is the write throughput in bytes.
+func (s Stats) InsertedCost() int64 {
+	return s.insertedCost
+}
+
+// AverageEntryCost returns the average cost of the entries inserted into the cache.
+// It returns 0 if no entries have been inserted.
+func (s Stats) AverageEntryCost() float64 {
+	if s.insertedCount == 0 {
+		return 0.0
+	}
+	return float64(s.insertedCost) / float64(s.insertedCount)
+}
+
+// LockContention returns the number of times the internal eviction lock was contended,
+// i.e. a write or cleanup operation had to wait for it. The reads never wait for the lock:
+// they leave their batches to its holder.
+func (s Stats) LockContention() int64 {
+	return s.lockContention
+}
+
+// ExpiredCount returns the number of expired entries removed by the cleanup.
+func (s Stats) ExpiredCount() int64 {
+	return s.expiredCount
+}
+
+// WriteBufferUtilization returns the fill level of the write buffer in [0, 1] at the time of the snapshot.
+func (s Stats) WriteBufferUtilization() float64 {
+	return s.writeBufferUtilization
+}
+
+// WriteBufferOverflows returns the number of times the write buffer was found more than 90% full.
+// The growth of this counter signals the impending write buffer saturation.
+func (s Stats) WriteBufferOverflows() int64 {
+	return s.writeBufferOverflows
+}
+
+// AdmissionRejections returns the number of items rejected by the admission filter of the eviction policy:
+// the items evicted soon after the insertion, because they were not accessed enough to be promoted.
+// Unlike RejectedSets, it doesn't count the items rejected because of their cost.
+//
+// It is always 0 for the eviction policies without an admission filter (LRU and Sampled).
+func (s Stats) AdmissionRejections() int64 {
+	return s.admissionRejections
+}
+
+// Resizes returns the number of times the internal hash table has been resized.
+//
+// Each resize rehashes all the entries, and the concurrent writes wait for it to finish, so the resizes
+// can cause latency spikes, especially while the cache is warming up. Sampling the counter over time allows
+// to correlate the resizes with the latency graphs, and InitialCapacity allows to avoid the grows.
+func (s Stats) Resizes() int64 {
+	return s.grows + s.shrinks
+}
+
+// Grows returns the number of times the internal hash table has been expanded.
+func (s Stats) Grows() int64 {
+	return s.grows
+}
+
+// Shrinks returns the number of times the internal hash table has been shrunk after the deletions.
+func (s Stats) Shrinks() int64 {
+	return s.shrinks
+}
+
+// AdmissionRate returns the share of the items inserted into the eviction policy that were not rejected
+// by its admission filter. It returns 0 if no items have been inserted.
+func (s Stats) AdmissionRate() float64 {
+	if s.inserted == 0 {
+		return 0.0
+	}
+	return float64(s.inserted-s.admissionRejections) / float64(s.inserted)
+}
+
+// CleanupCycles returns the number of the periodic expiration cleanup cycles.
+func (s Stats) CleanupCycles() int64 {
+	return s.cleanupCycles
+}
+
+// TotalCleanupDuration returns the total time spent in the periodic expiration cleanup cycles.
+func (s Stats) TotalCleanupDuration() time.Duration {
+	return s.cleanupDuration
+}
+
+// MaxCleanupDuration returns the duration of the longest periodic expiration cleanup cycle.
+//
+// The cleanup runs once a second, so if it approaches a second, then the cache is under a heavy expiry load.
+func (s Stats) MaxCleanupDuration() time.Duration {
+	return s.maxCleanupDuration
+}
+
+// P50WriteLatency returns the approximate median time the writes waited in the write buffer
+// before they were applied to the eviction policy. The latency is rounded up to a power of two nanoseconds.
+//
+// It is always 0 if the write latency tracking is disabled.
+func (s Stats) P50WriteLatency() time.Duration {
+	return s.p50WriteLatency
+}
+
+// P99WriteLatency returns the approximate 99th percentile of the time the writes waited in the write buffer
+// before they were applied to the eviction policy. The latency is rounded up to a power of two nanoseconds.
+//
+// It is always 0 if the write latency tracking is disabled.
+func (s Stats) P99WriteLatency() time.Duration {
+	return s.p99WriteLatency
+}
+
+// MaxWriteLatency returns the max time a write waited in the write buffer.
+//
+// It is always 0 if the write latency tracking is disabled.
+func (s Stats) MaxWriteLatency() time.Duration {
+	return s.maxWriteLatency
+}
+
+// Metrics is a snapshot of the cache metrics with stable JSON field names.
+type Metrics struct {
+	// Timestamp is the time when the snapshot was taken.
+	Timestamp    time.Time `json:"timestamp"`
+	Hits         int64     `json:"hits"`
+	Misses       int64     `json:"misses"`
+	Ratio        float64   `json:"ratio"`
+	Size         int       `json:"size"`
+	WeightedSize int       `json:"weighted_size"`
+	Capacity     int       `json:"capacity"`
+	Evictions    int64     `json:"evictions"`
+	Expirations  int64     `json:"expirations"`
+}
+
+// DryRunStats is a snapshot of the evictions simulated in the dry-run mode.
+type DryRunStats struct {
+	evictedCount int64
+	evictedCost  int64
+}
+
+// EvictedCount returns the number of entries that would have been evicted.
+func (s DryRunStats) EvictedCount() int64 {
+	return s.evictedCount
+}
+
+// EvictedCost returns the sum of costs of entries that would have been evicted.
+func (s DryRunStats) EvictedCost() int64 {
+	return s.evictedCost
+}
+
+// CostHistogram is a histogram of the item costs with exponentially spaced buckets: 1, 2, 4, 8, ..., 65536+.
+type CostHistogram struct {
+	counts [core.CostDistributionBuckets]int
+}
+
+func newCostHistogram(counts []int) CostHistogram {
+	var h CostHistogram
+	copy(h.counts[:], counts)
+	return h
+}
+
+// Buckets returns the number of buckets in the histogram.
+func (h CostHistogram) Buckets() int {
+	return len(h.counts)
+}
+
+// LowerBound returns the minimum cost of the items in the i-th bucket.
+//
+// The i-th bucket contains the items with cost in [2^i, 2^(i+1)), except for the last one,
+// which contains all items with cost >= 2^i. The items with zero cost are counted in the first bucket.
+func (h CostHistogram) LowerBound(i int) uint32 {
+	return 1 << i
+}
+
+// Count returns the number of items in the i-th bucket.
+func (h CostHistogram) Count(i int) int {
+	return h.counts[i]
+}
+
+// Total returns the total number of items in the histogram.
+func (h CostHistogram) Total() int {
+	total := 0
+	for _, c := range h.counts {
+		total += c
+	}
+	return total
+}
+
 func checkedAdd(a, b int64) int64 {
 	naiveSum := a + b
 	if (a^b) < 0 || (a^naiveSum) >= 0 {
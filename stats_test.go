@@ -16,7 +16,9 @@ package otter
 
 import (
 	"math"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestStats(t *testing.T) {
@@ -59,3 +61,144 @@ func TestStats(t *testing.T) {
 		t.Fatalf("not valid evicted cost. want %d, got %d", expected, s.EvictedCost())
 	}
 }
+
+func TestStats_Misses(t *testing.T) {
+	s := Stats{
+		misses:        10,
+		expiredMisses: 3,
+	}
+	if s.ExpiredMisses() != 3 || s.AbsentMisses() != 7 {
+		t.Fatalf("not valid misses. want 3 expired and 7 absent, got %d and %d", s.ExpiredMisses(), s.AbsentMisses())
+	}
+}
+
+func TestStats_InsertedCost(t *testing.T) {
+	c, err := MustBuilder[int, int](1000).
+		CollectStats().
+		Cost(func(key int, value int) uint32 {
+			return uint32(value)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		c.Set(i, i*10)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	s := c.Stats()
+	if s.InsertedCount() != 4 || s.InsertedCost() != 100 {
+		t.Fatalf("not valid inserted stats. want 4 and 100, got %d and %d", s.InsertedCount(), s.InsertedCost())
+	}
+	if s.AverageEntryCost() != 25 {
+		t.Fatalf("not valid average entry cost. want 25, got %.2f", s.AverageEntryCost())
+	}
+}
+
+func TestStats_Resizes(t *testing.T) {
+	const size = 10000
+	c, err := MustBuilder[int, int](size).CollectStats().Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	if s := c.Stats(); s.Grows() == 0 || s.Resizes() != s.Grows()+s.Shrinks() {
+		t.Fatalf("hash table should be grown, but got %d grows and %d resizes", s.Grows(), s.Resizes())
+	}
+
+	c, err = MustBuilder[int, int](size).CollectStats().InitialCapacity(size).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+	for i := 0; i < size; i++ {
+		c.Set(i, i)
+	}
+	if s := c.Stats(); s.Resizes() != 0 {
+		t.Fatalf("hash table should not be resized with enough initial capacity, but got %d resizes", s.Resizes())
+	}
+}
+
+func TestStats_Disabled(t *testing.T) {
+	const size = 10
+	c, err := MustBuilder[int, int](size).WithTTL(time.Hour).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 3*size; i++ {
+		c.Set(i, i)
+		c.Get(i)
+		c.Get(-i - 1)
+		c.SetIfAbsent(i, i)
+	}
+	c.Delete(0)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+
+	if s := c.Stats(); s != (Stats{}) {
+		t.Fatalf("stats should be empty when disabled, got %+v", s)
+	}
+	c.Close()
+}
+
+type countingRecorder struct {
+	hits         atomic.Int64
+	misses       atomic.Int64
+	rejectedSets atomic.Int64
+	evictions    atomic.Int64
+	evictedCost  atomic.Int64
+	expirations  atomic.Int64
+}
+
+func (r *countingRecorder) RecordHit()         { r.hits.Add(1) }
+func (r *countingRecorder) RecordMiss()        { r.misses.Add(1) }
+func (r *countingRecorder) RecordRejectedSet() { r.rejectedSets.Add(1) }
+func (r *countingRecorder) RecordExpiration()  { r.expirations.Add(1) }
+
+func (r *countingRecorder) RecordEviction(cost uint32) {
+	r.evictions.Add(1)
+	r.evictedCost.Add(int64(cost))
+}
+
+func TestStats_Recorder(t *testing.T) {
+	const size = 10
+	r := &countingRecorder{}
+	c, err := MustBuilder[int, int](size).
+		RecordStats(r).
+		Cost(func(key int, value int) uint32 {
+			return uint32(value)
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	for i := 0; i < 3*size; i++ {
+		c.Set(i, 1)
+	}
+	c.Set(-1, size+1)
+	if err := c.Verify(); err != nil {
+		t.Fatalf("cache is inconsistent: %v", err)
+	}
+	for i := 0; i < 3*size; i++ {
+		c.Get(i)
+	}
+
+	s := c.Stats()
+	if r.hits.Load() != s.Hits() || r.misses.Load() != s.Misses() || r.hits.Load()+r.misses.Load() != 3*size {
+		t.Fatalf("recorder should receive all reads, got %d hits and %d misses", r.hits.Load(), r.misses.Load())
+	}
+	if r.rejectedSets.Load() != 1 || s.RejectedSets() != 1 {
+		t.Fatalf("recorder should receive the rejected set, got %d", r.rejectedSets.Load())
+	}
+	if r.evictions.Load() != s.EvictedCount() || r.evictedCost.Load() != s.EvictedCost() || r.evictions.Load() == 0 {
+		t.Fatalf("recorder should receive all evictions, got %d with cost %d", r.evictions.Load(), r.evictedCost.Load())
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+// SyncMapAdapter is an adapter of a Cache with the API of sync.Map.
+//
+// It makes the migration from sync.Map a drop-in replacement for most uses, but the semantics differ:
+//   - the items can be evicted or expire at any time, so a Load after a Store may miss;
+//   - Store may drop the item if it has too much cost or if the cache is frozen;
+//   - Range doesn't take a snapshot of the cache, so it may miss the recently inserted keys.
+type SyncMapAdapter[K comparable, V any] struct {
+	cache Cache[K, V]
+}
+
+// NewSyncMap creates a cache with the given capacity and returns its adapter with the API of sync.Map.
+// The adapter owns the cache, so it should be closed with Close when it is no longer needed.
+//
+// The keys and the values are typed, so the type assertions of the sync.Map users can be removed.
+func NewSyncMap[K comparable, V any](capacity int) (*SyncMapAdapter[K, V], error) {
+	b, err := NewBuilder[K, V](capacity)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return NewSyncMapAdapter(cache), nil
+}
+
+// NewSyncMapAdapter returns an adapter of the cache with the API of sync.Map.
+func NewSyncMapAdapter[K comparable, V any](cache Cache[K, V]) *SyncMapAdapter[K, V] {
+	return &SyncMapAdapter[K, V]{
+		cache: cache,
+	}
+}
+
+// Load returns the value stored in the cache for a key, or the zero value if no value is present.
+// The ok result indicates whether value was found in the cache.
+func (m *SyncMapAdapter[K, V]) Load(key K) (value V, ok bool) {
+	return m.cache.Get(key)
+}
+
+// Store sets the value for a key.
+func (m *SyncMapAdapter[K, V]) Store(key K, value V) {
+	m.cache.Set(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *SyncMapAdapter[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return m.cache.GetOrSet(key, value)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *SyncMapAdapter[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	return m.cache.GetAndDelete(key)
+}
+
+// Delete deletes the value for a key.
+func (m *SyncMapAdapter[K, V]) Delete(key K) {
+	m.cache.Delete(key)
+}
+
+// Range calls f sequentially for each key and value present in the cache. If f returns false, range stops the iteration.
+func (m *SyncMapAdapter[K, V]) Range(f func(key K, value V) bool) {
+	m.cache.Range(f)
+}
+
+// Close closes the cache of the adapter and stops its goroutine.
+// The cache created by NewSyncMap is owned by the adapter, so Close must be called when the adapter is no longer needed.
+func (m *SyncMapAdapter[K, V]) Close() {
+	m.cache.Close()
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import "testing"
+
+func TestSyncMapAdapter(t *testing.T) {
+	c, err := MustBuilder[int, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	m := NewSyncMapAdapter(c)
+	m.Store(1, 1)
+	if v, ok := m.Load(1); !ok || v != 1 {
+		t.Fatalf("Load(1) = %d, %v, want = 1, true", v, ok)
+	}
+	if v, loaded := m.LoadOrStore(1, 2); !loaded || v != 1 {
+		t.Fatalf("LoadOrStore(1) = %d, %v, want = 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore(2, 2); loaded || v != 2 {
+		t.Fatalf("LoadOrStore(2) = %d, %v, want = 2, false", v, loaded)
+	}
+
+	count := 0
+	m.Range(func(key int, value int) bool {
+		count++
+		return true
+	})
+	if count != 2 {
+		t.Fatalf("Range should visit 2 items, but visited %d", count)
+	}
+
+	if v, loaded := m.LoadAndDelete(1); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete(1) = %d, %v, want = 1, true", v, loaded)
+	}
+	if _, loaded := m.LoadAndDelete(1); loaded {
+		t.Fatal("the key should be already deleted")
+	}
+
+	m.Delete(2)
+	if _, ok := m.Load(2); ok {
+		t.Fatal("the key should be deleted")
+	}
+}
+
+func TestNewSyncMap(t *testing.T) {
+	if _, err := NewSyncMap[string, int](0); err == nil {
+		t.Fatal("should fail with non-positive capacity")
+	}
+
+	m, err := NewSyncMap[string, int](100)
+	if err != nil {
+		t.Fatalf("can not create sync map: %v", err)
+	}
+
+	var syncMap interface {
+		Load(key string) (value int, ok bool)
+		Store(key string, value int)
+		LoadOrStore(key string, value int) (actual int, loaded bool)
+		LoadAndDelete(key string) (value int, loaded bool)
+		Delete(key string)
+		Range(f func(key string, value int) bool)
+	} = m
+
+	syncMap.Store("a", 1)
+	if v, ok := syncMap.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load(a) = %d, %v, want = 1, true", v, ok)
+	}
+	if v, loaded := syncMap.LoadAndDelete("a"); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete(a) = %d, %v, want = 1, true", v, loaded)
+	}
+
+	m.Close()
+	if !m.cache.IsClosed() {
+		t.Fatal("Close should close the cache of the adapter")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+// CacheView is a view of a Cache that only exposes the items matching a predicate.
+//
+// It allows multiple consumers to share one cache instance without key prefixing:
+// each consumer sees only its own entries, while the writes go to the underlying cache.
+type CacheView[K comparable, V any] struct {
+	cache     Cache[K, V]
+	predicate func(key K, value V) bool
+}
+
+// Filter returns a view of the cache in which only the items matching predicate are visible.
+func (c Cache[K, V]) Filter(predicate func(key K, value V) bool) CacheView[K, V] {
+	return CacheView[K, V]{
+		cache:     c,
+		predicate: predicate,
+	}
+}
+
+// Has checks if there is a visible item with the given key in the view.
+func (v CacheView[K, V]) Has(key K) bool {
+	_, ok := v.Get(key)
+	return ok
+}
+
+// Get returns the value associated with the key in the underlying cache.
+// If the item doesn't match the predicate of the view, then it is reported as a miss.
+func (v CacheView[K, V]) Get(key K) (V, bool) {
+	value, ok := v.cache.Get(key)
+	if !ok || !v.predicate(key, value) {
+		var zero V
+		return zero, false
+	}
+	return value, true
+}
+
+// Set associates the value with the key in the underlying cache.
+//
+// If it returns false, then the key-value item had too much cost and the Set was dropped.
+func (v CacheView[K, V]) Set(key K, value V) bool {
+	return v.cache.Set(key, value)
+}
+
+// Delete removes the association for this key from the underlying cache.
+func (v CacheView[K, V]) Delete(key K) {
+	v.cache.Delete(key)
+}
+
+// Range iterates over all items of the underlying cache that match the predicate of the view.
+//
+// Iteration stops early when the given function returns false.
+func (v CacheView[K, V]) Range(f func(key K, value V) bool) {
+	v.cache.Range(func(key K, value V) bool {
+		if !v.predicate(key, value) {
+			return true
+		}
+		return f(key, value)
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2024 Alexey Mayshev. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otter
+
+import "testing"
+
+func TestCacheView(t *testing.T) {
+	c, err := MustBuilder[int, int](100).Build()
+	if err != nil {
+		t.Fatalf("can not create cache: %v", err)
+	}
+
+	even := c.Filter(func(key int, value int) bool {
+		return value%2 == 0
+	})
+	for i := 0; i < 10; i++ {
+		even.Set(i, i)
+	}
+
+	if c.Size() != 10 {
+		t.Fatalf("all items should be stored in the underlying cache, size: %d", c.Size())
+	}
+	if v, ok := even.Get(2); !ok || v != 2 {
+		t.Fatalf("even.Get(2) = %d, %v, want = 2, true", v, ok)
+	}
+	if even.Has(3) {
+		t.Fatal("items not matching the predicate should not be visible")
+	}
+
+	count := 0
+	even.Range(func(key int, value int) bool {
+		if value%2 != 0 {
+			t.Fatalf("unexpected item in the view: %d", value)
+		}
+		count++
+		return true
+	})
+	if count != 5 {
+		t.Fatalf("the view should contain 5 items, got %d", count)
+	}
+
+	even.Delete(2)
+	if c.Has(2) {
+		t.Fatal("delete should remove the item from the underlying cache")
+	}
+}